@@ -0,0 +1,96 @@
+package skiplist
+
+import (
+	"math/rand"
+	"time"
+)
+
+// evictionSamples is the number of unpinned elements compared to pick each element evicted by
+// a list that tracks accesses.
+const evictionSamples = 16
+
+// LastAccess returns when the element was last accessed, if its list was constructed
+// WithAccessTracking, or the zero time otherwise. Gets are sampled at the rate set
+// WithStatsSampling, so the time may lag behind the latest Get.
+func (e *Element) LastAccess() time.Time {
+	if nanos := e.accessed.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// sampleAccess records a Get of element, or one in every statsSampling of them.
+func (list *SkipList) sampleAccess(element *Element) {
+	if list.statsSampling > 1 && rand.Int63n(int64(list.statsSampling)) != 0 {
+		return
+	}
+	element.accessed.Store(time.Now().UnixNano())
+}
+
+// Stale returns, in key order, the elements that have not been accessed for at least idle, to
+// report or expire stale entries. It returns nil unless the list was constructed
+// WithAccessTracking. Like any iteration, it does not lock the list.
+func (list *SkipList) Stale(idle time.Duration) []*Element {
+	if !list.trackAccess {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-idle).UnixNano()
+	var stale []*Element
+	for element := list.Front(); element != nil; element = element.Next() {
+		if element.accessed.Load() <= cutoff {
+			stale = append(stale, element)
+		}
+	}
+	return stale
+}
+
+// evictLeastRecent is evict for lists that track accesses. Finding the least recently accessed
+// element exactly would take a scan of the whole list, so each victim is the least recently
+// accessed of a sample of elements, in the manner of Redis's approximated LRU. Samples are taken
+// in turn around the list, so that every element is eventually considered.
+// The caller must hold the list mutex.
+func (list *SkipList) evictLeastRecent() []*Element {
+	var evicted []*Element
+	for list.weight > list.maxWeight {
+		victim := list.leastRecentSample()
+		if victim == nil {
+			break
+		}
+
+		list.unlink(list.getPrevElementNodes(victim.key), victim)
+		evicted = append(evicted, victim)
+		list.remember(victim)
+	}
+	return evicted
+}
+
+// leastRecentSample returns the least recently accessed of the next evictionSamples unpinned
+// elements from the list's eviction hand, which it moves past them, or nil if every element is
+// pinned. The caller must hold the list mutex.
+func (list *SkipList) leastRecentSample() *Element {
+	element := list.Front()
+	if list.evictHand != nil {
+		element = list.getPrevElementNodes(list.evictHand)[0].Next()
+	}
+
+	var victim *Element
+	for visited, sampled := 0, 0; visited < list.Length && sampled < evictionSamples; visited++ {
+		if element == nil {
+			element = list.Front()
+		}
+		if !list.held(element) {
+			sampled++
+			if victim == nil || element.accessed.Load() < victim.accessed.Load() {
+				victim = element
+			}
+		}
+		element = element.Next()
+	}
+
+	list.evictHand = nil
+	if element != nil {
+		list.evictHand = element.key
+	}
+	return victim
+}
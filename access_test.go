@@ -0,0 +1,53 @@
+package skiplist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessTracking(t *testing.T) {
+	var evicted []string
+	list := New(
+		WithAccessTracking(),
+		WithWeigher(func(key []byte, value interface{}) int64 { return 1 }),
+		WithMaxWeight(3),
+		WithRemoveCallback(func(e *Element, reason RemoveReason) {
+			evicted = append(evicted, string(e.Key()))
+		}),
+	)
+
+	before := time.Now()
+	for _, k := range []string{"a", "b", "c"} {
+		list.Set([]byte(k), nil)
+	}
+	a := list.Get([]byte("a"))
+	if a.LastAccess().Before(before) || a.LastAccess().After(time.Now()) {
+		t.Fatal("inserts must record an access", a.LastAccess())
+	}
+
+	// Pretend that b and c were accessed long ago, and then read c.
+	for _, k := range []string{"b", "c"} {
+		list.Get([]byte(k)).accessed.Store(before.Add(-time.Hour).UnixNano())
+	}
+	list.Get([]byte("c"))
+	if stale := list.Stale(time.Minute); len(stale) != 1 || string(stale[0].Key()) != "b" {
+		t.Fatal("wrong stale elements", stale)
+	}
+
+	list.Set([]byte("d"), nil)
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Fatal("the least recently accessed element must be evicted", evicted)
+	}
+
+	// Pinned elements are skipped however stale they are.
+	list.Get([]byte("a")).accessed.Store(1)
+	list.Pin([]byte("a"))
+	list.Set([]byte("e"), nil)
+	if len(evicted) != 2 || evicted[1] == "a" || list.Get([]byte("a")) == nil {
+		t.Fatal("pinned elements must not be evicted", evicted)
+	}
+
+	if untracked := New(); untracked.Set([]byte("a"), nil).LastAccess() != (time.Time{}) || untracked.Stale(0) != nil {
+		t.Fatal("lists without access tracking must not record accesses")
+	}
+}
@@ -0,0 +1,76 @@
+package skiplist
+
+import (
+	"unsafe"
+)
+
+// DefaultArenaChunkSize is the number of elements carved from each arena chunk.
+const DefaultArenaChunkSize = 4096
+
+// arena carves elements, towers and key bytes from large chunks, replacing several small
+// allocations per insert with an occasional large one. A chunk is only freed once none of
+// its elements are referenced, so arenas suit lists that are discarded as a whole, such as
+// memtables, rather than lists with heavy churn, or lists compacted now and then (see Compact).
+type arena struct {
+	chunkSize int
+	elements  []Element
+	towers    []unsafe.Pointer
+	keys      []byte
+	// held is the number of bytes allocated by the arena, counting every chunk as held.
+	held int64
+}
+
+func newArena(chunkSize int) *arena {
+	if chunkSize <= 0 {
+		chunkSize = DefaultArenaChunkSize
+	}
+	return &arena{chunkSize: chunkSize}
+}
+
+// newElement is newElement, allocating from the arena and copying key into it.
+// The caller must hold the list mutex.
+func (a *arena) newElement(list *SkipList, key []byte, value interface{}, level int) *Element {
+	if len(a.elements) == 0 {
+		a.elements = make([]Element, a.chunkSize)
+		a.held += int64(a.chunkSize) * elementSize
+	}
+	element := &a.elements[0]
+	a.elements = a.elements[1:]
+
+	element.list = list
+	element.next = a.tower(level)
+	element.key = a.key(key)
+	element.initial = value
+	element.value = unsafe.Pointer(&element.initial)
+	return element
+}
+
+func (a *arena) tower(level int) []unsafe.Pointer {
+	if len(a.towers) < level {
+		// Towers average 1/(1-p) levels, so a chunk of twice the element count rarely runs out
+		// before the element chunk does.
+		a.towers = make([]unsafe.Pointer, 2*a.chunkSize+level)
+		a.held += int64(len(a.towers)) * pointerSize
+	}
+	tower := a.towers[:level:level]
+	a.towers = a.towers[level:]
+	return tower
+}
+
+func (a *arena) key(key []byte) []byte {
+	if len(key) > len(a.keys) {
+		// Keys larger than an eighth of a chunk get their own allocation, so that a single
+		// large key cannot waste most of a chunk.
+		size := 64 * a.chunkSize
+		if len(key) > size/8 {
+			a.held += int64(len(key))
+			return append([]byte(nil), key...)
+		}
+		a.keys = make([]byte, size)
+		a.held += int64(size)
+	}
+	copied := a.keys[:len(key):len(key)]
+	copy(copied, key)
+	a.keys = a.keys[len(key):]
+	return copied
+}
@@ -0,0 +1,54 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	list := New(WithArena(16))
+
+	key := make([]byte, 8)
+	for i := uint64(0); i < 1000; i++ {
+		// Reusing the key buffer is safe because the arena copies keys.
+		endianness.PutUint64(key, i*7%1000)
+		list.Set(key, i)
+	}
+	for i := uint64(0); i < 1000; i += 3 {
+		list.Remove(orderedKey(i))
+	}
+	checkSanity(list, t)
+
+	if list.Len() != 666 {
+		t.Fatal("wrong length", list.Len())
+	}
+	if e := list.Get(orderedKey(7)); e == nil || e.Value().(uint64) != 1 {
+		t.Fatal("wrong element", e)
+	}
+
+	large := make([]byte, 1<<20)
+	if e := list.Set(large, "large"); e == nil || len(e.Key()) != len(large) {
+		t.Fatal("keys larger than a chunk must be stored", e)
+	}
+	checkSanity(list, t)
+}
+
+func TestArenaAllocations(t *testing.T) {
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		keys[i] = orderedKey(uint64(i))
+	}
+
+	insert := func(list *SkipList) func() {
+		i := 0
+		return func() {
+			list.Set(keys[i%len(keys)], nil)
+			i++
+		}
+	}
+
+	plain := testing.AllocsPerRun(len(keys)-1, insert(New()))
+	arena := testing.AllocsPerRun(len(keys)-1, insert(New(WithArena(0))))
+	if arena >= plain/2 {
+		t.Fatal("the arena must save most allocations", plain, arena)
+	}
+}
@@ -0,0 +1,41 @@
+package arenaskl
+
+// Iterator walks the entries of a SkipList in key order. An iterator starts out unpositioned;
+// call SeekToFirst or Seek before reading from it.
+type Iterator struct {
+	list    *SkipList
+	current uint32
+}
+
+// Valid reports whether the iterator is positioned at an entry.
+func (it *Iterator) Valid() bool {
+	return it.current != 0
+}
+
+// Key returns the key at the current position. The iterator must be valid.
+// The returned slice aliases the arena and must not be modified.
+func (it *Iterator) Key() []byte {
+	return it.list.key(it.current)
+}
+
+// Value returns the value at the current position. The iterator must be valid.
+// The returned slice aliases the arena and must not be modified.
+func (it *Iterator) Value() []byte {
+	return it.list.value(it.current)
+}
+
+// SeekToFirst positions the iterator at the first entry of the list.
+func (it *Iterator) SeekToFirst() {
+	it.current = it.list.node(it.list.head).tower[0].Load()
+}
+
+// Seek positions the iterator at the first entry whose key is greater than or equal to key.
+// The iterator is invalid if no such entry exists.
+func (it *Iterator) Seek(key []byte) {
+	it.current = it.list.seek(key)
+}
+
+// Next advances the iterator to the following entry. The iterator must be valid.
+func (it *Iterator) Next() {
+	it.current = it.list.node(it.current).tower[0].Load()
+}
@@ -0,0 +1,233 @@
+// Package arenaskl provides a skip list of []byte keys and values whose nodes, keys and values
+// all live in a single preallocated arena, in the style of badger's skl package. Towers store
+// uint32 offsets into the arena rather than pointers, which makes nodes smaller and keeps them
+// close together, and leaves the garbage collector no pointers to scan however many entries the
+// list holds.
+//
+// The arena does not grow: its capacity is fixed when the list is created, and Set fails with
+// ErrArenaFull once it is exhausted. Removing entries is not supported; like a memtable, the list
+// is discarded as a whole.
+package arenaskl
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/m3db/fast-skiplist/internal/tower"
+)
+
+const (
+	// MaxHeight is the maximum height of a tower.
+	MaxHeight = 20
+	// DefaultProbability matches the skiplist package.
+	DefaultProbability float64 = 1 / math.E
+)
+
+// ErrArenaFull is returned by Set when the arena has no room left for the entry.
+var ErrArenaFull = errors.New("arenaskl: arena is full")
+
+// node is the layout of an entry in the arena. Only the first height links of tower are
+// allocated, so a node must never be copied out of the arena.
+type node struct {
+	// value packs the offset of the value in its upper 32 bits and its size in the lower 32,
+	// so that both are replaced by a single atomic store when the value is updated.
+	value     atomic.Uint64
+	keyOffset uint32
+	keySize   uint32
+	height    uint32
+	tower     [MaxHeight]atomic.Uint32
+}
+
+const (
+	nodeSize  = uint32(unsafe.Sizeof(node{}))
+	linkSize  = uint32(unsafe.Sizeof(atomic.Uint32{}))
+	nodeAlign = uint32(unsafe.Alignof(node{}))
+)
+
+// SkipList is an arena-backed skip list. It is safe for concurrent use: writes are serialized
+// by a mutex, and reads and iteration are lock-free.
+type SkipList struct {
+	mutex sync.Mutex
+	// arena holds every node, key and value. It never contains pointers, so the garbage
+	// collector does not scan it.
+	arena []byte
+	// used is the number of bytes of arena allocated so far.
+	used       atomic.Uint32
+	head       uint32
+	length     atomic.Int64
+	randSource rand.Source
+	probTable  []float64
+	prevs      [MaxHeight]uint32
+}
+
+// New creates a list whose arena holds capacity bytes. Each entry takes its key and value
+// sizes plus 20 to about 100 bytes of node, depending on its height.
+func New(capacity int) *SkipList {
+	if capacity < 0 || capacity > math.MaxUint32-int(nodeSize) {
+		panic("arenaskl: capacity must be in [0, 4GiB)")
+	}
+
+	list := &SkipList{
+		// The arena is padded by a full node so that truncated nodes at its end can still be
+		// addressed as a node.
+		arena:      make([]byte, capacity+int(nodeSize)+int(nodeAlign)),
+		randSource: tower.NewXorshift(tower.UniqueSeed()),
+		probTable:  tower.ProbabilityTable(DefaultProbability, MaxHeight),
+	}
+	// Offset 0 is reserved to mean nil.
+	list.used.Store(1)
+
+	head, ok := list.allocNode(MaxHeight)
+	if !ok {
+		panic("arenaskl: capacity too small for the head node")
+	}
+	list.head = head
+	return list
+}
+
+// Len returns the number of entries in the list.
+func (list *SkipList) Len() int {
+	return int(list.length.Load())
+}
+
+// Size returns the number of bytes of the arena in use.
+func (list *SkipList) Size() int {
+	return int(list.used.Load())
+}
+
+// Set inserts value under key, or replaces the value of an existing entry. Both are copied into
+// the arena. Returns ErrArenaFull if the arena has no room for them, leaving the list unchanged.
+func (list *SkipList) Set(key, value []byte) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	prev := list.head
+	for i := MaxHeight - 1; i >= 0; i-- {
+		prev, _ = list.findSplice(key, prev, i)
+		list.prevs[i] = prev
+	}
+
+	if next := list.node(list.prevs[0]).tower[0].Load(); next != 0 && bytes.Equal(list.key(next), key) {
+		valueOffset, ok := list.allocBytes(value)
+		if !ok {
+			return ErrArenaFull
+		}
+		list.node(next).value.Store(uint64(valueOffset)<<32 | uint64(len(value)))
+		return nil
+	}
+
+	height := tower.Level(list.randSource, list.probTable)
+	mark := list.used.Load()
+	offset, ok := list.allocNode(height)
+	if !ok {
+		return ErrArenaFull
+	}
+	keyOffset, ok := list.allocBytes(key)
+	if !ok {
+		list.used.Store(mark)
+		return ErrArenaFull
+	}
+	valueOffset, ok := list.allocBytes(value)
+	if !ok {
+		list.used.Store(mark)
+		return ErrArenaFull
+	}
+
+	n := list.node(offset)
+	n.keyOffset, n.keySize, n.height = keyOffset, uint32(len(key)), uint32(height)
+	n.value.Store(uint64(valueOffset)<<32 | uint64(len(value)))
+	for i := 0; i < height; i++ {
+		prev := list.node(list.prevs[i])
+		n.tower[i].Store(prev.tower[i].Load())
+		prev.tower[i].Store(offset)
+	}
+
+	list.length.Add(1)
+	return nil
+}
+
+// Get returns the value stored under key, and whether the key was found. The returned slice
+// aliases the arena and must not be modified.
+func (list *SkipList) Get(key []byte) ([]byte, bool) {
+	offset := list.seek(key)
+	if offset == 0 || !bytes.Equal(list.key(offset), key) {
+		return nil, false
+	}
+	return list.value(offset), true
+}
+
+// NewIterator returns a new, unpositioned iterator over the list.
+func (list *SkipList) NewIterator() *Iterator {
+	return &Iterator{list: list}
+}
+
+// seek returns the offset of the first node whose key is greater than or equal to key,
+// or 0 if there is none.
+func (list *SkipList) seek(key []byte) uint32 {
+	prev, next := list.head, uint32(0)
+	for i := MaxHeight - 1; i >= 0; i-- {
+		prev, next = list.findSplice(key, prev, i)
+	}
+	return next
+}
+
+// findSplice returns the offsets of the nodes between which key belongs on level i,
+// starting from the node at start.
+func (list *SkipList) findSplice(key []byte, start uint32, i int) (prev, next uint32) {
+	prev = start
+	next = list.node(prev).tower[i].Load()
+	for next != 0 && bytes.Compare(key, list.key(next)) > 0 {
+		prev = next
+		next = list.node(next).tower[i].Load()
+	}
+	return prev, next
+}
+
+func (list *SkipList) node(offset uint32) *node {
+	return (*node)(unsafe.Pointer(&list.arena[offset]))
+}
+
+func (list *SkipList) key(offset uint32) []byte {
+	n := list.node(offset)
+	return list.arena[n.keyOffset : n.keyOffset+n.keySize : n.keyOffset+n.keySize]
+}
+
+func (list *SkipList) value(offset uint32) []byte {
+	packed := list.node(offset).value.Load()
+	valueOffset, size := uint32(packed>>32), uint32(packed)
+	return list.arena[valueOffset : valueOffset+size : valueOffset+size]
+}
+
+// allocNode reserves room for a node of the given height, leaving out the unused links.
+// The caller must hold the mutex, or be constructing the list.
+func (list *SkipList) allocNode(height int) (uint32, bool) {
+	used := list.used.Load()
+	offset := (used + nodeAlign - 1) &^ (nodeAlign - 1)
+	end := offset + nodeSize - uint32(MaxHeight-height)*linkSize
+	if int(end) > list.capacity() {
+		return 0, false
+	}
+	list.used.Store(end)
+	return offset, true
+}
+
+// allocBytes copies b into the arena. The caller must hold the mutex.
+func (list *SkipList) allocBytes(b []byte) (uint32, bool) {
+	offset := list.used.Load()
+	end := offset + uint32(len(b))
+	if int(end) > list.capacity() || len(b) > list.capacity() {
+		return 0, false
+	}
+	copy(list.arena[offset:end], b)
+	list.used.Store(end)
+	return offset, true
+}
+
+func (list *SkipList) capacity() int {
+	return len(list.arena) - int(nodeSize) - int(nodeAlign)
+}
@@ -0,0 +1,117 @@
+package arenaskl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func key(i int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(i))
+	return buf[:]
+}
+
+func TestSetGet(t *testing.T) {
+	list := New(1 << 20)
+	for i := 999; i >= 0; i-- {
+		if err := list.Set(key(i), []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := list.Set(key(5), []byte("five")); err != nil {
+		t.Fatal(err)
+	}
+
+	if list.Len() != 1000 {
+		t.Fatal("wrong length", list.Len())
+	}
+	if v, ok := list.Get(key(5)); !ok || string(v) != "five" {
+		t.Fatal("Set must replace the value of an existing key", string(v))
+	}
+	if v, ok := list.Get(key(42)); !ok || string(v) != "42" {
+		t.Fatal("wrong value", string(v))
+	}
+	if _, ok := list.Get(key(1000)); ok {
+		t.Fatal("found a missing key")
+	}
+
+	n := 0
+	it := list.NewIterator()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if !bytes.Equal(it.Key(), key(n)) {
+			t.Fatal("wrong iteration order at", n)
+		}
+		n++
+	}
+	if n != 1000 {
+		t.Fatal("iteration must visit every entry", n)
+	}
+
+	if it.Seek(key(998)); !it.Valid() || string(it.Value()) != "998" {
+		t.Fatal("wrong Seek result")
+	}
+}
+
+func TestArenaFull(t *testing.T) {
+	list := New(1024)
+
+	var err error
+	n := 0
+	for ; err == nil; n++ {
+		err = list.Set(key(n), make([]byte, 16))
+	}
+	if err != ErrArenaFull {
+		t.Fatal("expected ErrArenaFull", err)
+	}
+	if list.Len() != n-1 || list.Size() > 1024 {
+		t.Fatal("a failed Set must leave the list unchanged", list.Len(), n, list.Size())
+	}
+
+	it := list.NewIterator()
+	count := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		count++
+	}
+	if count != list.Len() {
+		t.Fatal("wrong number of entries", count)
+	}
+}
+
+func TestConcurrentReads(t *testing.T) {
+	list := New(1 << 22)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			list.Set(key(i), key(i))
+		}
+	}()
+
+	for n := 0; n < 10; n++ {
+		var prev []byte
+		it := list.NewIterator()
+		for it.SeekToFirst(); it.Valid(); it.Next() {
+			if prev != nil && bytes.Compare(prev, it.Key()) >= 0 {
+				t.Fatal("keys out of order")
+			}
+			if !bytes.Equal(it.Key(), it.Value()) {
+				t.Fatal("value must match its key")
+			}
+			prev = it.Key()
+		}
+	}
+	wg.Wait()
+}
+
+func BenchmarkSet(b *testing.B) {
+	b.ReportAllocs()
+	list := New(b.N*150 + 1024)
+	for i := 0; i < b.N; i++ {
+		list.Set(key(i), nil)
+	}
+}
@@ -0,0 +1,237 @@
+package skiplist
+
+import (
+	"sort"
+	"time"
+)
+
+// WriteBatch accumulates writes to apply to a list at once with Apply. The zero value is an
+// empty batch ready to use. A batch must not be modified while it is being applied.
+type WriteBatch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	key    []byte
+	value  interface{}
+	remove bool
+}
+
+// Set adds the insert or update of key to the batch.
+func (b *WriteBatch) Set(key []byte, value interface{}) {
+	b.ops = append(b.ops, batchOp{key: key, value: value})
+}
+
+// Remove adds the removal of key to the batch. Removing a key that is not in the list is not an error.
+func (b *WriteBatch) Remove(key []byte) {
+	b.ops = append(b.ops, batchOp{key: key, remove: true})
+}
+
+// Len returns the number of writes in the batch.
+func (b *WriteBatch) Len() int {
+	return len(b.ops)
+}
+
+// Reset empties the batch so that it can be reused.
+func (b *WriteBatch) Reset() {
+	for i := range b.ops {
+		b.ops[i] = batchOp{}
+	}
+	b.ops = b.ops[:0]
+}
+
+// Apply applies the writes of batch under a single acquisition of the list's lock, so that no
+// other write interleaves with them, and applying thousands of small writes does not pay for
+// locking thousands of times. Writes of the same key take effect in the order they were added to
+// the batch. Reads do not lock the list, so concurrent readers may observe part of the batch.
+//
+// The batch is first sorted by key, outside the lock, so that each write's search can start from
+// where the previous one ended rather than from the top of the list.
+//
+// If any key is invalid, the list is frozen, or the batch would insert more keys than a list
+// constructed WithCapacity has room for, or more bytes than its WithByteQuota, none of the batch
+// is applied. Elements removed by the batch are reported to the remove callback once the list
+// is unlocked.
+func (list *SkipList) Apply(batch *WriteBatch) error {
+	if list.metrics != nil {
+		defer list.observeLatency("Apply", time.Now())
+	}
+	for _, op := range batch.ops {
+		if err := list.checkKey("Apply", op.key); err != nil {
+			return err
+		}
+	}
+
+	// A stable sort keeps the writes of each key in the order they were added.
+	sort.SliceStable(batch.ops, func(i, j int) bool {
+		return list.compare(batch.ops[i].key, batch.ops[j].key) < 0
+	})
+
+	if list.hotKeys != nil {
+		for _, op := range batch.ops {
+			list.hotKeys.record(op.key)
+		}
+	}
+
+	removed, violations, err := list.apply(batch.ops)
+	if err == ErrReadOnly {
+		_, err = list.frozenWrite("Apply", nil, func(overflow *SkipList) (*Element, error) {
+			return nil, overflow.Apply(batch)
+		})
+		return err
+	}
+	if err != nil {
+		return list.newError("Apply", nil, err)
+	}
+
+	if list.metrics != nil {
+		for _, op := range batch.ops {
+			if op.remove {
+				list.metrics.IncRemove()
+			} else {
+				list.metrics.IncSet()
+			}
+		}
+	}
+	for _, violation := range violations {
+		list.onOrderViolation(violation)
+	}
+	for _, r := range removed {
+		list.notifyRemoval(r, Removed)
+	}
+	list.enforceMaxWeight()
+	return nil
+}
+
+// apply applies sorted writes, returning the removed elements and any order violations for the
+// caller to report once the list is unlocked.
+func (list *SkipList) apply(ops []batchOp) ([]removal, []OrderViolation, error) {
+	list.lock(lockApply)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, nil, ErrReadOnly
+	}
+	if list.reservations != nil {
+		for _, op := range ops {
+			if !op.remove && list.reserved(op.key) {
+				return nil, nil, ErrRangeReserved
+			}
+		}
+	}
+	if list.frozenRanges != nil {
+		for _, op := range ops {
+			if list.rangeFrozen(op.key) {
+				return nil, nil, ErrRangeFrozen
+			}
+		}
+	}
+
+	if list.capacity > 0 && list.Length+list.newKeys(ops) > list.capacity {
+		return nil, nil, ErrFull
+	}
+	if list.byteQuota > 0 && list.exceedsQuota(list.newBytes(ops)) {
+		return nil, nil, ErrQuotaExceeded
+	}
+
+	removed, violations := list.applyLocked(ops)
+	return removed, violations, nil
+}
+
+// applyLocked is apply for a caller that holds the list mutex and has checked that the writes
+// are allowed.
+func (list *SkipList) applyLocked(ops []batchOp) (removed []removal, violations []OrderViolation) {
+	prevs := list.prevNodesCache
+	for i := range prevs {
+		prevs[i] = &list.elementNode
+	}
+
+	for _, op := range ops {
+		list.advancePrevElementNodes(prevs, op.key)
+
+		element := prevs[0].Next()
+		found := element != nil && list.compare(element.key, op.key) == 0
+
+		switch {
+		case op.remove && found && list.tombstoneMode:
+			if !element.IsTombstone() {
+				removed = append(removed, removal{element: element, value: element.Value()})
+				list.bury(element)
+			}
+		case op.remove && found:
+			list.unlink(prevs, element)
+			removed = append(removed, removal{element: element, value: element.Value()})
+		case op.remove && list.tombstoneMode:
+			if violation := list.insertTombstone(prevs, op.key); violation != nil {
+				violations = append(violations, *violation)
+			}
+		case found:
+			list.update(element, op.value)
+			element.expires.Store(0)
+		case !op.remove:
+			if _, violation := list.insert(prevs, op.key, op.value); violation != nil {
+				violations = append(violations, *violation)
+			}
+		}
+	}
+
+	return removed, violations
+}
+
+// newKeys returns the number of keys that sorted writes insert into the list, not counting any
+// room made by their removals. The caller must hold the list mutex.
+func (list *SkipList) newKeys(ops []batchOp) int {
+	n := 0
+	var last []byte
+	for _, op := range ops {
+		if op.remove || last != nil && list.compare(last, op.key) == 0 {
+			continue
+		}
+		last = op.key
+		if list.find(op.key) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// newBytes returns the number of key and value bytes that sorted writes add to the list, not
+// counting any freed by their removals. The caller must hold the list mutex.
+func (list *SkipList) newBytes(ops []batchOp) int64 {
+	var n int64
+	for i, op := range ops {
+		// Only the last write of a key decides what the list ends up holding.
+		if op.remove || i+1 < len(ops) && list.compare(ops[i+1].key, op.key) == 0 {
+			continue
+		}
+		if element := list.find(op.key); element != nil {
+			n += list.growth(element, op.value)
+		} else {
+			n += int64(len(op.key)) + list.sizeValue(op.value)
+		}
+	}
+	return n
+}
+
+// advancePrevElementNodes moves prevs, the previous nodes of a key on each level, forward to
+// those of key, which must not sort before that key. Each level continues from the further of
+// its own previous node and the node reached on the level above, so that nearby keys cost a
+// short walk. The caller must hold the list mutex.
+func (list *SkipList) advancePrevElementNodes(prevs []*elementNode, key []byte) {
+	prev := &list.elementNode
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		if cached := list.elementOf(prevs[i]); cached != nil {
+			if above := list.elementOf(prev); above == nil || list.compare(cached.key, above.key) > 0 {
+				prev = prevs[i]
+			}
+		}
+
+		next := prev.NextAt(i)
+		for next != nil && list.compare(key, next.key) > 0 {
+			prev = &next.elementNode
+			next = next.NextAt(i)
+		}
+
+		prevs[i] = prev
+	}
+}
@@ -0,0 +1,111 @@
+package skiplist
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	var removed []string
+	list := New(WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		removed = append(removed, string(e.Key()))
+	}))
+	expected := make(map[uint64]interface{})
+
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 20; round++ {
+		var batch WriteBatch
+		for i := 0; i < 200; i++ {
+			k := uint64(rng.Intn(500))
+			if rng.Intn(3) == 0 {
+				batch.Remove(orderedKey(k))
+				delete(expected, k)
+			} else {
+				batch.Set(orderedKey(k), i)
+				expected[k] = i
+			}
+		}
+
+		if err := list.Apply(&batch); err != nil {
+			t.Fatal(err)
+		}
+		checkSanity(list, t)
+
+		if list.Len() != len(expected) {
+			t.Fatal("wrong length", list.Len(), len(expected))
+		}
+		for k, v := range expected {
+			if e := list.Get(orderedKey(k)); e == nil || e.Value() != v {
+				t.Fatal("wrong value after Apply", k, e, v)
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		t.Fatal("removals must be reported")
+	}
+}
+
+func TestApplyIsAllOrNothing(t *testing.T) {
+	list := New(WithMaxKeySize(2))
+	list.Set([]byte("a"), 1)
+
+	var batch WriteBatch
+	batch.Remove([]byte("a"))
+	batch.Set([]byte("b"), 2)
+	batch.Set([]byte("toolong"), 3)
+
+	if err := list.Apply(&batch); !errors.Is(err, ErrKeyTooLarge) {
+		t.Fatal("expected ErrKeyTooLarge, got", err)
+	}
+	if list.Len() != 1 || list.Get([]byte("a")) == nil {
+		t.Fatal("a rejected batch must not be applied")
+	}
+
+	list.Freeze()
+	batch.Reset()
+	batch.Set([]byte("b"), 2)
+	if err := list.Apply(&batch); !errors.Is(err, ErrReadOnly) {
+		t.Fatal("expected ErrReadOnly, got", err)
+	}
+	if batch.Len() != 1 || list.Len() != 1 {
+		t.Fatal("a frozen list must not be written")
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	list := New()
+	var batch WriteBatch
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		batch.Set(benchKey(i), nil)
+		if batch.Len() == 1000 {
+			list.Apply(&batch)
+			batch.Reset()
+		}
+	}
+}
+
+func TestApplyCapacity(t *testing.T) {
+	list := New(WithCapacity(3))
+	list.Set([]byte("a"), 1)
+
+	var batch WriteBatch
+	batch.Set([]byte("a"), 2)
+	batch.Remove([]byte("b"))
+	batch.Set([]byte("b"), 1)
+	batch.Set([]byte("b"), 2)
+	batch.Set([]byte("c"), 1)
+	if err := list.Apply(&batch); err != nil || list.Len() != 3 {
+		t.Fatal("batch within capacity rejected", err, list.Len())
+	}
+
+	batch.Reset()
+	batch.Set([]byte("c"), 2)
+	batch.Set([]byte("d"), 1)
+	if err := list.Apply(&batch); !errors.Is(err, ErrFull) || list.Get([]byte("c")).Value() != 1 {
+		t.Fatal("batch over capacity must be rejected whole", err)
+	}
+}
@@ -0,0 +1,67 @@
+package skiplist
+
+import (
+	"encoding/binary"
+)
+
+// KeyDomain maps the keys of a list to integers of a fixed domain, such as the IDs of a series
+// encoded in their keys, for ExportKeyBitmap.
+type KeyDomain interface {
+	// Index returns the integer of key, or false if key is outside the domain.
+	Index(key []byte) (uint64, bool)
+}
+
+// Bitmap is a set of integers that ExportKeyBitmap adds to, such as a *roaring64.Bitmap, so that
+// the list need not depend on a bitmap implementation.
+type Bitmap interface {
+	Add(x uint64)
+}
+
+// Uint64Domain is the KeyDomain of 8-byte big-endian keys from Min to Max inclusive, whose
+// integers are their offsets from Min.
+type Uint64Domain struct {
+	Min, Max uint64
+}
+
+// Index returns the offset of key from Min, or false if key is not 8 bytes long or decodes to an
+// integer outside [Min, Max].
+func (d Uint64Domain) Index(key []byte) (uint64, bool) {
+	if len(key) != 8 {
+		return 0, false
+	}
+	x := binary.BigEndian.Uint64(key)
+	if x < d.Min || x > d.Max {
+		return 0, false
+	}
+	return x - d.Min, true
+}
+
+// ExportKeyBitmap adds the integer of every key of the list in domain to bitmap, returning the
+// number of keys added. A bitmap of the keys is far more compact than the keys themselves when
+// shipping the set of keys a node holds to other nodes. Keys outside the domain, tombstones and
+// expired elements are left out.
+//
+// Like all iteration, the export does not lock the list: keys present for the whole export are
+// always added, while keys inserted or removed during it may or may not be.
+func (list *SkipList) ExportKeyBitmap(domain KeyDomain, bitmap Bitmap) int {
+	it := list.NewIterator()
+	it.skipTombstones = true
+
+	// Big-endian integer keys sort in the order of their integers under the default
+	// comparator, so the export can skip to the start of the domain and stop at its end.
+	if d, ok := domain.(Uint64Domain); ok && list.byteOrder {
+		it.lower = binary.BigEndian.AppendUint64(nil, d.Min)
+		if d.Max < ^uint64(0) {
+			it.upper = binary.BigEndian.AppendUint64(nil, d.Max+1)
+		}
+	}
+
+	n := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if x, ok := domain.Index(it.Key()); ok {
+			bitmap.Add(x)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package skiplist
+
+import (
+	"bytes"
+	"testing"
+)
+
+type setBitmap map[uint64]bool
+
+func (b setBitmap) Add(x uint64) { b[x] = true }
+
+func TestExportKeyBitmap(t *testing.T) {
+	list := New(WithTombstones())
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	list.Set([]byte("not an integer"), 0)
+	list.Remove(orderedKey(150))
+
+	bitmap := setBitmap{}
+	if n := list.ExportKeyBitmap(Uint64Domain{Min: 100, Max: 199}, bitmap); n != 99 || len(bitmap) != 99 {
+		t.Fatal("wrong number of keys exported", n, len(bitmap))
+	}
+	if !bitmap[0] || !bitmap[99] || bitmap[50] {
+		t.Fatal("wrong keys exported", bitmap)
+	}
+
+	// Other domains, and lists with a comparator, are exported by a full scan.
+	reversed := New(WithComparator(func(a, b []byte) int { return bytes.Compare(b, a) }))
+	for i := uint64(0); i < 1000; i++ {
+		reversed.Set(orderedKey(i), i)
+	}
+	bitmap = setBitmap{}
+	if n := reversed.ExportKeyBitmap(Uint64Domain{Min: 100, Max: 199}, bitmap); n != 100 {
+		t.Fatal("wrong number of keys exported with a comparator", n)
+	}
+}
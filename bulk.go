@@ -0,0 +1,195 @@
+package skiplist
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+const (
+	// contextCheckInterval is the number of elements that operations taking a context process
+	// between checks of the context.
+	contextCheckInterval = 4096
+	// channelChunkSize is the number of pairs NewFromChannel sorts and merges at a time.
+	channelChunkSize = 16384
+)
+
+// KV is a key and its value, as received by NewFromChannel.
+type KV struct {
+	Key   []byte
+	Value interface{}
+}
+
+// NewFromSorted builds a list, configured by opts, from elements produced in strictly increasing
+// key order by next, which returns false once there are none left. Since every element goes at
+// the end of the list, it is linked directly after the tail of each level, without searching,
+// so the list is built in time linear in the number of elements and locked only once. This is
+// the cheapest way to rebuild a list from a sorted snapshot.
+//
+// An element whose key does not sort after the previous one fails the build with an *Error
+// wrapping ErrNotSorted, as does an invalid key with the reason it is invalid, and an element
+// beyond the capacity set WithCapacity with ErrFull.
+func NewFromSorted(next func() (key []byte, value interface{}, ok bool), opts ...Option) (*SkipList, error) {
+	return NewFromSortedContext(context.Background(), next, opts...)
+}
+
+// NewFromSortedContext is like NewFromSorted, but checks ctx every few thousand elements, so
+// that a long load can be abandoned. If ctx is done before next runs out, returns the list of
+// the elements loaded so far, which is valid and usable, along with an *Error wrapping the
+// context's error. The caller can tell how far the load got from the list's Len and MaxKey.
+func NewFromSortedContext(ctx context.Context, next func() (key []byte, value interface{}, ok bool), opts ...Option) (*SkipList, error) {
+	list := New(opts...)
+
+	violations, err := list.appendSorted(ctx, next)
+	if err != nil && !errors.Is(err, ctx.Err()) {
+		list.Close()
+		return nil, err
+	}
+
+	for _, violation := range violations {
+		list.onOrderViolation(violation)
+	}
+	list.enforceMaxWeight()
+	return list, err
+}
+
+func (list *SkipList) appendSorted(ctx context.Context, next func() ([]byte, interface{}, bool)) ([]OrderViolation, error) {
+	list.lock(lockBulk)
+	defer list.unlock()
+
+	var (
+		violations []OrderViolation
+		last       *Element
+	)
+	prevs := list.prevNodesCache
+	for n := 1; ; n++ {
+		if n%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return violations, list.newError("NewFromSorted", nil, err)
+			}
+		}
+		key, value, ok := next()
+		if !ok {
+			break
+		}
+
+		if err := list.checkKey("NewFromSorted", key); err != nil {
+			return nil, err
+		}
+		if last != nil && list.compare(key, last.key) <= 0 {
+			return nil, list.newError("NewFromSorted", key, ErrNotSorted)
+		}
+		if list.capacity > 0 && list.Length >= list.capacity {
+			return nil, list.newError("NewFromSorted", key, ErrFull)
+		}
+		if list.exceedsQuota(int64(len(key)) + list.sizeValue(value)) {
+			return nil, list.newError("NewFromSorted", key, ErrQuotaExceeded)
+		}
+
+		copy(prevs, list.tails)
+		var violation *OrderViolation
+		if last, violation = list.insert(prevs, key, value); violation != nil {
+			violations = append(violations, *violation)
+		}
+	}
+	return violations, nil
+}
+
+// NewFromChannel builds a list, configured by opts, from the pairs received on ch until it is
+// closed, for producers in a pipeline that do not sort what they send. The pairs are gathered in
+// chunks of up to 16384, and each chunk is sorted and merged into the list under a single lock
+// by a goroutine of its own, while the next chunk is received. A key received more than once
+// takes the last value received.
+//
+// An invalid key fails the build with an *Error describing why, as does a merge rejected by the
+// list, say for being over its capacity. If ctx is done before ch is closed, returns the list of
+// the chunks merged so far, which is valid and usable, along with an *Error wrapping the
+// context's error; pairs received since the last full chunk are dropped.
+func NewFromChannel(ctx context.Context, ch <-chan KV, opts ...Option) (*SkipList, error) {
+	list := New(opts...)
+
+	chunks := make(chan []batchOp, 1)
+	failed := make(chan struct{})
+	merged := make(chan error, 1)
+	go func() {
+		merged <- list.mergeChunks(chunks, failed)
+	}()
+
+	err := list.receiveChunks(ctx, ch, chunks, failed)
+	close(chunks)
+	if mergeErr := <-merged; mergeErr != nil {
+		err = mergeErr
+	}
+	if err != nil && !errors.Is(err, ctx.Err()) {
+		list.Close()
+		return nil, err
+	}
+
+	list.enforceMaxWeight()
+	return list, err
+}
+
+// receiveChunks gathers the pairs received on ch into chunks sent on chunks, until ch is
+// closed, ctx is done or the merge of a chunk has failed.
+func (list *SkipList) receiveChunks(ctx context.Context, ch <-chan KV, chunks chan<- []batchOp, failed <-chan struct{}) error {
+	chunk := make([]batchOp, 0, channelChunkSize)
+	send := func() error {
+		select {
+		case chunks <- chunk:
+			chunk = make([]batchOp, 0, channelChunkSize)
+			return nil
+		case <-failed:
+			return nil
+		case <-ctx.Done():
+			return list.newError("NewFromChannel", nil, ctx.Err())
+		}
+	}
+
+	for {
+		select {
+		case kv, ok := <-ch:
+			if !ok {
+				if len(chunk) > 0 {
+					return send()
+				}
+				return nil
+			}
+			if err := list.checkKey("NewFromChannel", kv.Key); err != nil {
+				return err
+			}
+			chunk = append(chunk, batchOp{key: kv.Key, value: kv.Value})
+			if len(chunk) == channelChunkSize {
+				if err := send(); err != nil {
+					return err
+				}
+			}
+		case <-failed:
+			return nil
+		case <-ctx.Done():
+			return list.newError("NewFromChannel", nil, ctx.Err())
+		}
+	}
+}
+
+// mergeChunks sorts and applies the chunks received on chunks until it is closed. If a chunk is
+// rejected, it closes failed and discards the remaining chunks.
+func (list *SkipList) mergeChunks(chunks <-chan []batchOp, failed chan<- struct{}) error {
+	for chunk := range chunks {
+		// A stable sort keeps the last value received for a key last.
+		sort.SliceStable(chunk, func(i, j int) bool {
+			return list.compare(chunk[i].key, chunk[j].key) < 0
+		})
+
+		_, violations, err := list.apply(chunk)
+		if err != nil {
+			close(failed)
+			for range chunks {
+			}
+			return list.newError("NewFromChannel", nil, err)
+		}
+		for _, violation := range violations {
+			list.onOrderViolation(violation)
+		}
+	}
+	return nil
+}
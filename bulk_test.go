@@ -0,0 +1,158 @@
+package skiplist
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewFromSorted(t *testing.T) {
+	i := uint64(0)
+	list, err := NewFromSorted(func() ([]byte, interface{}, bool) {
+		if i == 10000 {
+			return nil, nil, false
+		}
+		i++
+		return orderedKey(i * 2), i, true
+	}, WithName("sorted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(list, t)
+
+	if list.Len() != 10000 || list.Name() != "sorted" {
+		t.Fatal("wrong list", list.Len(), list.Name())
+	}
+	if e := list.Seek(orderedKey(101)); e == nil || e.Value() != uint64(51) {
+		t.Fatal("wrong Seek result", e)
+	}
+
+	// Tower heights must follow the list's probability, as if the elements had been inserted.
+	if stats := list.Stats(); stats.LevelCounts[1] < 3300 || stats.LevelCounts[1] > 4100 {
+		t.Fatal("wrong distribution of tower heights", stats.LevelCounts[:4])
+	}
+
+	// The list is usable as usual afterwards.
+	list.Set(orderedKey(3), nil)
+	list.Remove(orderedKey(4))
+	checkSanity(list, t)
+}
+
+func TestNewFromSortedRejectsUnsorted(t *testing.T) {
+	keys := []string{"a", "c", "b"}
+	_, err := NewFromSorted(func() ([]byte, interface{}, bool) {
+		if len(keys) == 0 {
+			return nil, nil, false
+		}
+		key := keys[0]
+		keys = keys[1:]
+		return []byte(key), nil, true
+	})
+
+	var listErr *Error
+	if !errors.Is(err, ErrNotSorted) || !errors.As(err, &listErr) || string(listErr.Key) != "b" {
+		t.Fatal("expected an ErrNotSorted error for key b, got", err)
+	}
+}
+
+func TestNewFromSortedContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	i := uint64(0)
+	list, err := NewFromSortedContext(ctx, func() ([]byte, interface{}, bool) {
+		if i == 3*contextCheckInterval {
+			cancel()
+		}
+		i++
+		return orderedKey(i), i, true
+	})
+	if !errors.Is(err, context.Canceled) || list == nil {
+		t.Fatal("a cancelled load must return the partial list", err)
+	}
+	checkSanity(list, t)
+	if n := list.Len(); n < 3*contextCheckInterval || n > 4*contextCheckInterval {
+		t.Fatal("the load must stop soon after cancellation", n)
+	}
+}
+
+func TestNewFromChannel(t *testing.T) {
+	const n = 50000
+	ch := make(chan KV)
+	go func() {
+		defer close(ch)
+		// Keys arrive out of order, each twice, the second value winning.
+		for round := 0; round < 2; round++ {
+			for i := uint64(0); i < n; i++ {
+				k := i * 7919 % n
+				ch <- KV{Key: orderedKey(k), Value: k + uint64(round)}
+			}
+		}
+	}()
+
+	list, err := NewFromChannel(context.Background(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(list, t)
+	if list.Len() != n {
+		t.Fatal("wrong length", list.Len())
+	}
+	for i := uint64(0); i < n; i += 997 {
+		if e := list.Get(orderedKey(i)); e == nil || e.Value() != i+1 {
+			t.Fatal("wrong value", i, e)
+		}
+	}
+
+	ch = make(chan KV, 1)
+	ch <- KV{Key: []byte("too long"), Value: 1}
+	close(ch)
+	if list, err := NewFromChannel(context.Background(), ch, WithMaxKeySize(4)); list != nil || !errors.Is(err, ErrKeyTooLarge) {
+		t.Fatal("invalid key must fail the build", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if list, err := NewFromChannel(ctx, make(chan KV)); list == nil || !errors.Is(err, context.Canceled) {
+		t.Fatal("cancelled build must return the partial list", err)
+	}
+}
+
+func TestNewFromSortedCapacity(t *testing.T) {
+	i := uint64(0)
+	list, err := NewFromSorted(func() ([]byte, interface{}, bool) {
+		i++
+		return orderedKey(i), i, i <= 4
+	}, WithCapacity(2))
+	if list != nil || !errors.Is(err, ErrFull) {
+		t.Fatal("build over capacity must fail", err)
+	}
+}
+
+func TestBulkLoadFailureStopsSweeper(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		keys := [][]byte{[]byte("b"), []byte("a")}
+		_, err := NewFromSorted(func() ([]byte, interface{}, bool) {
+			if len(keys) == 0 {
+				return nil, nil, false
+			}
+			key := keys[0]
+			keys = keys[1:]
+			return key, nil, true
+		}, WithExpirySweeper(time.Hour, 10))
+		if !errors.Is(err, ErrNotSorted) {
+			t.Fatal("expected ErrNotSorted, got", err)
+		}
+
+		ch := make(chan KV, 1)
+		ch <- KV{Key: []byte("ab")}
+		close(ch)
+		if _, err := NewFromChannel(context.Background(), ch, WithMaxKeySize(1), WithExpirySweeper(time.Hour, 10)); err == nil {
+			t.Fatal("expected an invalid key error")
+		}
+	}
+	if after := runtime.NumGoroutine(); after >= before+10 {
+		t.Fatal("failed loads must stop the sweepers of their lists", before, after)
+	}
+}
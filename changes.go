@@ -0,0 +1,41 @@
+package skiplist
+
+// Changes returns an iterator over the elements written after sequence number since, positioned
+// at the first of them, for incremental replication of the list to followers. Each element
+// carries the latest value of its key, so a key written several times since is visited once.
+// Changes(0) visits every element.
+//
+// A follower records Seq before iterating and passes it as since to its next call: writes made
+// while it iterates may or may not be visited, but any it misses are newer than the recorded
+// sequence number and visited next time.
+//
+// Removed keys are reported as tombstones, which the follower applies as deletions, so the list
+// must be constructed WithTombstones: on other lists a key removed since would simply be absent,
+// and Changes returns an *Error wrapping ErrNoTombstones instead. Expired elements are not
+// visited.
+//
+// Only Remove and the other single-key removals leave tombstones. RemoveRange, eviction, expiry,
+// Clear, Rotate and CompleteFlush unlink elements, and the tombstones among them, outright, so
+// once one of them has removed a key since, Changes returns an *Error wrapping ErrChangesLost and
+// the follower must resynchronise from Changes(0), which never fails this way: it visits every
+// element, and the follower drops the keys it does not visit.
+//
+// The changes are found by walking the whole list, skipping the elements not written since, so
+// every call costs O(n) in the length of the list however few changes there are. Followers
+// polling a large list should call it no more often than they can afford a full scan.
+func (list *SkipList) Changes(since uint64) (*Iterator, error) {
+	if !list.tombstoneMode {
+		return nil, list.newError("Changes", nil, ErrNoTombstones)
+	}
+	list.mutex.RLock()
+	lost := since > 0 && since < list.lostSeq
+	list.mutex.RUnlock()
+	if lost {
+		return nil, list.newError("Changes", nil, ErrChangesLost)
+	}
+
+	it := list.NewIterator()
+	it.since = since
+	it.SeekToFirst()
+	return it, nil
+}
@@ -0,0 +1,83 @@
+package skiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	list := New(WithTombstones())
+	for i := uint64(0); i < 10; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	since := list.Seq()
+	list.Set(orderedKey(3), "three")
+	list.Set(orderedKey(3), "3")
+	list.Remove(orderedKey(5))
+	list.Set(orderedKey(20), 20)
+
+	it, err := list.Changes(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []uint64
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, orderedKeyValue(it.Key()))
+		if e := it.Element(); orderedKeyValue(e.Key()) == 5 && !e.IsTombstone() {
+			t.Fatal("removal not reported as a tombstone")
+		}
+	}
+	if len(keys) != 3 || keys[0] != 3 || keys[1] != 5 || keys[2] != 20 {
+		t.Fatal("wrong changes", keys)
+	}
+
+	n := 0
+	all, _ := list.Changes(0)
+	for ; all.Valid(); all.Next() {
+		n++
+	}
+	if n != list.Len() {
+		t.Fatal("Changes(0) must visit every element", n)
+	}
+	if none, _ := list.Changes(list.Seq()); none.Valid() {
+		t.Fatal("no changes expected since the last write")
+	}
+
+	if _, err := New().Changes(0); !errors.Is(err, ErrNoTombstones) {
+		t.Fatal("Changes must require tombstones, got", err)
+	}
+}
+
+func TestChangesLost(t *testing.T) {
+	list := New(WithTombstones())
+	for i := uint64(0); i < 10; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	list.Remove(orderedKey(1))
+	since := list.Seq()
+
+	list.RemoveRange(orderedKey(5), orderedKey(7))
+	if _, err := list.Changes(since); !errors.Is(err, ErrChangesLost) {
+		t.Fatal("removals without tombstones must be reported, got", err)
+	}
+	if _, err := list.Changes(list.Seq()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.Changes(0); err != nil {
+		t.Fatal("Changes(0) must always succeed, got", err)
+	}
+
+	// Unlinking a tombstone the follower has seen loses nothing.
+	since = list.Seq()
+	list.RemoveRange(orderedKey(1), orderedKey(2))
+	if _, err := list.Changes(since); err != nil {
+		t.Fatal(err)
+	}
+
+	since = list.Seq()
+	list.Clear()
+	if _, err := list.Changes(since); !errors.Is(err, ErrChangesLost) {
+		t.Fatal("clearing must be reported, got", err)
+	}
+}
@@ -0,0 +1,165 @@
+package skiplist
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Clear removes every element from the list at once, leaving it empty. Unlike creating a new
+// list, it keeps the list's allocations, such as its head tower and the unused part of its arena,
+// for the elements inserted next, which saves churning memory when a memtable is emptied and
+// refilled over and over. Clearing unlinks the head of the list rather than each element, so it
+// takes the list's lock only briefly however large the list. Iterators already walking the list
+// carry on over the cleared elements, as they do over removed ones.
+//
+// Cleared elements are reported to the remove callback with the Cleared reason once the list is
+// unlocked. Writes rejected by a frozen list clear nothing (see ClearE).
+func (list *SkipList) Clear() {
+	_ = list.ClearE()
+}
+
+// ClearE is like Clear, but returns an *Error wrapping ErrReadOnly if the list is frozen.
+func (list *SkipList) ClearE() error {
+	front, err := list.clear()
+	if err != nil {
+		return list.newError("Clear", nil, err)
+	}
+
+	if list.notifiesRemove() {
+		for element := front; element != nil; element = element.Next() {
+			list.notifyRemove(element, Cleared)
+		}
+	}
+	return nil
+}
+
+// clear empties the list, returning the first of the cleared elements.
+func (list *SkipList) clear() (*Element, error) {
+	list.lock(lockClear)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil {
+		return nil, ErrRangeFrozen
+	}
+
+	front := list.Front()
+	list.reset()
+	return front, nil
+}
+
+// reset empties the list by unlinking its head and zeroing its bookkeeping, keeping its
+// allocations. The caller must hold the list mutex.
+func (list *SkipList) reset() {
+	if list.watchers != nil {
+		list.emitDeletes()
+	}
+	if list.Length > 0 {
+		list.lostSeq = list.nextSeq()
+	}
+	for i := range list.next {
+		atomic.StorePointer(&list.next[i], nil)
+		list.tails[i] = &list.elementNode
+		list.levelCounts[i] = 0
+		if list.spans != nil {
+			list.spans[i] = 0
+		}
+	}
+	atomic.StorePointer(&list.last, nil)
+	list.Length = 0
+	list.weight = 0
+	list.keyBytes, list.valueBytes, list.nodeBytes = 0, 0, 0
+	list.tombstones = 0
+	list.linkVersion.Add(1)
+	list.evictHand = nil
+	clear(list.pinSites)
+	if list.history != nil {
+		list.versionsFloor = list.nextSeq()
+		list.history = list.newHistory()
+	}
+	if list.namespaces != nil {
+		for _, ns := range list.namespaces.namespaces {
+			ns.stats.Count = 0
+			ns.stats.Bytes = 0
+		}
+	}
+}
+
+// ClearIncremental removes every element present when it is called, taking the list's lock for
+// at most batchSize elements at a time and yielding to other goroutines between batches, so that
+// clearing a large list never blocks writers for long. Elements inserted or updated while it runs
+// are newer than the clear and are kept. Returns the number of elements removed, which are
+// reported to the remove callback with the Cleared reason.
+//
+// If the list is frozen part way through, the elements removed so far stay removed and the
+// error wraps ErrReadOnly.
+func (list *SkipList) ClearIncremental(batchSize int) (int, error) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	cut := list.Seq()
+	total := 0
+	var resume []byte
+	for started := false; !started || resume != nil; started = true {
+		removed, next, err := list.clearBatch(cut, resume, batchSize)
+		for _, element := range removed {
+			list.notifyRemove(element, Cleared)
+		}
+		total += len(removed)
+		if err != nil {
+			return total, list.newError("ClearIncremental", nil, err)
+		}
+
+		resume = next
+		runtime.Gosched()
+	}
+	return total, nil
+}
+
+// clearBatch visits up to batchSize elements starting at the first key >= resume, or at the
+// front of the list when resume is nil, and removes those not newer than cut. Returns the removed
+// elements and the key to resume from, which is nil once the end of the list is reached.
+func (list *SkipList) clearBatch(cut uint64, resume []byte, batchSize int) ([]*Element, []byte, error) {
+	list.lock(lockClear)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil {
+		return nil, nil, ErrRangeFrozen
+	}
+
+	var prevs []*elementNode
+	if resume == nil {
+		prevs = list.prevNodesCache
+		for i := range prevs {
+			prevs[i] = &list.elementNode
+		}
+	} else {
+		prevs = list.getPrevElementNodes(resume)
+	}
+
+	var removed []*Element
+	element := prevs[0].Next()
+	for visited := 0; element != nil && visited < batchSize; visited++ {
+		next := element.Next()
+		if element.Seq() <= cut {
+			list.unlink(prevs, element)
+			removed = append(removed, element)
+		} else {
+			for i := range element.next {
+				prevs[i] = &element.elementNode
+			}
+		}
+		element = next
+	}
+
+	if element == nil {
+		return removed, nil, nil
+	}
+	return removed, element.key, nil
+}
@@ -0,0 +1,127 @@
+package skiplist
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestClearIncremental(t *testing.T) {
+	var reasons []RemoveReason
+	list := New(WithRemoveCallback(func(element *Element, reason RemoveReason) {
+		reasons = append(reasons, reason)
+	}))
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	n, err := list.ClearIncremental(64)
+	if err != nil || n != 1000 {
+		t.Fatal("every element must be cleared", n, err)
+	}
+	if !list.IsEmpty() || list.Len() != 0 || list.MaxKey() != nil {
+		t.Fatal("the list must be empty")
+	}
+	if len(reasons) != 1000 || reasons[0] != Cleared {
+		t.Fatal("cleared elements must be reported", len(reasons))
+	}
+	checkSanity(list, t)
+
+	list.Set([]byte("a"), 1)
+	if list.Len() != 1 {
+		t.Fatal("a cleared list must remain usable")
+	}
+}
+
+func TestClearIncrementalKeepsConcurrentWrites(t *testing.T) {
+	var list *SkipList
+	var once sync.Once
+	list = New(WithRemoveCallback(func(*Element, RemoveReason) {
+		// Write between the first two batches, on both sides of the clear's position.
+		once.Do(func() {
+			for i := uint64(1); i < 1000; i += 2 {
+				list.Set(orderedKey(i), i)
+			}
+			list.Set(orderedKey(500), uint64(501))
+		})
+	}))
+	for i := uint64(0); i < 1000; i += 2 {
+		list.Set(orderedKey(i), i)
+	}
+
+	if _, err := list.ClearIncremental(16); err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(list, t)
+
+	for e := list.Front(); e != nil; e = e.Next() {
+		if e.Value().(uint64)%2 == 0 {
+			t.Fatal("elements present when clearing started must be removed", e.Value())
+		}
+	}
+	if list.Len() != 501 {
+		t.Fatal("writes made while clearing must be kept", list.Len())
+	}
+}
+
+func TestClearIncrementalFrozen(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+	list.Freeze()
+
+	if n, err := list.ClearIncremental(10); n != 0 || !errors.Is(err, ErrReadOnly) {
+		t.Fatal("clearing a frozen list must fail", n, err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	var cleared int
+	list := New(
+		WithRankIndex(),
+		WithWeigher(func(key []byte, value interface{}) int64 { return 1 }),
+		WithRemoveCallback(func(element *Element, reason RemoveReason) {
+			if reason == Cleared {
+				cleared++
+			}
+		}),
+	)
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	ns := list.Namespace(nil)
+	head := &list.next[0]
+	it := list.NewIterator()
+	it.SeekToFirst()
+
+	list.Clear()
+	checkSanity(list, t)
+	checkSpans(list, t)
+	if !list.IsEmpty() || list.Len() != 0 || list.Weight() != 0 || list.Back() != nil || ns.Stats().Count != 0 || cleared != 1000 {
+		t.Fatal("the list must be empty", list.Len(), list.Weight(), ns.Stats(), cleared)
+	}
+	if &list.next[0] != head {
+		t.Fatal("the head tower must be kept")
+	}
+
+	n := 0
+	for ; it.Valid(); it.Next() {
+		n++
+	}
+	if n != 1000 {
+		t.Fatal("iterators must carry on over cleared elements", n)
+	}
+
+	for i := uint64(0); i < 10; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	checkSanity(list, t)
+	checkSpans(list, t)
+	if list.Len() != 10 || list.Weight() != 10 || ns.Stats().Count != 10 {
+		t.Fatal("a cleared list must remain usable", list.Len(), list.Weight())
+	}
+
+	list.Freeze()
+	if err := list.ClearE(); !errors.Is(err, ErrReadOnly) || list.Len() != 10 {
+		t.Fatal("clearing a frozen list must fail", err)
+	}
+}
@@ -0,0 +1,155 @@
+package skiplist
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Clone returns an independent copy of the list, taken in a single pass under the list's read
+// lock, so that the copy holds the contents of the list at one point in time. The copy can be
+// handed to a flusher, say, while the list carries on being written, and writes to either do not
+// show in the other.
+//
+// The copied elements keep their sequence numbers, weights, tower heights and access times, and
+// values are copied as they are, so values that are pointers are shared. Keys are shared too if
+// shareKeys is set, which is safe since a list never modifies its keys, and copied otherwise, for
+// callers that reuse the key buffers they inserted.
+//
+// The copy keeps the list's configuration, other than its callbacks and eviction, as a frozen
+// list made by Rotate does. Its capacity, byte quota and insert verification stay, and the copy
+// counts its keys and values against the quota as the list does, so a copy of a full list is
+// full. It is writable even when the list is frozen, and has none of the list's pins,
+// namespaces, hot keys, ghosts or reservations.
+func (list *SkipList) Clone(shareKeys bool) *SkipList {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	clone := list.newLike()
+	prevs := clone.prevNodesCache
+	for element := list.Front(); element != nil; element = element.Next() {
+		key := element.key
+		if !shareKeys {
+			key = append([]byte(nil), key...)
+		}
+		copied := newElement(clone, key, element.Value(), len(element.next))
+		copied.weight = element.weight
+
+		copy(prevs, clone.tails)
+		clone.link(prevs, copied)
+		if element.IsTombstone() {
+			atomic.StorePointer(&copied.value, unsafe.Pointer(&tombstoneValue))
+			clone.tombstones++
+		}
+		copied.seq.Store(element.Seq())
+		copied.accessed.Store(element.accessed.Load())
+		copied.expires.Store(element.expires.Load())
+	}
+	clone.seq = list.seq
+	clone.versionsFloor = list.seq
+	clone.lostSeq = list.lostSeq
+	clone.inserts, clone.appends = 0, 0
+	return clone
+}
+
+// copyBatchSize is the number of elements CopyRange writes to the destination list at a time.
+const copyBatchSize = 1024
+
+// CopyRange writes the elements of the list with start <= key < end into dst, as when
+// re-partitioning data across shards, returning the number of elements written. A nil start or
+// end leaves that side of the range open. transform, if not nil, is called with each key and
+// value and returns the value to write, or false to leave the key out. Tombstones are not copied.
+//
+// The range is read with an iterator, so like any iteration it does not lock the list, and
+// written to dst in batches of up to 1024 elements with Apply, so dst is never locked for long
+// and sees the batches as they are written. Keys are shared between the lists, which is safe since
+// a list never modifies its keys. If a batch is rejected, CopyRange returns the number of elements
+// written before it and the error of Apply; the elements of the rejected batch are not written.
+func (list *SkipList) CopyRange(dst *SkipList, start, end []byte, transform func(key []byte, value interface{}) (interface{}, bool)) (int, error) {
+	var batch WriteBatch
+	n := 0
+	flush := func() error {
+		if err := dst.Apply(&batch); err != nil {
+			return err
+		}
+		n += batch.Len()
+		batch.Reset()
+		return nil
+	}
+
+	it := list.newBoundedIterator(start, end)
+	it.skipTombstones = true
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		key, value := it.Key(), it.Value()
+		if transform != nil {
+			var ok bool
+			if value, ok = transform(key, value); !ok {
+				continue
+			}
+		}
+
+		batch.Set(key, value)
+		if batch.Len() == copyBatchSize {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if batch.Len() > 0 {
+		if err := flush(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// newLike returns an empty list with the configuration of list, other than its callbacks,
+// eviction, watermarks and namespaces, which belong to list alone. Limits on writes, such as the
+// capacity and byte quota, and the insert verification's report are kept. The caller must hold
+// the list mutex.
+func (list *SkipList) newLike() *SkipList {
+	like := &SkipList{
+		name:          list.name,
+		labels:        list.labels,
+		maxKeySize:    list.maxKeySize,
+		compare:       list.compare,
+		byteOrder:     list.byteOrder,
+		maxLevel:      list.maxLevel,
+		expectedSize:  list.expectedSize,
+		randSource:    list.newRandSource(),
+		probability:   list.probability,
+		probTable:     list.probTable,
+		newRand:       list.newRand,
+		statsSampling: list.statsSampling,
+		weigher:       list.weigher,
+		valueSizer:    list.valueSizer,
+		fingerSearch:  list.fingerSearch,
+		merge:         list.merge,
+		trackAccess:   list.trackAccess,
+		tombstoneMode: list.tombstoneMode,
+		rankIndex:     list.rankIndex,
+		capacity:      list.capacity,
+		byteQuota:     list.byteQuota,
+	}
+	like.onOrderViolation = list.onOrderViolation
+	like.elementNode = elementNode{next: make([]unsafe.Pointer, list.maxLevel)}
+	like.prevNodesCache = make([]*elementNode, list.maxLevel)
+	like.tails = make([]*elementNode, list.maxLevel)
+	like.levelCounts = make([]int, list.maxLevel)
+	for i := range like.tails {
+		like.tails[i] = &like.elementNode
+	}
+	like.fingers.New = like.newFinger
+	if list.spans != nil {
+		like.spans = make([]int, list.maxLevel)
+		like.rankCache = make([]int, list.maxLevel)
+	}
+	if list.history != nil {
+		like.maxVersions = list.maxVersions
+		like.history = like.newHistory()
+	}
+	if list.lockWaits != nil {
+		like.lockWaits = new(lockWaits)
+	}
+	like.epoch.Store(&epoch{})
+	return like
+}
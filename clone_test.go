@@ -0,0 +1,103 @@
+package skiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	list := New(WithRankIndex())
+	for i := uint64(0); i < 200; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	list.Remove(orderedKey(7))
+
+	clone := list.Clone(false)
+	checkSanity(clone, t)
+	checkSpans(clone, t)
+	if clone.Len() != list.Len() || clone.Frozen() {
+		t.Fatal("the clone must hold every element", clone.Len(), list.Len())
+	}
+	for a, b := list.Front(), clone.Front(); a != nil || b != nil; a, b = a.Next(), b.Next() {
+		if a == nil || b == nil || string(a.Key()) != string(b.Key()) || a.Value() != b.Value() ||
+			a.Seq() != b.Seq() || len(a.next) != len(b.next) {
+			t.Fatal("the clone must match the list element by element")
+		}
+		if &a.Key()[0] == &b.Key()[0] {
+			t.Fatal("keys must be copied unless shared")
+		}
+	}
+
+	list.Set(orderedKey(1000), "new")
+	list.Remove(orderedKey(0))
+	clone.Set(orderedKey(7), "clone")
+	if clone.Get(orderedKey(1000)) != nil || clone.Get(orderedKey(0)) == nil || list.Get(orderedKey(7)) != nil {
+		t.Fatal("writes to the list and its clone must not show in the other")
+	}
+	if rank, ok := clone.Rank(orderedKey(7)); rank != 7 || !ok {
+		t.Fatal("the clone must keep the rank index", rank, ok)
+	}
+	if list.Get(orderedKey(1000)).Seq() <= clone.Get(orderedKey(199)).Seq() {
+		t.Fatal("the list's sequence must carry on past the cloned elements")
+	}
+
+	shared := list.Clone(true)
+	if &shared.Front().Key()[0] != &list.Front().Key()[0] {
+		t.Fatal("keys must be shared when asked")
+	}
+}
+
+func TestCopyRange(t *testing.T) {
+	list := New(WithTombstones())
+	for i := uint64(0); i < 3000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	list.Remove(orderedKey(100))
+
+	dst := New()
+	dst.Set(orderedKey(5000), "kept")
+	n, err := list.CopyRange(dst, orderedKey(0), orderedKey(2500), func(key []byte, value interface{}) (interface{}, bool) {
+		return value.(uint64) * 2, value.(uint64)%2 == 0
+	})
+	if err != nil || n != 1249 {
+		t.Fatal("wrong copy result", n, err)
+	}
+	checkSanity(dst, t)
+	if dst.Len() != 1250 || dst.Get(orderedKey(100)) != nil || dst.Get(orderedKey(1)) != nil {
+		t.Fatal("wrong elements copied", dst.Len())
+	}
+	if e := dst.Get(orderedKey(2498)); e == nil || e.Value() != uint64(4996) {
+		t.Fatal("value not transformed", e)
+	}
+
+	dst.Freeze()
+	if n, err := list.CopyRange(dst, nil, nil, nil); n != 0 || !errors.Is(err, ErrReadOnly) {
+		t.Fatal("copy into a frozen list must fail", n, err)
+	}
+}
+
+func TestCloneKeepsLimits(t *testing.T) {
+	list := New(WithCapacity(1), WithInsertVerification(func(OrderViolation) {}))
+	list.Set(orderedKey(1), 1)
+
+	clone := list.Clone(true)
+	if _, err := clone.SetE(orderedKey(2), 2); !errors.Is(err, ErrFull) {
+		t.Fatal("a clone must keep the capacity of its list", err)
+	}
+	if clone.onOrderViolation == nil {
+		t.Fatal("a clone must keep the insert verification of its list")
+	}
+}
+
+func TestCloneKeepsByteQuota(t *testing.T) {
+	list := New(WithByteQuota(16))
+	list.Set([]byte("a"), "0123456789")
+
+	clone := list.Clone(true)
+	if _, err := clone.SetE([]byte("b"), "0123456789"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatal("a clone must keep the byte quota of its list", err)
+	}
+	if _, err := clone.SetE([]byte("b"), "01"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,197 @@
+package skiplist
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// CompactionStats describes a compaction of a list, as made by Compact or predicted by
+// CompactDryRun.
+type CompactionStats struct {
+	// Elements is the number of elements moved, tombstones included.
+	Elements int
+	// Tombstones is the number of moved elements that are tombstones.
+	Tombstones int
+	// Nodes is the number of tower levels of the moved elements, each of which is a node linked
+	// into one level of the list.
+	Nodes int
+	// BytesBefore and BytesAfter are the memory held by the list before and after the
+	// compaction. Unlike ApproxMemoryUsage, they count the whole of the chunks of the list's
+	// arena, including the space of removed elements the chunks still hold.
+	BytesBefore int64
+	BytesAfter  int64
+	// Duration is how long the compaction held the list's lock, or zero for a dry run.
+	Duration time.Duration
+}
+
+// Reclaimed returns the number of bytes the compaction frees, BytesBefore less BytesAfter.
+func (s CompactionStats) Reclaimed() int64 {
+	return s.BytesBefore - s.BytesAfter
+}
+
+// Compact moves the elements of a list constructed WithArena to a fresh arena, freeing the
+// chunks of the old one, which keep the space of every element removed since they were
+// allocated. It holds the list's lock while it copies every element, so CompactDryRun can tell
+// beforehand whether the memory it frees is worth stopping writers for. A list without an arena
+// has nothing to reclaim: Compact leaves it as it is and reports as much.
+//
+// The moved elements keep their keys, values, sequence numbers, weights, tower heights, access
+// and expiry times and pins, and tombstones stay tombstones, but they are new elements: an
+// *Element obtained before Compact no longer reflects writes to its key, and iterators positioned
+// before Compact carry on over the elements as they were. Compacting a frozen list fails with
+// ErrReadOnly, and a list with frozen ranges with ErrRangeFrozen.
+func (list *SkipList) Compact() (CompactionStats, error) {
+	stats, err := list.compact()
+	if err != nil {
+		return CompactionStats{}, list.newError("Compact", nil, err)
+	}
+	return stats, nil
+}
+
+// CompactDryRun returns the statistics Compact would report if it were called now, other than
+// its Duration, without moving any element. It walks the list under the list's read lock.
+func (list *SkipList) CompactDryRun() CompactionStats {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.planCompaction()
+}
+
+// planCompaction predicts the statistics of compacting the list. The caller must hold the list
+// mutex.
+func (list *SkipList) planCompaction() CompactionStats {
+	before := list.heldBytes(list.arena)
+	if list.arena == nil {
+		return CompactionStats{BytesBefore: before, BytesAfter: before}
+	}
+
+	stats := CompactionStats{BytesBefore: before}
+	plan := arenaPlan{chunkSize: list.arena.chunkSize}
+	for element := list.Front(); element != nil; element = element.Next() {
+		plan.add(len(element.next), len(element.key))
+		stats.Elements++
+		stats.Nodes += len(element.next)
+		if element.IsTombstone() {
+			stats.Tombstones++
+		}
+	}
+	stats.BytesAfter = list.heldBytes(&arena{held: plan.held})
+	return stats
+}
+
+// heldBytes returns the memory held by the list if its elements were allocated from a, or if
+// a is nil, by the Go allocator. The caller must hold the list mutex.
+func (list *SkipList) heldBytes(a *arena) int64 {
+	if a == nil {
+		return list.fixedBytes() + list.nodeBytes + list.keyBytes + list.valueBytes
+	}
+	// The arena holds the elements, towers and keys, but not the values or rank spans.
+	var spans int64
+	if list.spans != nil {
+		for _, count := range list.levelCounts {
+			spans += int64(count) * intSize
+		}
+	}
+	return list.fixedBytes() + a.held + list.valueBytes + spans
+}
+
+func (list *SkipList) compact() (CompactionStats, error) {
+	list.lock(lockCompact)
+	defer list.unlock()
+
+	if list.frozen {
+		return CompactionStats{}, ErrReadOnly
+	}
+	if list.frozenRanges != nil {
+		return CompactionStats{}, ErrRangeFrozen
+	}
+	start := time.Now()
+	stats := CompactionStats{BytesBefore: list.heldBytes(list.arena)}
+	if list.arena == nil {
+		stats.BytesAfter = stats.BytesBefore
+		return stats, nil
+	}
+
+	// The elements are linked into a fresh list first, and the list's head then pointed at them,
+	// so that readers find either every old element or every new one.
+	fresh := list.newLike()
+	fresh.arena = newArena(list.arena.chunkSize)
+	var pinSites map[*Element][]pinSite
+	if list.pinSites != nil {
+		pinSites = make(map[*Element][]pinSite, len(list.pinSites))
+	}
+	prevs := fresh.prevNodesCache
+	for element := list.Front(); element != nil; element = element.Next() {
+		copied := fresh.arena.newElement(list, element.key, element.Value(), len(element.next))
+		copied.weight = element.weight
+
+		copy(prevs, fresh.tails)
+		fresh.link(prevs, copied)
+		if element.IsTombstone() {
+			atomic.StorePointer(&copied.value, unsafe.Pointer(&tombstoneValue))
+			stats.Tombstones++
+		}
+		copied.seq.Store(element.Seq())
+		copied.accessed.Store(element.accessed.Load())
+		copied.expires.Store(element.expires.Load())
+		copied.pins = element.pins
+		if sites, ok := list.pinSites[element]; ok {
+			pinSites[copied] = sites
+		}
+		stats.Elements++
+		stats.Nodes += len(element.next)
+	}
+
+	for i := range list.next {
+		list.tails[i] = fresh.tails[i]
+		if list.tails[i] == &fresh.elementNode {
+			list.tails[i] = &list.elementNode
+		}
+		atomic.StorePointer(&list.next[i], atomic.LoadPointer(&fresh.next[i]))
+	}
+	atomic.StorePointer(&list.last, atomic.LoadPointer(&fresh.last))
+	copy(list.spans, fresh.spans)
+	list.nodeBytes = fresh.nodeBytes
+	list.arena = fresh.arena
+	list.pinSites = pinSites
+	list.linkVersion.Add(1)
+
+	stats.BytesAfter = list.heldBytes(list.arena)
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
+
+// arenaPlan follows the chunk allocations of an arena of chunkSize elements, without making
+// them, to predict the bytes a compacted arena holds. It must be kept in step with arena.
+type arenaPlan struct {
+	chunkSize int
+	// elements, towers and keys are the space left in the current chunk of each kind.
+	elements, towers, keys int
+	held                   int64
+}
+
+func (p *arenaPlan) add(level, keyLen int) {
+	if p.elements == 0 {
+		p.elements = p.chunkSize
+		p.held += int64(p.chunkSize) * elementSize
+	}
+	p.elements--
+
+	if p.towers < level {
+		p.towers = 2*p.chunkSize + level
+		p.held += int64(p.towers) * pointerSize
+	}
+	p.towers -= level
+
+	if keyLen > p.keys {
+		size := 64 * p.chunkSize
+		if keyLen > size/8 {
+			p.held += int64(keyLen)
+			return
+		}
+		p.keys = size
+		p.held += int64(size)
+	}
+	p.keys -= keyLen
+}
@@ -0,0 +1,85 @@
+package skiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	list := New(WithArena(16), WithRankIndex())
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if i%10 != 0 {
+			list.Remove(orderedKey(i))
+		}
+	}
+	list.Pin(orderedKey(20))
+	stale := list.Get(orderedKey(10))
+
+	// The arena still holds the space of the removed elements, which compacting frees.
+	plan := list.CompactDryRun()
+	if plan.Elements != 100 || plan.Reclaimed() <= 0 || plan.Duration != 0 {
+		t.Fatal("wrong dry run", plan)
+	}
+
+	stats, err := list.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Elements != plan.Elements || stats.Nodes != plan.Nodes || stats.BytesBefore != plan.BytesBefore ||
+		stats.BytesAfter != plan.BytesAfter || stats.Duration <= 0 {
+		t.Fatal("compaction did not match its dry run", stats, plan)
+	}
+	checkSanity(list, t)
+
+	if list.Len() != 100 || list.Pins(orderedKey(20)) != 1 {
+		t.Fatal("compaction lost state", list.Len(), list.Pins(orderedKey(20)))
+	}
+	if e := list.GetByRank(5); e == nil || e.Value().(uint64) != 50 {
+		t.Fatal("wrong element", e)
+	}
+	list.Set(orderedKey(10), "new")
+	if e := list.Get(orderedKey(10)); e == stale || e.Value() != "new" {
+		t.Fatal("compaction must move elements", e)
+	}
+	if again := list.CompactDryRun(); again.Reclaimed() != 0 {
+		t.Fatal("compacted list has nothing to reclaim", again)
+	}
+}
+
+func TestCompactTombstones(t *testing.T) {
+	list := New(WithArena(16), WithTombstones())
+	list.Set(orderedKey(1), 1)
+	list.Set(orderedKey(2), 2)
+	list.Remove(orderedKey(1))
+
+	stats, err := list.Compact()
+	if err != nil || stats.Elements != 2 || stats.Tombstones != 1 {
+		t.Fatal("wrong stats", stats, err)
+	}
+	if e := list.Get(orderedKey(1)); e == nil || !e.IsTombstone() || list.Tombstones() != 1 {
+		t.Fatal("compaction must keep tombstones", e)
+	}
+	checkSanity(list, t)
+}
+
+func TestCompactWithoutArena(t *testing.T) {
+	list := New()
+	list.Set(orderedKey(1), 1)
+	e := list.Get(orderedKey(1))
+
+	stats, err := list.Compact()
+	if err != nil || stats.Elements != 0 || stats.Reclaimed() != 0 || stats.BytesBefore != list.ApproxMemoryUsage() {
+		t.Fatal("wrong stats", stats, err)
+	}
+	if list.Get(orderedKey(1)) != e {
+		t.Fatal("lists without an arena are not compacted")
+	}
+
+	frozen, _ := list.Rotate()
+	if _, err := frozen.Compact(); !errors.Is(err, ErrReadOnly) {
+		t.Fatal("frozen lists cannot be compacted", err)
+	}
+}
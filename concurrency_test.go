@@ -0,0 +1,170 @@
+package skiplist
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// These tests are meaningful under the race detector: go test -race.
+
+func TestReadYourWritesAcrossGoroutines(t *testing.T) {
+	list := New()
+	key := []byte("counter")
+	list.Set(key, int64(0))
+
+	const writes = 20000
+	var published int64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := int64(1); i <= writes; i++ {
+			list.Set(key, i)
+			atomic.StoreInt64(&published, i)
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			held := list.Get(key)
+			for {
+				p := atomic.LoadInt64(&published)
+				if v := list.Get(key).Value().(int64); v < p {
+					t.Errorf("read %d after %d was published", v, p)
+					return
+				}
+				if v := held.Value().(int64); v < p {
+					t.Errorf("held element read %d after %d was published", v, p)
+					return
+				}
+				if p == writes {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestConcurrentInsertRemoveIterate(t *testing.T) {
+	list := New()
+	var wg sync.WaitGroup
+
+	for w := uint64(0); w < 4; w++ {
+		wg.Add(1)
+		go func(w uint64) {
+			defer wg.Done()
+			for i := uint64(0); i < 5000; i++ {
+				key := orderedKey(i*4 + w)
+				list.Set(key, i)
+				if i%3 == 0 {
+					list.Remove(key)
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				var prev []byte
+				for e := list.Front(); e != nil; e = e.Next() {
+					if prev != nil && orderedKeyValue(e.Key()) <= orderedKeyValue(prev) {
+						t.Error("iteration out of order")
+						return
+					}
+					prev = e.Key()
+					_ = e.Value()
+				}
+				_ = list.Len()
+			}
+		}()
+	}
+
+	wg.Wait()
+	checkSanity(list, t)
+}
+
+func TestReadsDoNotWaitForWriters(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	// Hold the write lock as a writer would; every read must still complete.
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		list.Get(orderedKey(50))
+		list.Seek(orderedKey(50))
+		list.SeekForPrev(orderedKey(50))
+		list.KeysBetween(nil, nil, func([]byte) bool { return true })
+
+		it := list.NewIterator()
+		for it.SeekToLast(); it.Valid(); it.Prev() {
+		}
+		it.Seek(orderedKey(10))
+		it.SeekLT(orderedKey(10))
+		it.SeekForPrev(orderedKey(10))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("reads must not block behind the write lock")
+	}
+}
+
+func TestLockFreeGetDuringChurn(t *testing.T) {
+	list := New()
+	// Even keys are stable; odd keys are inserted and removed concurrently.
+	for i := uint64(0); i < 1000; i += 2 {
+		list.Set(orderedKey(i), i)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for i := uint64(1); i < 1000; i += 2 {
+				list.Set(orderedKey(i), i)
+			}
+			for i := uint64(1); i < 1000; i += 2 {
+				list.Remove(orderedKey(i))
+			}
+		}
+	}()
+
+	for n := 0; n < 20; n++ {
+		for i := uint64(0); i < 1000; i += 2 {
+			if e := list.Get(orderedKey(i)); e == nil || e.Value().(uint64) != i {
+				t.Fatal("stable key must always be found", i)
+			}
+		}
+		last := list.NewIterator()
+		if last.SeekToLast(); !last.Valid() || last.Value().(uint64) < 998 {
+			t.Fatal("wrong last element", last.Element())
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+	checkSanity(list, t)
+}
@@ -0,0 +1,170 @@
+package skiplist
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/m3db/fast-skiplist/internal/tower"
+)
+
+// ConcurrentSkipList is an insert-only skip list whose writers do not lock: each level is
+// linked with a compare-and-swap on the previous node's next pointer, in the style of the
+// LevelDB and Badger memtables, so that any number of goroutines may insert at the same time.
+// Dropping removal is what makes this simple; a memtable is discarded as a whole once flushed.
+//
+// Reads and iteration behave as they do for SkipList. Elements are only linked forwards, so
+// their Prev is always nil.
+type ConcurrentSkipList struct {
+	elementNode
+	name       string
+	compare    func(a, b []byte) int
+	maxLevel   int
+	probTable  []float64
+	length     atomic.Int64
+	retryLimit int
+	// fallback is held shared by writers linking an element and exclusively by a writer that
+	// exceeded the retry limit, which then links its element without contention.
+	fallback      sync.RWMutex
+	casRetries    atomic.Uint64
+	lockFallbacks atomic.Uint64
+}
+
+// NewConcurrent creates a new concurrent skip list. Of the options, WithMaxLevel,
+// WithProbability, WithComparator, WithName and WithRetryLimit apply; the others configure
+// features that need a write lock and are ignored.
+func NewConcurrent(opts ...Option) *ConcurrentSkipList {
+	config := configure(opts...)
+	return &ConcurrentSkipList{
+		elementNode: elementNode{next: make([]unsafe.Pointer, config.maxLevel)},
+		name:        config.name,
+		compare:     config.compare,
+		maxLevel:    config.maxLevel,
+		probTable:   config.probTable,
+		retryLimit:  config.retryLimit,
+	}
+}
+
+// Stats returns a summary of the list. Of its fields, Name, Length, MaxLevel, CASRetries and
+// LockFallbacks apply.
+func (list *ConcurrentSkipList) Stats() Stats {
+	return Stats{
+		Name:          list.name,
+		Length:        list.Len(),
+		MaxLevel:      list.maxLevel,
+		CASRetries:    list.casRetries.Load(),
+		LockFallbacks: list.lockFallbacks.Load(),
+	}
+}
+
+// Len returns the number of elements in the list.
+func (list *ConcurrentSkipList) Len() int {
+	return int(list.length.Load())
+}
+
+// Front returns the first element of the list, or nil if it is empty.
+func (list *ConcurrentSkipList) Front() *Element {
+	return list.Next()
+}
+
+// Set inserts a value in the list with the specified key, or updates the value of the
+// existing element with that key. It may be called concurrently with any other method.
+func (list *ConcurrentSkipList) Set(key []byte, value interface{}) *Element {
+	element, _ := list.set(key, value)
+	return element
+}
+
+// set is Set, also returning the number of compare-and-swaps it retried.
+func (list *ConcurrentSkipList) set(key []byte, value interface{}) (*Element, int) {
+	var (
+		prevs [64]*elementNode
+		nexts [64]*Element
+	)
+
+	prev := &list.elementNode
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		prevs[i], nexts[i] = list.findSplice(key, prev, i)
+		if next := nexts[i]; next != nil && list.compare(key, next.key) == 0 {
+			next.storeValue(value)
+			return next, 0
+		}
+		prev = prevs[i]
+	}
+
+	retries, locked := 0, false
+	list.fallback.RLock()
+	defer func() {
+		if locked {
+			list.fallback.Unlock()
+		} else {
+			list.fallback.RUnlock()
+		}
+	}()
+
+	element := newElement(nil, key, value, tower.Level(tower.SharedSource{}, list.probTable))
+	for i := range element.next {
+		for {
+			atomic.StorePointer(&element.next[i], unsafe.Pointer(nexts[i]))
+			if atomic.CompareAndSwapPointer(&prevs[i].next[i], unsafe.Pointer(nexts[i]), unsafe.Pointer(element)) {
+				break
+			}
+
+			list.casRetries.Add(1)
+			if retries++; !locked && list.retryLimit > 0 && retries > list.retryLimit {
+				// Taking the lock exclusively waits out the writers linking elements and keeps
+				// new ones from starting, so the compare-and-swaps below cannot fail again.
+				list.fallback.RUnlock()
+				list.fallback.Lock()
+				locked = true
+				list.lockFallbacks.Add(1)
+			}
+
+			// Another writer linked an element between prevs[i] and nexts[i]; search again
+			// from prevs[i], which is still before key. Once the element is linked on the bottom
+			// level no other writer can insert the same key, so only the bottom level can race.
+			prevs[i], nexts[i] = list.findSplice(key, prevs[i], i)
+			if next := nexts[i]; i == 0 && next != nil && list.compare(key, next.key) == 0 {
+				next.storeValue(value)
+				return next, retries
+			}
+		}
+	}
+
+	list.length.Add(1)
+	return element, retries
+}
+
+// findSplice returns the nodes between which key belongs on level i, starting from start.
+func (list *ConcurrentSkipList) findSplice(key []byte, start *elementNode, i int) (*elementNode, *Element) {
+	prev := start
+	next := prev.NextAt(i)
+	for next != nil && list.compare(key, next.key) > 0 {
+		prev = &next.elementNode
+		next = next.NextAt(i)
+	}
+	return prev, next
+}
+
+// Get finds an element by key. It returns element pointer if found, nil if not found.
+func (list *ConcurrentSkipList) Get(key []byte) *Element {
+	if element := list.Seek(key); element != nil && list.compare(element.key, key) == 0 {
+		return element
+	}
+	return nil
+}
+
+// Seek returns the first element whose key is greater than or equal to key,
+// or nil if every element sorts before key.
+func (list *ConcurrentSkipList) Seek(key []byte) *Element {
+	prev := &list.elementNode
+	var next *Element
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		prev, next = list.findSplice(key, prev, i)
+	}
+	return next
+}
+
+// Name returns the name the list was constructed with, if any.
+func (list *ConcurrentSkipList) Name() string {
+	return list.name
+}
@@ -0,0 +1,188 @@
+package skiplist
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentSkipListInsert(t *testing.T) {
+	list := NewConcurrent()
+
+	// Writers insert overlapping ranges so that they race on the same keys as well as on
+	// the same gaps.
+	const writers, keys = 8, 5000
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				k := uint64((i*7 + w*keys/writers) % keys)
+				list.Set(orderedKey(k), k)
+			}
+		}(w)
+	}
+
+	// Readers must always see increasing keys.
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				var prev []byte
+				for e := list.Front(); e != nil; e = e.Next() {
+					if prev != nil && bytes.Compare(prev, e.key) >= 0 {
+						t.Error("keys out of order", prev, e.key)
+						return
+					}
+					prev = e.key
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if list.Len() != keys {
+		t.Fatal("every key must be inserted exactly once", list.Len())
+	}
+
+	for k := range list.next {
+		for e := list.NextAt(k); e != nil && e.NextAt(k) != nil; e = e.NextAt(k) {
+			if bytes.Compare(e.key, e.NextAt(k).key) >= 0 {
+				t.Fatalf("keys out of order on level %d", k)
+			}
+		}
+	}
+
+	n := 0
+	for e := list.Front(); e != nil; e = e.Next() {
+		if orderedKeyValue(e.Key()) != uint64(n) || e.Value().(uint64) != uint64(n) {
+			t.Fatal("wrong element", n, e.Key())
+		}
+		n++
+	}
+
+	if e := list.Get(orderedKey(42)); e == nil || e.Value().(uint64) != 42 {
+		t.Fatal("Get must find inserted keys", e)
+	}
+	if list.Get(orderedKey(keys)) != nil {
+		t.Fatal("Get must not find missing keys")
+	}
+}
+
+func TestConcurrentSkipListRetryLimit(t *testing.T) {
+	list := NewConcurrent(WithRetryLimit(1))
+
+	// Writers append to the same end of the list, so that they keep losing races to each other.
+	const writers, keys = 8, 2000
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				list.Set(orderedKey(uint64(i*writers+w)), i)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := list.Stats()
+	if stats.Length != writers*keys || list.Len() != writers*keys {
+		t.Fatal("every key must be inserted", stats.Length)
+	}
+	if stats.LockFallbacks > stats.CASRetries {
+		t.Fatal("only writes that retried may fall back to the lock", stats.LockFallbacks, stats.CASRetries)
+	}
+	n := 0
+	for e := list.Front(); e != nil; e = e.Next() {
+		if orderedKeyValue(e.Key()) != uint64(n) {
+			t.Fatal("wrong element", n, e.Key())
+		}
+		n++
+	}
+}
+
+func TestConcurrentSkipListRetriesBounded(t *testing.T) {
+	const limit = 2
+	list := NewConcurrent(WithRetryLimit(limit))
+
+	const writers, keys = 16, 2000
+	var (
+		wg         sync.WaitGroup
+		maxRetries atomic.Int64
+	)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				_, retries := list.set(orderedKey(uint64(i*writers+w)), i)
+				for {
+					max := maxRetries.Load()
+					if int64(retries) <= max || maxRetries.CompareAndSwap(max, int64(retries)) {
+						break
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if max := maxRetries.Load(); max > limit+1 {
+		t.Fatal("a write falling back to the lock must not retry again", max)
+	}
+	if list.Len() != writers*keys {
+		t.Fatal("every key must be inserted", list.Len())
+	}
+}
+
+func TestConcurrentSkipListNoSweeper(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		NewConcurrent(WithExpirySweeper(time.Millisecond, 8))
+		NewIntrusive[intrusiveOrder](WithExpirySweeper(time.Millisecond, 8))
+	}
+	if after := runtime.NumGoroutine(); after >= before+10 {
+		t.Fatal("options must not start goroutines for lists that ignore them", before, after)
+	}
+}
+
+func TestConcurrentSkipListOptions(t *testing.T) {
+	list := NewConcurrent(WithName("memtable"), WithMaxLevel(4), WithComparator(func(a, b []byte) int {
+		return bytes.Compare(b, a)
+	}))
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 2)
+	list.Set([]byte("a"), 3)
+
+	if list.Name() != "memtable" || list.maxLevel != 4 || list.Len() != 2 {
+		t.Fatal("options must apply", list.Name(), list.maxLevel, list.Len())
+	}
+	if e := list.Front(); string(e.Key()) != "b" || e.Next().Value().(int) != 3 {
+		t.Fatal("the comparator must order the list", e.Key())
+	}
+}
+
+func BenchmarkParallelInsert(b *testing.B) {
+	b.Run("SkipList", func(b *testing.B) {
+		list := New()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				list.Set(benchKey(i), i)
+			}
+		})
+	})
+	b.Run("ConcurrentSkipList", func(b *testing.B) {
+		list := NewConcurrent()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				list.Set(benchKey(i), i)
+			}
+		})
+	})
+}
@@ -0,0 +1,24 @@
+// Package skiplist implements a fast, concurrency-safe skip list keyed by []byte.
+//
+// # Concurrency
+//
+// All methods of SkipList may be called concurrently. Writes (Set, Remove and friends) are
+// serialized by the list's mutex and take effect at a single point while it is held, so the
+// list behaves as if operations ran one at a time in some order consistent with real time:
+//
+//   - Once Set returns, every Get that starts afterwards, on any goroutine, observes the
+//     write (or a later one). In particular a goroutine always reads its own writes.
+//   - Element.Value is safe to call while the same key is being updated. Values are swapped
+//     atomically, so it returns either the old or the new value, never a mix of the two.
+//   - Once Remove returns, Get no longer finds the key. Elements obtained before the removal
+//     stay readable, but are no longer part of the list.
+//
+// Reads (Get, Seek, SeekForPrev, Front, Element.Next and Iterator) do not lock the list, so
+// readers never wait for writers or for each other. Links are published with atomic stores,
+// which order each write before any read that observes it. An iteration observes every element
+// that was present for its whole duration, may or may not observe elements inserted or removed
+// while it runs, and always yields keys in increasing order.
+//
+// The exported Length field is only safe to read while no writes are in flight; use Len
+// from concurrent code.
+package skiplist
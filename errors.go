@@ -0,0 +1,120 @@
+package skiplist
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrNotFound is returned when the requested key is not in the list.
+	ErrNotFound = errors.New("key not found")
+	// ErrKeyTooLarge is returned when a key exceeds the list's maximum key size.
+	ErrKeyTooLarge = errors.New("key too large")
+	// ErrReadOnly is returned when writing to a frozen list.
+	ErrReadOnly = errors.New("list is read-only")
+	// ErrRangeReserved is returned when writing a key in a range reserved by ReserveRange.
+	ErrRangeReserved = errors.New("key range is reserved")
+	// ErrNotSorted is returned when input required to be in increasing key order is not.
+	ErrNotSorted = errors.New("keys are not in increasing order")
+	// ErrNoMergeOperator is returned by Merge on a list constructed without a merge operator.
+	ErrNoMergeOperator = errors.New("list has no merge operator")
+	// ErrRangeFrozen is returned when writing a key in a range frozen by FreezeRange.
+	ErrRangeFrozen = errors.New("key range is frozen")
+	// ErrElementReplaced is returned when a write of a key already in a frozen list is forwarded
+	// to its overflow list, so the returned element is not the one the frozen list holds.
+	ErrElementReplaced = errors.New("element replaced")
+	// ErrFull is returned when inserting a key into a list holding as many elements as the
+	// capacity set WithCapacity.
+	ErrFull = errors.New("list is full")
+	// ErrQuotaExceeded is returned when a write would take the bytes of a list's keys and values
+	// past the quota set WithByteQuota.
+	ErrQuotaExceeded = errors.New("byte quota exceeded")
+	// ErrVersionDiscarded is returned by reads at a sequence number whose version of the key is
+	// no longer kept by the list.
+	ErrVersionDiscarded = errors.New("version discarded")
+	// ErrNoTombstones is returned by Changes on a list constructed without WithTombstones, which
+	// could not report the keys removed since.
+	ErrNoTombstones = errors.New("list does not keep tombstones")
+	// ErrChangesLost is returned by Changes when a key was removed since without leaving a
+	// tombstone to report its removal.
+	ErrChangesLost = errors.New("changes lost")
+)
+
+// Error describes a failed list operation. Use errors.Is to test for the underlying cause.
+type Error struct {
+	// List identifies the list the operation was performed on.
+	List string
+	// Op is the name of the failed operation, e.g. "Set".
+	Op string
+	// Key is the key the operation was called with.
+	Key []byte
+	// Err is the underlying cause, one of the Err* values of this package, or the error of the
+	// context of an operation taking one.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.List + ": " + e.Op + " " + quoteKey(e.Key) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is the value a write re-panics with, once it has released the list's lock, when it
+// panics while holding it, typically because a callback called under the lock did: the
+// comparator, an insert or update hook, a merge operator or an UpdateFunc. Elements are linked
+// and unlinked with the callbacks' calls either before or after them, so the list stays
+// consistent and usable, though the panicking write may be partly applied, such as a
+// TransformValues that stopped halfway. Lock-free reads, such as Get and iteration, hold no lock
+// and let panics through unchanged.
+type PanicError struct {
+	// List identifies the list the operation was performed on.
+	List string
+	// Op is the name of the operation that panicked, e.g. "Set".
+	Op string
+	// Value is the value the operation panicked with.
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return e.List + ": panic in " + e.Op + ": " + fmt.Sprint(e.Value)
+}
+
+// Unwrap returns the value panicked with if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// newPanic returns the value to re-panic with after op panicked with r, keeping the context of
+// the innermost list if the panic passed through several, as an overflow list's does.
+func (list *SkipList) newPanic(op string, r interface{}) interface{} {
+	if _, ok := r.(*PanicError); ok {
+		return r
+	}
+	return &PanicError{List: list.String(), Op: op, Value: r}
+}
+
+func (list *SkipList) newError(op string, key []byte, err error) error {
+	return &Error{List: list.String(), Op: op, Key: key, Err: err}
+}
+
+// checkKey validates a key against the list's limits.
+func (list *SkipList) checkKey(op string, key []byte) error {
+	if list.maxKeySize > 0 && len(key) > list.maxKeySize {
+		return list.newError(op, key, ErrKeyTooLarge)
+	}
+	return nil
+}
+
+// quoteKey formats a key for error messages, truncating long keys.
+func quoteKey(key []byte) string {
+	const maxQuoted = 32
+	if len(key) > maxQuoted {
+		return strconv.Quote(string(key[:maxQuoted])) + "..."
+	}
+	return strconv.Quote(string(key))
+}
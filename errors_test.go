@@ -0,0 +1,115 @@
+package skiplist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorReturningAPI(t *testing.T) {
+	list := New(WithName("errs"), WithMaxKeySize(4))
+
+	if _, err := list.SetE([]byte("toolong"), 1); !errors.Is(err, ErrKeyTooLarge) {
+		t.Fatal("expected ErrKeyTooLarge, got", err)
+	}
+
+	if list.Set([]byte("toolong"), 1) != nil || list.Length != 0 {
+		t.Fatal("Set must reject keys that are too large")
+	}
+
+	if e, err := list.SetE([]byte("ok"), 1); err != nil || e == nil {
+		t.Fatal("unexpected SetE failure", err)
+	}
+
+	if e, err := list.GetE([]byte("ok")); err != nil || e.Value().(int) != 1 {
+		t.Fatal("unexpected GetE result", e, err)
+	}
+
+	_, err := list.GetE([]byte("nope"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("expected ErrNotFound, got", err)
+	}
+
+	var listErr *Error
+	if !errors.As(err, &listErr) || listErr.Op != "Get" || !strings.Contains(err.Error(), `"errs"`) {
+		t.Fatal("error must identify the list and operation", err)
+	}
+
+	if _, err := list.RemoveE([]byte("ok")); err != nil {
+		t.Fatal("unexpected RemoveE failure", err)
+	}
+
+	if _, err := list.RemoveE([]byte("ok")); !errors.Is(err, ErrNotFound) {
+		t.Fatal("expected ErrNotFound, got", err)
+	}
+}
+
+func TestByteQuota(t *testing.T) {
+	list := New(WithByteQuota(10))
+
+	if _, err := list.SetE([]byte("a"), []byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.SetE([]byte("b"), []byte("12345")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded, got", err)
+	}
+	if _, err := list.UpdateE([]byte("a"), func(old interface{}) (interface{}, bool) {
+		return []byte("1234567890"), true
+	}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded from Update, got", err)
+	}
+
+	var batch WriteBatch
+	batch.Set([]byte("c"), []byte("12"))
+	batch.Set([]byte("d"), []byte("12"))
+	if err := list.Apply(&batch); !errors.Is(err, ErrQuotaExceeded) || list.Len() != 1 {
+		t.Fatal("expected ErrQuotaExceeded from Apply, got", err, list.Len())
+	}
+
+	// Shrinking writes and removals always succeed, and make room.
+	if _, err := list.SetE([]byte("a"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.SetE([]byte("b"), []byte("12345")); err != nil {
+		t.Fatal(err)
+	}
+	if stats := list.Stats(); stats.KeyBytes+stats.ValueBytes != 8 {
+		t.Fatal("wrong byte count", stats.KeyBytes, stats.ValueBytes)
+	}
+}
+
+func TestCallbackPanics(t *testing.T) {
+	list := New(WithName("panics"), WithComparator(func(a, b []byte) int {
+		if string(a) == "bad" || string(b) == "bad" {
+			panic("bad key")
+		}
+		return strings.Compare(string(a), string(b))
+	}))
+	list.Set([]byte("a"), 1)
+
+	recovered := func(fn func()) (r interface{}) {
+		defer func() { r = recover() }()
+		fn()
+		return nil
+	}
+
+	r := recovered(func() { list.Set([]byte("bad"), 2) })
+	if p, ok := r.(*PanicError); !ok || p.Op != "Set" || p.Value != "bad key" || !strings.Contains(p.Error(), "panics") {
+		t.Fatal("a panicking comparator must re-panic with context", r)
+	}
+
+	errUpdate := errors.New("update failed")
+	r = recovered(func() {
+		list.Update([]byte("a"), func(old interface{}) (interface{}, bool) { panic(errUpdate) })
+	})
+	if err, ok := r.(error); !ok || !errors.Is(err, errUpdate) {
+		t.Fatal("a panicking UpdateFunc must re-panic wrapping its error", r)
+	}
+
+	// The lock was released, so the list is still usable.
+	list.Set([]byte("b"), 3)
+	if list.Len() != 2 || list.Get([]byte("a")).Value() != 1 {
+		t.Fatal("the list must stay consistent after a panic", list.Len())
+	}
+	checkSanity(list, t)
+}
@@ -0,0 +1,50 @@
+package skiplist
+
+// finger caches the search path of a recent lookup: the last element before the key on each
+// level, nil standing for the head, as of the given version of the list's links.
+//
+// Fingers are kept in a sync.Pool, which holds them per processor, and each search borrows one
+// for its duration. Unlike the list's prevNodesCache, which only writers holding the lock may
+// use, a finger is therefore never shared by concurrent searches.
+type finger struct {
+	prevs   []*Element
+	version uint64
+}
+
+func (list *SkipList) newFinger() interface{} {
+	return &finger{prevs: make([]*Element, list.maxLevel)}
+}
+
+// start returns where a search for the elements before key can begin: the lowest level on which
+// the cached element precedes key and is directly followed by an element that does not, along
+// with that element. Returns nil and the top level when no cached element qualifies.
+//
+// The path is only used if the list's links are still at the version it was cached at, as it
+// could otherwise hold removed elements, whose forward pointers skip elements inserted since.
+// Fingers therefore pay off on read-mostly lists.
+func (f *finger) start(list *SkipList, key []byte, orEqual bool, version uint64) (*Element, int) {
+	if f.version != version {
+		return nil, list.maxLevel - 1
+	}
+
+	for i, prev := range f.prevs {
+		if prev == nil {
+			// Levels above have the head before key too.
+			break
+		}
+		if !list.before(prev.key, key, orEqual) {
+			continue
+		}
+		if next := prev.NextAt(i); next == nil || !list.before(next.key, key, orEqual) {
+			return prev, i
+		}
+	}
+	return nil, list.maxLevel - 1
+}
+
+// before reports whether a search for the elements before key, or up to key when orEqual is
+// set, passes over an element with key a.
+func (list *SkipList) before(a, key []byte, orEqual bool) bool {
+	c := list.compare(a, key)
+	return c < 0 || (c == 0 && orEqual)
+}
@@ -0,0 +1,75 @@
+package skiplist
+
+import (
+	"errors"
+)
+
+// FlushHandle marks a consistent cut of a list taken by BeginFlush.
+type FlushHandle struct {
+	list *SkipList
+	seq  uint64
+}
+
+// Seq returns the sequence number of the cut. Elements whose Seq is less than or equal
+// to it are covered by the flush.
+func (h FlushHandle) Seq() uint64 {
+	return h.seq
+}
+
+// BeginFlush marks a consistent cut of the list for flushing. The caller then persists the
+// elements covered by the cut, those whose Seq is at most the handle's, and calls CompleteFlush.
+func (list *SkipList) BeginFlush() FlushHandle {
+	return FlushHandle{list: list, seq: list.Seq()}
+}
+
+// CompleteFlush removes every element covered by the cut of handle, returning how many were
+// removed. Elements inserted or updated after BeginFlush are newer than the cut and are kept,
+// so writes that raced with the flush are not lost. Removed elements are reported to the
+// remove callback with the Flushed reason.
+func (list *SkipList) CompleteFlush(handle FlushHandle) (int, error) {
+	if handle.list != list {
+		return 0, errors.New("flush handle belongs to another list")
+	}
+
+	removed, err := list.completeFlush(handle.seq)
+	if err != nil {
+		return 0, list.newError("CompleteFlush", nil, err)
+	}
+
+	for _, element := range removed {
+		list.notifyRemove(element, Flushed)
+	}
+	return len(removed), nil
+}
+
+func (list *SkipList) completeFlush(seq uint64) ([]*Element, error) {
+	list.lock(lockFlush)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, ErrReadOnly
+	}
+
+	// Walk the bottom level once, keeping prevs pointed at the last retained node on each
+	// level so that covered elements can be unlinked as they are found.
+	prevs := list.prevNodesCache
+	for i := range prevs {
+		prevs[i] = &list.elementNode
+	}
+
+	var removed []*Element
+	for element := list.Front(); element != nil; {
+		next := element.Next()
+		if element.Seq() <= seq && (list.frozenRanges == nil || !list.rangeFrozen(element.key)) {
+			list.unlink(prevs, element)
+			removed = append(removed, element)
+		} else {
+			for i := range element.next {
+				prevs[i] = &element.elementNode
+			}
+		}
+		element = next
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,49 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestTwoPhaseFlush(t *testing.T) {
+	var flushed int
+	list := New(WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		if reason == Flushed {
+			flushed++
+		}
+	}))
+
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	handle := list.BeginFlush()
+
+	// Concurrent writes after the cut: an update of a covered key and new keys.
+	list.Set(orderedKey(10), uint64(1000))
+	list.Set(orderedKey(200), uint64(200))
+	list.Set(orderedKey(50), uint64(5000))
+
+	n, err := list.CompleteFlush(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(list, t)
+
+	if n != 98 || flushed != 98 {
+		t.Fatal("wrong number of flushed elements", n, flushed)
+	}
+
+	if list.Length != 3 {
+		t.Fatal("elements written after the cut must be kept", list.Length)
+	}
+
+	for _, k := range []uint64{10, 50, 200} {
+		if e := list.Get(orderedKey(k)); e == nil || e.Seq() <= handle.Seq() {
+			t.Fatal("missing newer element", k)
+		}
+	}
+
+	if _, err := New().CompleteFlush(handle); err == nil {
+		t.Fatal("a handle must only complete the flush of its own list")
+	}
+}
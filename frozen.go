@@ -0,0 +1,57 @@
+package skiplist
+
+// FrozenPolicy decides what happens to writes to a frozen list.
+type FrozenPolicy int
+
+const (
+	// FrozenReject rejects writes. SetE and RemoveE return ErrReadOnly,
+	// while Set and Remove return nil.
+	FrozenReject FrozenPolicy = iota
+	// FrozenPanic panics with an *Error wrapping ErrReadOnly, for engines where writing to a
+	// frozen list is a programming error.
+	FrozenPanic
+	// FrozenForward transparently applies writes to the overflow list set WithOverflowList.
+	// Writes of keys already in the frozen list then create or update an element of the
+	// overflow list, which SetE, MergeE, SetWithTTLE and UpdateE report by returning it along
+	// with an *Error wrapping ErrElementReplaced.
+	FrozenForward
+)
+
+// Freeze makes the list read-only. Writes that have not acquired the list's lock by the time
+// Freeze returns are handled according to the list's FrozenPolicy. Freezing cannot be undone.
+func (list *SkipList) Freeze() {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	list.frozen = true
+}
+
+// Frozen reports whether the list has been frozen.
+func (list *SkipList) Frozen() bool {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.frozen
+}
+
+// frozenWrite handles a write rejected because the list is frozen, according to the list's
+// policy. forward applies the write to the overflow list.
+func (list *SkipList) frozenWrite(op string, key []byte, forward func(overflow *SkipList) (*Element, error)) (*Element, error) {
+	err := list.newError(op, key, ErrReadOnly)
+	switch list.frozenPolicy {
+	case FrozenPanic:
+		panic(err)
+	case FrozenForward:
+		return forward(list.overflow)
+	}
+	return nil, err
+}
+
+// replaced returns the result of a write of key forwarded to the overflow list, with an *Error
+// wrapping ErrElementReplaced if key is also in this list, whose element the write did not reach.
+func (list *SkipList) replaced(op string, key []byte, element *Element, err error) (*Element, error) {
+	if err == nil && element != nil && list.Get(key) != nil {
+		return element, list.newError(op, key, ErrElementReplaced)
+	}
+	return element, err
+}
@@ -0,0 +1,85 @@
+package skiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFrozenReject(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+	list.Freeze()
+
+	if !list.Frozen() {
+		t.Fatal("list must be frozen")
+	}
+
+	if _, err := list.SetE([]byte("b"), 2); !errors.Is(err, ErrReadOnly) {
+		t.Fatal("expected ErrReadOnly, got", err)
+	}
+
+	if list.Set([]byte("a"), 2) != nil || list.Remove([]byte("a")) != nil {
+		t.Fatal("writes to a frozen list must be rejected")
+	}
+
+	if _, err := list.RemoveE([]byte("a")); !errors.Is(err, ErrReadOnly) {
+		t.Fatal("expected ErrReadOnly, got", err)
+	}
+
+	if e := list.Get([]byte("a")); e == nil || e.Value().(int) != 1 || list.Length != 1 {
+		t.Fatal("a frozen list must keep its contents")
+	}
+}
+
+func TestFrozenPanic(t *testing.T) {
+	list := New(WithFrozenPolicy(FrozenPanic))
+	list.Freeze()
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, ErrReadOnly) {
+			t.Fatal("expected a panic with ErrReadOnly, got", err)
+		}
+		// The panic must not leave the list locked.
+		list.Get([]byte("a"))
+	}()
+	list.Set([]byte("a"), 1)
+}
+
+func TestFrozenForward(t *testing.T) {
+	overflow := New()
+	list := New(WithOverflowList(overflow))
+	list.Set([]byte("a"), 1)
+	list.Freeze()
+
+	if e := list.Set([]byte("b"), 2); e == nil || overflow.Get([]byte("b")) != e {
+		t.Fatal("writes must be forwarded to the overflow list")
+	}
+
+	if list.Get([]byte("b")) != nil {
+		t.Fatal("forwarded writes must not reach the frozen list")
+	}
+
+	if list.Remove([]byte("b")) == nil || overflow.Length != 0 {
+		t.Fatal("removals must be forwarded to the overflow list")
+	}
+}
+
+func TestFrozenForwardReplaced(t *testing.T) {
+	overflow := New()
+	list := New(WithOverflowList(overflow))
+	a := list.Set([]byte("a"), 1)
+	list.Freeze()
+
+	e, err := list.SetE([]byte("a"), 2)
+	if !errors.Is(err, ErrElementReplaced) || e == nil || e == a || overflow.Get([]byte("a")) != e {
+		t.Fatal("forwarded write of a frozen key must report the replaced element", e, err)
+	}
+	if a.Value() != 1 {
+		t.Fatal("forwarded write reached the frozen element")
+	}
+
+	if _, err := list.SetE([]byte("b"), 2); err != nil {
+		t.Fatal("forwarded write of a new key is not a replacement", err)
+	}
+}
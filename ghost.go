@@ -0,0 +1,89 @@
+package skiplist
+
+import (
+	"time"
+)
+
+// Ghost describes an element that was evicted to keep the list within its maximum weight.
+// Ghosts remember evictions after the elements are gone, which is what admission policies
+// in the style of ARC or 2Q build on: a key missed shortly after its eviction was evicted too
+// early, and deserves to be admitted ahead of keys never seen before.
+type Ghost struct {
+	Key []byte
+	// Weight is the weight the element had when it was evicted.
+	Weight int64
+	// Seq is the sequence number of the eviction.
+	Seq uint64
+	// Evicted is when the element was evicted.
+	Evicted time.Time
+}
+
+// ghostList remembers the most recent evictions, up to a fixed number, forgetting the oldest first.
+type ghostList struct {
+	// ring holds the ghosts in eviction order, next being the position of the oldest once full.
+	ring []Ghost
+	next int
+	// byKey indexes the remembered ghosts. A ghost in the ring whose key maps to a different
+	// eviction, or to none, was forgotten early because its key was inserted again.
+	byKey map[string]Ghost
+}
+
+func newGhostList(capacity int) *ghostList {
+	return &ghostList{
+		ring:  make([]Ghost, 0, capacity),
+		byKey: make(map[string]Ghost, capacity),
+	}
+}
+
+func (g *ghostList) add(ghost Ghost) {
+	if len(g.ring) < cap(g.ring) {
+		g.ring = append(g.ring, ghost)
+	} else {
+		if oldest := g.ring[g.next]; g.byKey[string(oldest.Key)].Seq == oldest.Seq {
+			delete(g.byKey, string(oldest.Key))
+		}
+		g.ring[g.next] = ghost
+		g.next = (g.next + 1) % len(g.ring)
+	}
+	g.byKey[string(ghost.Key)] = ghost
+}
+
+// forget drops the ghost of key, if any.
+func (g *ghostList) forget(key []byte) {
+	delete(g.byKey, string(key))
+}
+
+// Ghost returns the most recent eviction of key, if the list still remembers it. Keys are
+// forgotten once they are inserted again, or once enough other elements were evicted since.
+// It always reports false unless the list was constructed WithGhostList.
+func (list *SkipList) Ghost(key []byte) (Ghost, bool) {
+	if list.ghosts == nil {
+		return Ghost{}, false
+	}
+
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	ghost, ok := list.ghosts.byKey[string(key)]
+	return ghost, ok
+}
+
+// Ghosts returns the evictions the list remembers, oldest first.
+func (list *SkipList) Ghosts() []Ghost {
+	if list.ghosts == nil {
+		return nil
+	}
+
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	g := list.ghosts
+	ghosts := make([]Ghost, 0, len(g.byKey))
+	for i := range g.ring {
+		ghost := g.ring[(g.next+i)%len(g.ring)]
+		if g.byKey[string(ghost.Key)].Seq == ghost.Seq {
+			ghosts = append(ghosts, ghost)
+		}
+	}
+	return ghosts
+}
@@ -0,0 +1,97 @@
+package skiplist
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	sketchDepth = 4
+	sketchWidth = 2048
+)
+
+// HotKey is a frequently accessed key along with its estimated access count.
+type HotKey struct {
+	Key   []byte
+	Count uint64
+}
+
+// hotKeyTracker estimates access frequencies with a count-min sketch and retains
+// the k keys with the highest estimates seen so far. When sampling, each access is counted
+// with probability 1/rate, and counted rate times. Sampling randomly rather than every rate-th
+// access keeps periodic access patterns from aliasing with the sampling.
+type hotKeyTracker struct {
+	rate uint64
+
+	mutex  sync.Mutex
+	k      int
+	sketch [sketchDepth][sketchWidth]uint64
+	top    map[string]uint64
+}
+
+func newHotKeyTracker(k int, rate uint64) *hotKeyTracker {
+	if rate < 1 {
+		rate = 1
+	}
+	return &hotKeyTracker{k: k, rate: rate, top: make(map[string]uint64, k+1)}
+}
+
+// record counts one access to key.
+func (t *hotKeyTracker) record(key []byte) {
+	if t.rate > 1 && rand.Int63n(int64(t.rate)) != 0 {
+		return
+	}
+
+	h := fnv.New64a()
+	h.Write(key)
+	sum := h.Sum64()
+	// Derive the row hashes from two halves of one hash (Kirsch-Mitzenmacher).
+	h1, h2 := sum&0xffffffff, sum>>32
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	estimate := ^uint64(0)
+	for i := range t.sketch {
+		slot := &t.sketch[i][(h1+uint64(i)*h2)%sketchWidth]
+		*slot += t.rate
+		if *slot < estimate {
+			estimate = *slot
+		}
+	}
+
+	if _, ok := t.top[string(key)]; ok || len(t.top) < t.k {
+		t.top[string(key)] = estimate
+		return
+	}
+
+	minKey, minCount := "", ^uint64(0)
+	for k, c := range t.top {
+		if c < minCount {
+			minKey, minCount = k, c
+		}
+	}
+
+	if estimate > minCount {
+		delete(t.top, minKey)
+		t.top[string(key)] = estimate
+	}
+}
+
+// hotKeys returns the tracked keys ordered by descending estimated count.
+func (t *hotKeyTracker) hotKeys() []HotKey {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	keys := make([]HotKey, 0, len(t.top))
+	for k, c := range t.top {
+		keys = append(keys, HotKey{Key: []byte(k), Count: c})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Count > keys[j].Count
+	})
+	return keys
+}
@@ -0,0 +1,345 @@
+// Package bench runs configurable read, write and scan mixes against the skip list and against
+// baseline ordered and unordered stores, producing a report that can be compared across
+// configurations such as probability, max level or sharding.
+package bench
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+// Store is the interface a benchmarked data structure must implement.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Set inserts or updates key.
+	Set(key []byte, value interface{})
+	// Get reports whether key is present.
+	Get(key []byte) bool
+	// Scan visits up to n keys in order, starting at the first key >= start,
+	// and returns the number of keys visited.
+	Scan(start []byte, n int) int
+}
+
+// Target is a named data structure under test. New is called once per run so that
+// every run starts from an empty store.
+type Target struct {
+	Name string
+	New  func() Store
+}
+
+// SkipList returns a target for a skip list constructed with opts.
+func SkipList(name string, opts ...skiplist.Option) Target {
+	return Target{
+		Name: name,
+		New: func() Store {
+			return skipListStore{skiplist.New(opts...)}
+		},
+	}
+}
+
+// Sharded returns a target for a skip list of n shards, partitioned by hash, with every
+// shard constructed with opts.
+func Sharded(name string, n int, opts ...skiplist.Option) Target {
+	return Target{
+		Name: name,
+		New: func() Store {
+			return shardedStore{skiplist.NewSharded(n, opts...)}
+		},
+	}
+}
+
+// SortedSlice returns a target for a sorted slice guarded by a read-write mutex,
+// the simplest ordered baseline.
+func SortedSlice() Target {
+	return Target{
+		Name: "sorted-slice",
+		New: func() Store {
+			return &sortedSliceStore{}
+		},
+	}
+}
+
+// SyncMap returns a target for a sync.Map. Maps are unordered, so its scans have to
+// collect and sort every key, which is what an ordered structure saves.
+func SyncMap() Target {
+	return Target{
+		Name: "sync.Map",
+		New: func() Store {
+			return &syncMapStore{}
+		},
+	}
+}
+
+// Workload describes a mix of operations. Reads, Writes and Scans are relative weights,
+// so {Reads: 9, Writes: 1} is a 90% read workload.
+type Workload struct {
+	// Keys is the size of the keyspace. The store is loaded with every key before the run.
+	Keys int
+	// Ops is the number of operations in the run, shared between the goroutines.
+	Ops int
+	// Goroutines is the number of goroutines issuing operations concurrently. Defaults to 1.
+	Goroutines int
+
+	Reads, Writes, Scans int
+	// ScanLength is the number of keys each scan visits. Defaults to 100.
+	ScanLength int
+	// Seed seeds the choice of operations and keys, so that runs are repeatable.
+	Seed int64
+}
+
+// Result holds the measurements of one target under one workload.
+type Result struct {
+	Target   string
+	Ops      int
+	Duration time.Duration
+	Reads    int
+	Writes   int
+	Scans    int
+}
+
+// NsPerOp returns the average wall clock time per operation.
+func (r Result) NsPerOp() float64 {
+	if r.Ops == 0 {
+		return 0
+	}
+	return float64(r.Duration.Nanoseconds()) / float64(r.Ops)
+}
+
+// Report is the results of a set of targets under the same workload.
+type Report struct {
+	Workload Workload
+	Results  []Result
+}
+
+// Run loads each target with the workload's keyspace, then times the workload's operations
+// against it. Targets see the same sequence of operations.
+func Run(w Workload, targets ...Target) Report {
+	if w.Goroutines < 1 {
+		w.Goroutines = 1
+	}
+	if w.ScanLength < 1 {
+		w.ScanLength = 100
+	}
+	if w.Reads+w.Writes+w.Scans == 0 {
+		w.Reads = 1
+	}
+
+	report := Report{Workload: w}
+	for _, target := range targets {
+		report.Results = append(report.Results, run(w, target))
+	}
+	return report
+}
+
+func run(w Workload, target Target) Result {
+	store := target.New()
+	for i := 0; i < w.Keys; i++ {
+		store.Set(key(i), i)
+	}
+
+	result := Result{Target: target.Name, Ops: w.Ops}
+	counts := make([][3]int, w.Goroutines)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for g := 0; g < w.Goroutines; g++ {
+		ops := w.Ops / w.Goroutines
+		if g < w.Ops%w.Goroutines {
+			ops++
+		}
+
+		wg.Add(1)
+		go func(g, ops int) {
+			defer wg.Done()
+			counts[g] = runOps(w, store, rand.New(rand.NewSource(w.Seed+int64(g))), ops)
+		}(g, ops)
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	for _, c := range counts {
+		result.Reads += c[0]
+		result.Writes += c[1]
+		result.Scans += c[2]
+	}
+	return result
+}
+
+// runOps issues ops operations and returns the number of reads, writes and scans among them.
+func runOps(w Workload, store Store, rng *rand.Rand, ops int) (counts [3]int) {
+	total := w.Reads + w.Writes + w.Scans
+	keys := w.Keys
+	if keys < 1 {
+		keys = 1
+	}
+
+	for i := 0; i < ops; i++ {
+		k := key(rng.Intn(keys))
+		switch op := rng.Intn(total); {
+		case op < w.Reads:
+			store.Get(k)
+			counts[0]++
+		case op < w.Reads+w.Writes:
+			store.Set(k, i)
+			counts[1]++
+		default:
+			store.Scan(k, w.ScanLength)
+			counts[2]++
+		}
+	}
+	return counts
+}
+
+// WriteTo writes the report as an aligned table, one target per row.
+func (r Report) WriteTo(out io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	w := r.Workload
+	fmt.Fprintf(&buf, "keys=%d ops=%d goroutines=%d mix=%d/%d/%d (read/write/scan) scan=%d\n",
+		w.Keys, w.Ops, w.Goroutines, w.Reads, w.Writes, w.Scans, w.ScanLength)
+
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "target\tns/op\tduration\treads\twrites\tscans\t")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%.1f\t%s\t%d\t%d\t%d\t\n",
+			res.Target, res.NsPerOp(), res.Duration.Round(time.Microsecond), res.Reads, res.Writes, res.Scans)
+	}
+	tw.Flush()
+
+	n, err := out.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// key returns the i-th key of the keyspace. Big endian keys sort in the same order as i.
+func key(i int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(i))
+	return buf[:]
+}
+
+type skipListStore struct {
+	list *skiplist.SkipList
+}
+
+func (s skipListStore) Set(key []byte, value interface{}) {
+	s.list.Set(key, value)
+}
+
+func (s skipListStore) Get(key []byte) bool {
+	return s.list.Get(key) != nil
+}
+
+func (s skipListStore) Scan(start []byte, n int) int {
+	visited := 0
+	for e := s.list.Seek(start); e != nil && visited < n; e = e.Next() {
+		visited++
+	}
+	return visited
+}
+
+type shardedStore struct {
+	list *skiplist.ShardedSkipList
+}
+
+func (s shardedStore) Set(key []byte, value interface{}) {
+	s.list.Set(key, value)
+}
+
+func (s shardedStore) Get(key []byte) bool {
+	return s.list.Get(key) != nil
+}
+
+func (s shardedStore) Scan(start []byte, n int) int {
+	visited := 0
+	it := s.list.NewIterator()
+	for it.Seek(start); it.Valid() && visited < n; it.Next() {
+		visited++
+	}
+	return visited
+}
+
+type sortedSliceStore struct {
+	mutex  sync.RWMutex
+	keys   [][]byte
+	values []interface{}
+}
+
+func (s *sortedSliceStore) search(key []byte) int {
+	return sort.Search(len(s.keys), func(i int) bool {
+		return bytes.Compare(s.keys[i], key) >= 0
+	})
+}
+
+func (s *sortedSliceStore) Set(key []byte, value interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.search(key)
+	if i < len(s.keys) && bytes.Equal(s.keys[i], key) {
+		s.values[i] = value
+		return
+	}
+
+	s.keys = append(s.keys, nil)
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = key
+	s.values = append(s.values, nil)
+	copy(s.values[i+1:], s.values[i:])
+	s.values[i] = value
+}
+
+func (s *sortedSliceStore) Get(key []byte) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	i := s.search(key)
+	return i < len(s.keys) && bytes.Equal(s.keys[i], key)
+}
+
+func (s *sortedSliceStore) Scan(start []byte, n int) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	i := s.search(start)
+	if rest := len(s.keys) - i; rest < n {
+		return rest
+	}
+	return n
+}
+
+type syncMapStore struct {
+	m sync.Map
+}
+
+func (s *syncMapStore) Set(key []byte, value interface{}) {
+	s.m.Store(string(key), value)
+}
+
+func (s *syncMapStore) Get(key []byte) bool {
+	_, ok := s.m.Load(string(key))
+	return ok
+}
+
+func (s *syncMapStore) Scan(start []byte, n int) int {
+	var keys []string
+	s.m.Range(func(k, _ interface{}) bool {
+		if k.(string) >= string(start) {
+			keys = append(keys, k.(string))
+		}
+		return true
+	})
+	sort.Strings(keys)
+
+	if len(keys) < n {
+		return len(keys)
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+func TestStoresAgree(t *testing.T) {
+	targets := []Target{SkipList("skiplist"), Sharded("sharded", 4), SortedSlice(), SyncMap()}
+	for _, target := range targets {
+		store := target.New()
+		for i := 9; i >= 0; i-- {
+			store.Set(key(i*2), i)
+		}
+
+		if !store.Get(key(4)) || store.Get(key(5)) {
+			t.Fatal(target.Name, "wrong Get result")
+		}
+
+		if n := store.Scan(key(5), 3); n != 3 {
+			t.Fatal(target.Name, "wrong scan length", n)
+		}
+
+		if n := store.Scan(key(15), 100); n != 2 {
+			t.Fatal(target.Name, "scan must stop at the last key", n)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	w := Workload{Keys: 1000, Ops: 2000, Goroutines: 4, Reads: 8, Writes: 1, Scans: 1, ScanLength: 10, Seed: 1}
+	report := Run(w,
+		SkipList("skiplist"),
+		SkipList("skiplist p=1/2", skiplist.WithProbability(0.5)),
+		SortedSlice(),
+		SyncMap(),
+	)
+
+	if len(report.Results) != 4 {
+		t.Fatal("expected one result per target", report.Results)
+	}
+
+	for _, res := range report.Results {
+		if res.Reads+res.Writes+res.Scans != w.Ops || res.Duration <= 0 {
+			t.Fatal("wrong result", res)
+		}
+		if res != report.Results[0] && (res.Reads != report.Results[0].Reads || res.Scans != report.Results[0].Scans) {
+			t.Fatal("targets must see the same operations", res, report.Results[0])
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "sorted-slice") || !strings.Contains(buf.String(), "ns/op") {
+		t.Fatal("report must list every target", buf.String())
+	}
+}
@@ -0,0 +1,89 @@
+package tower
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	randv2 "math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// Xorshift is a xorshift64* generator, which is fast and small enough to draw tower heights
+// under a list's lock. It is not safe for concurrent use.
+type Xorshift struct {
+	state uint64
+}
+
+// NewXorshift returns a xorshift64* generator seeded with seed.
+func NewXorshift(seed uint64) *Xorshift {
+	x := &Xorshift{}
+	x.Seed(int64(seed))
+	return x
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (x *Xorshift) Int63() int64 {
+	x.state ^= x.state >> 12
+	x.state ^= x.state << 25
+	x.state ^= x.state >> 27
+	return int64((x.state * 2685821657736338717) >> 1)
+}
+
+// Seed resets the generator to the state derived from seed. The state of a xorshift generator
+// must not be zero, so seed is mixed before use.
+func (x *Xorshift) Seed(seed int64) {
+	x.state = mix(uint64(seed))
+	if x.state == 0 {
+		x.state = 1
+	}
+}
+
+// PCG is a rand.Source backed by the PCG generator of math/rand/v2, whose sequence is
+// determined by its seed. It is not safe for concurrent use.
+type PCG struct {
+	pcg randv2.PCG
+}
+
+// NewPCG returns a PCG generator seeded with seed1 and seed2.
+func NewPCG(seed1, seed2 uint64) *PCG {
+	p := &PCG{}
+	p.pcg.Seed(seed1, seed2)
+	return p
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (p *PCG) Int63() int64 {
+	return int64(p.pcg.Uint64() >> 1)
+}
+
+// Seed resets the generator to the state derived from seed.
+func (p *PCG) Seed(seed int64) {
+	p.pcg.Seed(uint64(seed), 0)
+}
+
+var seeds atomic.Uint64
+
+// UniqueSeed returns a seed that differs between calls, even between calls in the same
+// nanosecond, by mixing the time with a process-wide counter.
+func UniqueSeed() uint64 {
+	return mix(uint64(time.Now().UnixNano()) ^ mix(seeds.Add(1)))
+}
+
+// CryptoSeed returns a seed read from crypto/rand, which cannot be predicted from the seeds of
+// other lists or from the time a list was created.
+func CryptoSeed() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return UniqueSeed()
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// mix is the finalizer of splitmix64, which spreads the bits of nearby values such as
+// consecutive counters or timestamps across the whole word.
+func mix(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,45 @@
+// Package tower calculates the heights of new skip list towers. It is shared by the
+// skip list implementations of this module.
+package tower
+
+import (
+	"math"
+	"math/rand"
+)
+
+// ProbabilityTable calculates in advance the probability of a new node having a given level.
+// probability is in [0, 1], maxLevel is (0, 64]
+// Returns a table of floating point probabilities that each level should be included during an insert.
+func ProbabilityTable(probability float64, maxLevel int) (table []float64) {
+	for i := 1; i <= maxLevel; i++ {
+		prob := math.Pow(probability, float64(i-1))
+		table = append(table, prob)
+	}
+	return table
+}
+
+// Level returns the height of a new tower, between 1 and len(table), drawing a single
+// number from src and looking it up in a table computed by ProbabilityTable.
+func Level(src rand.Source, table []float64) (level int) {
+	// Our random number source only has Int63(), so we have to produce a float64 from it
+	// Reference: https://golang.org/src/math/rand/rand.go#L150
+	r := float64(src.Int63()) / (1 << 63)
+
+	level = 1
+	for level < len(table) && r < table[level] {
+		level++
+	}
+	return
+}
+
+// SharedSource is a rand.Source backed by math/rand's top-level functions, which are safe
+// for concurrent use. It lets goroutines draw tower heights without sharing a lock.
+type SharedSource struct{}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (SharedSource) Int63() int64 {
+	return rand.Int63()
+}
+
+// Seed is a no-op; the top-level source is seeded randomly at startup.
+func (SharedSource) Seed(int64) {}
@@ -0,0 +1,209 @@
+package skiplist
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/m3db/fast-skiplist/internal/tower"
+)
+
+// Hook links a caller-owned struct into an IntrusiveList. Embedding a Hook in a struct makes
+// the struct itself the element of the list, so inserting needs no separate Element and the
+// struct's fields need no interface boxing:
+//
+//	type Order struct {
+//		skiplist.Hook
+//		Price, Quantity int64
+//	}
+//
+//	orders := skiplist.NewIntrusive[Order]()
+//	orders.Insert(key, &Order{Price: 100, Quantity: 5})
+//
+// A Hook may be linked into at most one list at a time, and must not be copied while linked.
+// A removed item may be inserted again, but only once no reader can still be standing on it
+// from before its removal, since reinserting rebuilds its links.
+type Hook struct {
+	next []unsafe.Pointer
+	key  []byte
+	// owner points to the struct embedding the hook, which may not start with it.
+	owner  unsafe.Pointer
+	linked bool
+}
+
+// Key returns the key the hook was inserted with.
+func (h *Hook) Key() []byte {
+	return h.key
+}
+
+func (h *Hook) nextAt(i int) *Hook {
+	return (*Hook)(atomic.LoadPointer(&h.next[i]))
+}
+
+func (h *Hook) skipHook() *Hook {
+	return h
+}
+
+// Hooked is satisfied by pointers to structs embedding a Hook.
+type Hooked[T any] interface {
+	*T
+	skipHook() *Hook
+}
+
+// IntrusiveList is a skip list of caller-owned structs of type T, each embedding a Hook.
+// It has the same concurrency guarantees as SkipList: writes are serialized by a mutex,
+// and reads and iteration are lock-free.
+type IntrusiveList[T any, P Hooked[T]] struct {
+	head       Hook
+	name       string
+	compare    func(a, b []byte) int
+	maxLevel   int
+	length     int
+	randSource rand.Source
+	probTable  []float64
+	mutex      sync.RWMutex
+	prevs      []*Hook
+}
+
+// NewIntrusive creates a new intrusive list of T. Of the options, WithMaxLevel,
+// WithProbability, WithComparator, WithName, WithSeededRand and WithCryptoSeededRand apply.
+func NewIntrusive[T any, P Hooked[T]](opts ...Option) *IntrusiveList[T, P] {
+	config := configure(opts...)
+	return &IntrusiveList[T, P]{
+		head:       Hook{next: make([]unsafe.Pointer, config.maxLevel)},
+		name:       config.name,
+		compare:    config.compare,
+		maxLevel:   config.maxLevel,
+		randSource: config.randSource,
+		probTable:  config.probTable,
+		prevs:      make([]*Hook, config.maxLevel),
+	}
+}
+
+// Len returns the number of items in the list.
+func (list *IntrusiveList[T, P]) Len() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.length
+}
+
+// Insert links item into the list under key. It returns false, leaving the list unchanged,
+// if the key is already present or item is already linked into a list.
+func (list *IntrusiveList[T, P]) Insert(key []byte, item P) bool {
+	hook := item.skipHook()
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if hook.linked {
+		return false
+	}
+
+	prevs := list.getPrevHooks(key)
+	if next := prevs[0].nextAt(0); next != nil && list.compare(next.key, key) == 0 {
+		return false
+	}
+
+	hook.key = key
+	hook.linked = true
+	hook.owner = unsafe.Pointer(item)
+	hook.next = make([]unsafe.Pointer, tower.Level(list.randSource, list.probTable))
+	for i := range hook.next {
+		atomic.StorePointer(&hook.next[i], prevs[i].next[i])
+		atomic.StorePointer(&prevs[i].next[i], unsafe.Pointer(hook))
+	}
+
+	list.length++
+	return true
+}
+
+// Remove unlinks the item with the given key and returns it, or returns nil if there is none.
+// The item may be inserted again once removed.
+func (list *IntrusiveList[T, P]) Remove(key []byte) P {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	prevs := list.getPrevHooks(key)
+	hook := prevs[0].nextAt(0)
+	if hook == nil || list.compare(hook.key, key) != 0 {
+		return nil
+	}
+
+	for i := range hook.next {
+		atomic.StorePointer(&prevs[i].next[i], atomic.LoadPointer(&hook.next[i]))
+	}
+
+	// The links are left in place, so that concurrent readers standing on the item keep
+	// walking forward through the list.
+	hook.linked = false
+	list.length--
+	return P(hook.owner)
+}
+
+// Get returns the item with the given key, or nil if there is none.
+func (list *IntrusiveList[T, P]) Get(key []byte) P {
+	if hook := list.seek(key); hook != nil && list.compare(hook.key, key) == 0 {
+		return P(hook.owner)
+	}
+	return nil
+}
+
+// Seek returns the first item whose key is greater than or equal to key,
+// or nil if every item sorts before key.
+func (list *IntrusiveList[T, P]) Seek(key []byte) P {
+	if hook := list.seek(key); hook != nil {
+		return P(hook.owner)
+	}
+	return nil
+}
+
+// Front returns the first item of the list, or nil if it is empty.
+func (list *IntrusiveList[T, P]) Front() P {
+	if hook := list.head.nextAt(0); hook != nil {
+		return P(hook.owner)
+	}
+	return nil
+}
+
+// Next returns the item following item, or nil at the end of the list.
+// item must have been returned by the list.
+func (list *IntrusiveList[T, P]) Next(item P) P {
+	if next := item.skipHook().nextAt(0); next != nil {
+		return P(next.owner)
+	}
+	return nil
+}
+
+// Name returns the name the list was constructed with, if any.
+func (list *IntrusiveList[T, P]) Name() string {
+	return list.name
+}
+
+func (list *IntrusiveList[T, P]) seek(key []byte) *Hook {
+	prev := &list.head
+	var next *Hook
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next = prev.nextAt(i)
+		for next != nil && list.compare(key, next.key) > 0 {
+			prev = next
+			next = next.nextAt(i)
+		}
+	}
+	return next
+}
+
+// getPrevHooks finds the last hook before key on each level. The caller must hold the mutex.
+func (list *IntrusiveList[T, P]) getPrevHooks(key []byte) []*Hook {
+	prev := &list.head
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next := prev.nextAt(i)
+		for next != nil && list.compare(key, next.key) > 0 {
+			prev = next
+			next = next.nextAt(i)
+		}
+		list.prevs[i] = prev
+	}
+	return list.prevs
+}
@@ -0,0 +1,102 @@
+package skiplist
+
+import (
+	"sync"
+	"testing"
+)
+
+type intrusiveOrder struct {
+	id int
+	Hook
+	price uint64
+}
+
+func TestIntrusiveList(t *testing.T) {
+	list := NewIntrusive[intrusiveOrder](WithName("orders"))
+
+	orders := make([]intrusiveOrder, 100)
+	for i := range orders {
+		orders[i] = intrusiveOrder{id: i, price: uint64(99 - i)}
+		if !list.Insert(orderedKey(orders[i].price), &orders[i]) {
+			t.Fatal("insert must succeed", i)
+		}
+	}
+
+	if list.Insert(orderedKey(5), &intrusiveOrder{}) {
+		t.Fatal("inserting an existing key must fail")
+	}
+	if list.Insert(orderedKey(500), &orders[0]) {
+		t.Fatal("inserting a linked item must fail")
+	}
+
+	if list.Len() != 100 || list.Name() != "orders" {
+		t.Fatal("wrong list", list.Len(), list.Name())
+	}
+
+	// The hook is not the first field, so items must be recovered from their owner pointer.
+	if o := list.Get(orderedKey(10)); o != &orders[89] || o.id != 89 {
+		t.Fatal("Get must return the inserted item", o)
+	}
+	if o := list.Seek(orderedKey(1000)); o != nil {
+		t.Fatal("Seek past the end must return nil", o)
+	}
+
+	var prev uint64
+	n := 0
+	for o := list.Front(); o != nil; o = list.Next(o) {
+		if n > 0 && o.price <= prev {
+			t.Fatal("items out of order", prev, o.price)
+		}
+		prev = o.price
+		n++
+	}
+	if n != 100 {
+		t.Fatal("iteration must visit every item", n)
+	}
+
+	if o := list.Remove(orderedKey(10)); o != &orders[89] {
+		t.Fatal("Remove must return the item", o)
+	}
+	if list.Get(orderedKey(10)) != nil || list.Remove(orderedKey(10)) != nil {
+		t.Fatal("removed item must be gone")
+	}
+	if !list.Insert(orderedKey(1000), &orders[89]) || list.Get(orderedKey(1000)) != &orders[89] {
+		t.Fatal("removed items may be inserted again")
+	}
+}
+
+func TestIntrusiveListConcurrentReads(t *testing.T) {
+	list := NewIntrusive[intrusiveOrder]()
+	orders := make([]intrusiveOrder, 1000)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range orders {
+			list.Insert(orderedKey(uint64(i)), &orders[i])
+		}
+		for i := 0; i < len(orders); i += 2 {
+			list.Remove(orderedKey(uint64(i)))
+		}
+	}()
+
+	for n := 0; n < 10; n++ {
+		for o := list.Front(); o != nil; o = list.Next(o) {
+		}
+	}
+	wg.Wait()
+
+	if list.Len() != 500 {
+		t.Fatal("wrong length", list.Len())
+	}
+}
+
+func BenchmarkIntrusiveInsert(b *testing.B) {
+	b.ReportAllocs()
+	list := NewIntrusive[intrusiveOrder]()
+	orders := make([]intrusiveOrder, b.N)
+	for i := 0; i < b.N; i++ {
+		list.Insert(orderedKey(uint64(i)), &orders[i])
+	}
+}
@@ -0,0 +1,287 @@
+package skiplist
+
+import (
+	"math"
+	"sync"
+)
+
+var iteratorPool = sync.Pool{
+	New: func() interface{} {
+		return &Iterator{}
+	},
+}
+
+// Iterator walks the elements of a SkipList in key order, forwards or backwards.
+// An iterator starts out unpositioned; call one of the Seek methods before reading from it.
+type Iterator struct {
+	list    *SkipList
+	current *Element
+	// epoch is the epoch of list when the iterator was created, or after following a rotation.
+	epoch *epoch
+
+	// lower and upper optionally restrict the iterator to keys in [lower, upper).
+	lower, upper []byte
+	// done, if set, invalidates the iterator once it is closed.
+	done <-chan struct{}
+	// skipTombstones makes the iterator step over tombstones.
+	skipTombstones bool
+	// since makes the iterator step over elements last written at or before this sequence number.
+	since uint64
+}
+
+// NewIterator returns a new, unpositioned iterator over the list.
+func (list *SkipList) NewIterator() *Iterator {
+	return &Iterator{list: list, epoch: list.epoch.Load()}
+}
+
+// AcquireIterator returns an unpositioned iterator over the list, reusing a previously released
+// iterator when one is available. Call Release once done with it to return it to the pool.
+func (list *SkipList) AcquireIterator() *Iterator {
+	it := iteratorPool.Get().(*Iterator)
+	it.list = list
+	it.epoch = list.epoch.Load()
+	return it
+}
+
+// Release returns an iterator obtained from AcquireIterator to the pool.
+// The iterator must not be used afterwards.
+func (it *Iterator) Release() {
+	*it = Iterator{}
+	iteratorPool.Put(it)
+}
+
+// Range returns an iterator over the elements with start <= key < end, positioned at the first
+// of them. A nil start or end leaves that side of the range open. The iterator is bounded on both
+// sides, so Next, Prev and the Seek methods never move it outside of the range.
+//
+// Like all iteration, a range does not lock the list. Elements present for the whole iteration
+// are always visited, in order; elements inserted or removed during it may or may not be.
+func (list *SkipList) Range(start, end []byte) *Iterator {
+	it := list.newBoundedIterator(start, end)
+	it.SeekToFirst()
+	return it
+}
+
+// newBoundedIterator returns an unpositioned iterator restricted to keys in [lower, upper).
+// A nil bound leaves that side of the range open.
+func (list *SkipList) newBoundedIterator(lower, upper []byte) *Iterator {
+	return &Iterator{list: list, epoch: list.epoch.Load(), lower: lower, upper: upper}
+}
+
+// Valid reports whether the iterator is positioned at an element.
+func (it *Iterator) Valid() bool {
+	return it.current != nil
+}
+
+// Element returns the element at the current position, or nil if the iterator is not valid.
+func (it *Iterator) Element() *Element {
+	return it.current
+}
+
+// Key returns the key at the current position. The iterator must be valid.
+func (it *Iterator) Key() []byte {
+	return it.current.key
+}
+
+// Value returns the value at the current position. The iterator must be valid.
+func (it *Iterator) Value() interface{} {
+	return it.current.Value()
+}
+
+// SeekToFirst positions the iterator at the first element of the list.
+func (it *Iterator) SeekToFirst() {
+	for {
+		list := it.view()
+		element := list.Front()
+		if it.lower != nil {
+			element = list.searchGreaterOrEqual(it.lower)
+		}
+		if !it.rotated() {
+			it.set(it.live(element, true))
+			return
+		}
+	}
+}
+
+// SeekToLast positions the iterator at the last element of the list.
+func (it *Iterator) SeekToLast() {
+	for {
+		list := it.view()
+		element := list.lastElement()
+		if it.upper != nil {
+			element = list.searchLess(it.upper, false)
+		}
+		if !it.rotated() {
+			it.set(it.live(element, false))
+			return
+		}
+	}
+}
+
+// Seek positions the iterator at the first element whose key is greater than or equal to key.
+// The iterator is invalid if no such element exists.
+func (it *Iterator) Seek(key []byte) {
+	if it.lower != nil && it.list.compare(key, it.lower) < 0 {
+		key = it.lower
+	}
+	for {
+		element := it.view().searchGreaterOrEqual(key)
+		if !it.rotated() {
+			it.set(it.live(element, true))
+			return
+		}
+	}
+}
+
+// SeekLT positions the iterator at the last element whose key is strictly less than key.
+// The iterator is invalid if no such element exists.
+func (it *Iterator) SeekLT(key []byte) {
+	key = it.clampUpper(key)
+	for {
+		element := it.view().searchLess(key, false)
+		if !it.rotated() {
+			it.set(it.live(element, false))
+			return
+		}
+	}
+}
+
+// SeekForPrev positions the iterator at the last element whose key is less than or equal to key,
+// matching the semantics of RocksDB's Iterator::SeekForPrev.
+// The iterator is invalid if no such element exists.
+func (it *Iterator) SeekForPrev(key []byte) {
+	orEqual := true
+	if it.upper != nil && it.list.compare(key, it.upper) >= 0 {
+		key, orEqual = it.upper, false
+	}
+	for {
+		element := it.view().searchLess(key, orEqual)
+		if !it.rotated() {
+			it.set(it.live(element, false))
+			return
+		}
+	}
+}
+
+// Next advances the iterator to the following element. The iterator must be valid.
+func (it *Iterator) Next() {
+	it.set(it.live(it.current.Next(), true))
+}
+
+// Prev moves the iterator to the preceding element. The iterator must be valid.
+func (it *Iterator) Prev() {
+	it.set(it.live(it.current.Prev(), false))
+}
+
+// Peek returns the element following the current position without advancing the iterator.
+// Returns nil if the iterator is not valid or is at the last element.
+func (it *Iterator) Peek() *Element {
+	if it.current == nil {
+		return nil
+	}
+
+	next := it.live(it.current.Next(), true)
+	if !it.inBounds(next) {
+		return nil
+	}
+	return next
+}
+
+// Progress estimates how far the iterator has come through its range, from 0 at the first
+// element towards 1 at the last, for reporting the completion of long scans. Progress is
+// measured in key order, so a backward scan counts down. An invalid iterator reports 1.
+//
+// The estimate counts elements on the lowest level that holds at most about a thousand elements
+// of the range, which is a uniform sample of it. That keeps it cheap and accurate to within a few
+// percent however large the range, but too costly to call for every element.
+func (it *Iterator) Progress() float64 {
+	if it.current == nil {
+		return 1
+	}
+
+	list := it.view()
+	level := 0
+	for level < list.maxLevel-1 && list.countOnLevel(level, it.lower, it.upper, progressSampleSize) > progressSampleSize {
+		level++
+	}
+
+	total := list.countOnLevel(level, it.lower, it.upper, -1)
+	if total == 0 {
+		return 1
+	}
+	done := list.countOnLevel(level, it.lower, it.current.key, -1)
+	return math.Min(1, float64(done)/float64(total))
+}
+
+// view returns the list the iterator reads: its own list, or the frozen list its contents were
+// moved to if the list has been rotated since the iterator was created.
+func (it *Iterator) view() *SkipList {
+	for it.epoch != nil {
+		frozen := it.epoch.frozen.Load()
+		if frozen == nil {
+			break
+		}
+		it.list, it.epoch = frozen, it.epoch.frozenEpoch
+	}
+	return it.list
+}
+
+// rotated reports whether the list returned by view has been rotated since, in which case a
+// search of it may have observed part of the rotation and must be repeated on the frozen list.
+// Rotate publishes the frozen list before emptying the list, so a search that completes before
+// the frozen list is published only observed the pre-rotation contents.
+func (it *Iterator) rotated() bool {
+	return it.epoch != nil && it.epoch.frozen.Load() != nil
+}
+
+// set moves the iterator to element, invalidating it if element falls outside the
+// iterator's bounds or the iterator has been closed.
+func (it *Iterator) set(element *Element) {
+	if it.done != nil {
+		select {
+		case <-it.done:
+			element = nil
+		default:
+		}
+	}
+
+	if !it.inBounds(element) {
+		element = nil
+	}
+	it.current = element
+}
+
+// live returns the first element from element onwards, in the given direction, that has not
+// expired, is not a tombstone if the iterator skips tombstones, and was written after the
+// iterator's since.
+func (it *Iterator) live(element *Element, forward bool) *Element {
+	for it.inBounds(element) && (element.expired() || it.skipTombstones && element.IsTombstone() || element.Seq() <= it.since) {
+		if forward {
+			element = element.Next()
+		} else {
+			element = element.Prev()
+		}
+	}
+	return element
+}
+
+func (it *Iterator) inBounds(element *Element) bool {
+	if element == nil {
+		return false
+	}
+	if it.lower != nil && it.list.compare(element.key, it.lower) < 0 {
+		return false
+	}
+	if it.upper != nil && it.list.compare(element.key, it.upper) >= 0 {
+		return false
+	}
+	return true
+}
+
+// clampUpper returns key, or the iterator's upper bound if key sorts after it.
+func (it *Iterator) clampUpper(key []byte) []byte {
+	if it.upper != nil && it.list.compare(key, it.upper) > 0 {
+		return it.upper
+	}
+	return key
+}
@@ -0,0 +1,271 @@
+package skiplist
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestIteratorSeekLT(t *testing.T) {
+	list := New()
+	for _, k := range []string{"10", "20", "30"} {
+		list.Set([]byte(k), k)
+	}
+
+	it := list.NewIterator()
+	cases := []struct {
+		key      string
+		lt, prev string
+	}{
+		{"05", "", ""},
+		{"10", "", "10"},
+		{"15", "10", "10"},
+		{"20", "10", "20"},
+		{"30", "20", "30"},
+		{"99", "30", "30"},
+	}
+
+	for _, c := range cases {
+		it.SeekLT([]byte(c.key))
+		if c.lt == "" {
+			if it.Valid() {
+				t.Fatalf("SeekLT(%s): expected invalid iterator, got %s", c.key, it.Key())
+			}
+		} else if !it.Valid() || !bytes.Equal(it.Key(), []byte(c.lt)) {
+			t.Fatalf("SeekLT(%s): expected %s, got %v", c.key, c.lt, it.Element())
+		}
+
+		it.SeekForPrev([]byte(c.key))
+		if c.prev == "" {
+			if it.Valid() {
+				t.Fatalf("SeekForPrev(%s): expected invalid iterator, got %s", c.key, it.Key())
+			}
+		} else if !it.Valid() || !bytes.Equal(it.Key(), []byte(c.prev)) {
+			t.Fatalf("SeekForPrev(%s): expected %s, got %v", c.key, c.prev, it.Element())
+		}
+	}
+}
+
+func TestIteratorForward(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	var n uint64
+	it := list.NewIterator()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if it.Value().(uint64) != n {
+			t.Fatal("wrong iteration order at", n, it.Value())
+		}
+		n++
+	}
+
+	if n != 100 {
+		t.Fatal("wrong number of iterated elements", n)
+	}
+}
+
+func TestIteratorPeek(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 2)
+
+	it := list.NewIterator()
+	if it.Peek() != nil {
+		t.Fatal("unpositioned iterator must not peek an element")
+	}
+
+	it.SeekToFirst()
+	if e := it.Peek(); e == nil || !bytes.Equal(e.Key(), []byte("b")) {
+		t.Fatal("expected to peek at \"b\"", e)
+	}
+	if !bytes.Equal(it.Key(), []byte("a")) {
+		t.Fatal("Peek must not advance the iterator")
+	}
+
+	it.Next()
+	if it.Peek() != nil {
+		t.Fatal("expected nil when peeking past the last element")
+	}
+}
+
+func TestIteratorAcquireRelease(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+
+	it := list.AcquireIterator()
+	it.SeekToFirst()
+	if !it.Valid() || it.Value().(int) != 1 {
+		t.Fatal("acquired iterator must iterate the list")
+	}
+	it.Release()
+
+	other := New()
+	it = other.AcquireIterator()
+	if it.Valid() {
+		t.Fatal("acquired iterator must start unpositioned")
+	}
+	it.SeekToFirst()
+	if it.Valid() {
+		t.Fatal("acquired iterator must iterate the list it was acquired from")
+	}
+	it.Release()
+}
+
+func BenchmarkIteratorAcquireRelease(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		it := benchList.AcquireIterator()
+		it.SeekForPrev(benchKey(i))
+		it.Release()
+	}
+}
+
+func TestIteratorBackward(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i*2), i*2)
+	}
+	for i := uint64(0); i < 100; i += 3 {
+		list.Remove(orderedKey(i * 2))
+	}
+	checkSanity(list, t)
+
+	if list.Front().Prev() != nil {
+		t.Fatal("the first element must not have a predecessor")
+	}
+
+	// The latest 5 entries before key 101.
+	var got []uint64
+	it := list.NewIterator()
+	for it.SeekLT(orderedKey(101)); it.Valid() && len(got) < 5; it.Prev() {
+		got = append(got, it.Value().(uint64))
+	}
+
+	want := []uint64{100, 98, 94, 92, 88}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatal("wrong backward iteration", got)
+		}
+	}
+
+	n := 0
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		n++
+	}
+	if n != list.Length {
+		t.Fatal("backward iteration must visit every element", n, list.Length)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	list := New()
+	for _, k := range []string{"10", "20", "30"} {
+		list.Set([]byte(k), k)
+	}
+
+	cases := []struct {
+		key, ge, le string
+	}{
+		{"05", "10", ""},
+		{"10", "10", "10"},
+		{"15", "20", "10"},
+		{"30", "30", "30"},
+		{"99", "", "30"},
+	}
+
+	it := list.NewIterator()
+	for _, c := range cases {
+		e := list.Seek([]byte(c.key))
+		if (c.ge == "" && e != nil) || (c.ge != "" && (e == nil || string(e.Key()) != c.ge)) {
+			t.Fatalf("Seek(%s): expected %q, got %v", c.key, c.ge, e)
+		}
+
+		it.Seek([]byte(c.key))
+		if it.Element() != e {
+			t.Fatalf("Iterator.Seek(%s) must agree with SkipList.Seek", c.key)
+		}
+
+		e = list.SeekForPrev([]byte(c.key))
+		if (c.le == "" && e != nil) || (c.le != "" && (e == nil || string(e.Key()) != c.le)) {
+			t.Fatalf("SeekForPrev(%s): expected %q, got %v", c.key, c.le, e)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	var got []uint64
+	for it := list.Range(orderedKey(10), orderedKey(20)); it.Valid(); it.Next() {
+		got = append(got, it.Value().(uint64))
+	}
+	if len(got) != 10 || got[0] != 10 || got[9] != 19 {
+		t.Fatal("wrong range", got)
+	}
+
+	it := list.Range(orderedKey(10), orderedKey(20))
+	if it.Prev(); it.Valid() {
+		t.Fatal("Prev must not leave the range")
+	}
+	if it.SeekToLast(); !it.Valid() || it.Value().(uint64) != 19 {
+		t.Fatal("SeekToLast must stay within the range")
+	}
+	if it.Seek(orderedKey(5)); !it.Valid() || it.Value().(uint64) != 10 {
+		t.Fatal("Seek must stay within the range")
+	}
+	if it.SeekForPrev(orderedKey(50)); !it.Valid() || it.Value().(uint64) != 19 {
+		t.Fatal("SeekForPrev must stay within the range")
+	}
+
+	if it := list.Range(orderedKey(200), nil); it.Valid() {
+		t.Fatal("a range past the last key must be empty")
+	}
+
+	n := 0
+	for it := list.Range(nil, nil); it.Valid(); it.Next() {
+		n++
+	}
+	if n != 100 {
+		t.Fatal("an open range must visit the whole list", n)
+	}
+}
+
+func TestIteratorProgress(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 10000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	it := list.NewIterator()
+	if it.Progress() != 1 {
+		t.Fatal("an invalid iterator must report completion")
+	}
+
+	it.SeekToFirst()
+	if p := it.Progress(); p != 0 {
+		t.Fatal("the first element must report no progress", p)
+	}
+
+	last := 0.0
+	for i := 0; it.Valid(); i++ {
+		if i%1000 == 0 {
+			p := it.Progress()
+			if p < last || math.Abs(p-float64(i)/10000) > 0.1 {
+				t.Fatal("progress must grow with the scan", i, p, last)
+			}
+			last = p
+		}
+		it.Next()
+	}
+
+	r := list.Range(orderedKey(1000), orderedKey(2000))
+	r.Seek(orderedKey(1500))
+	if p := r.Progress(); math.Abs(p-0.5) > 0.1 {
+		t.Fatal("progress must be relative to the range", p)
+	}
+}
@@ -0,0 +1,110 @@
+package skiplist
+
+import (
+	"math/rand"
+	"time"
+)
+
+// lockOp identifies the operation waiting for the list mutex, for the lock wait statistics.
+type lockOp int
+
+const (
+	lockSet lockOp = iota
+	lockRemove
+	lockRemoveRange
+	lockUpdate
+	lockApply
+	lockClear
+	lockRotate
+	lockFlush
+	lockEvict
+	lockReserve
+	lockExpire
+	lockCompact
+	lockBulk
+	numLockOps
+)
+
+var lockOpNames = [numLockOps]string{
+	lockSet:         "Set",
+	lockRemove:      "Remove",
+	lockRemoveRange: "RemoveRange",
+	lockUpdate:      "Update",
+	lockApply:       "Apply",
+	lockClear:       "Clear",
+	lockRotate:      "Rotate",
+	lockFlush:       "Flush",
+	lockEvict:       "Evict",
+	lockReserve:     "ReserveRange",
+	lockExpire:      "Expire",
+	lockCompact:     "Compact",
+	lockBulk:        "NewFromSorted",
+}
+
+// LockWait is the time one kind of operation spent waiting to lock a list, as reported by
+// Stats for lists constructed WithLockWaitTracking. Waits are sampled at the rate set
+// WithStatsSampling, and Acquisitions and Wait are scaled up accordingly, so they are estimates.
+type LockWait struct {
+	// Op is the operation, e.g. "Set". Operations that change several elements at once, such
+	// as "Apply" for batches and "Clear", are reported apart from the single-element ones.
+	Op string
+	// Acquisitions is the number of times the operation locked the list.
+	Acquisitions uint64
+	// Wait is the total time the operation spent waiting for the lock.
+	Wait time.Duration
+	// MaxWait is the longest sampled wait.
+	MaxWait time.Duration
+}
+
+// lockWaits accumulates the sampled lock waits of each operation. It is written with the list
+// mutex held for writing, and read with it held for reading.
+type lockWaits [numLockOps]struct {
+	samples uint64
+	total   time.Duration
+	max     time.Duration
+}
+
+// lock locks the list mutex for writing on behalf of op, sampling the time spent waiting if
+// the list tracks lock waits.
+func (list *SkipList) lock(op lockOp) {
+	if list.lockWaits == nil || (list.statsSampling > 1 && rand.Int63n(int64(list.statsSampling)) != 0) {
+		list.mutex.Lock()
+		list.lockedOp = op
+		return
+	}
+
+	start := time.Now()
+	list.mutex.Lock()
+	list.lockedOp = op
+	wait := time.Since(start)
+
+	w := &list.lockWaits[op]
+	w.samples++
+	w.total += wait
+	if wait > w.max {
+		w.max = wait
+	}
+}
+
+// lockWaitStats returns the lock waits of the operations sampled so far. The caller must hold
+// the list mutex.
+func (list *SkipList) lockWaitStats() []LockWait {
+	if list.lockWaits == nil {
+		return nil
+	}
+
+	var waits []LockWait
+	scale := uint64(list.statsSampling)
+	for op, w := range list.lockWaits {
+		if w.samples == 0 {
+			continue
+		}
+		waits = append(waits, LockWait{
+			Op:           lockOpNames[op],
+			Acquisitions: w.samples * scale,
+			Wait:         w.total * time.Duration(scale),
+			MaxWait:      w.max,
+		})
+	}
+	return waits
+}
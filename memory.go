@@ -0,0 +1,77 @@
+package skiplist
+
+import (
+	"unsafe"
+)
+
+const (
+	elementSize = int64(unsafe.Sizeof(Element{}))
+	pointerSize = int64(unsafe.Sizeof(unsafe.Pointer(nil)))
+	intSize     = int64(unsafe.Sizeof(0))
+)
+
+// towerSizes are the tower sizes allocElement rounds levels up to.
+var towerSizes = [...]int{1, 2, 3, 4, 6, 8, 12, 16, 24, 32, 48, 64}
+
+// towerCapacity returns the size of the tower allocElement allocates for level.
+func towerCapacity(level int) int {
+	for _, size := range towerSizes {
+		if level <= size {
+			return size
+		}
+	}
+	return towerSizes[len(towerSizes)-1]
+}
+
+// ApproxMemoryUsage estimates the memory held by the list in bytes: its elements and their
+// towers, their keys, and their values as sized WithValueSizer, or by length for byte slices and
+// strings without a sizer. It is kept up to date by every write, so it is cheap enough to check
+// after each one, as memtables flushing on a memory threshold do. It does not account for memory
+// held by a history of versions, ghost keys, hot key tracking or the Go allocator's overhead.
+func (list *SkipList) ApproxMemoryUsage() int64 {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.fixedBytes() + list.nodeBytes + list.keyBytes + list.valueBytes
+}
+
+// fixedBytes returns the memory held by the list itself: its head tower, along with the tails,
+// level counts and search cache of each level.
+func (list *SkipList) fixedBytes() int64 {
+	return int64(unsafe.Sizeof(*list)) + int64(list.maxLevel)*(3*pointerSize+intSize)
+}
+
+// footprint returns the memory held by an element and its tower, not counting its key and
+// value.
+func (list *SkipList) footprint(element *Element) int64 {
+	tower := len(element.next)
+	if list.arena == nil {
+		tower = towerCapacity(tower)
+	}
+	return elementSize + int64(tower)*pointerSize + int64(len(element.spans))*intSize
+}
+
+// sizeValue returns the size of a value, as reported by the list's value sizer, or its length
+// if it is a byte slice or string and the list has no sizer.
+func (list *SkipList) sizeValue(value interface{}) int64 {
+	if value == nil {
+		return 0
+	}
+	if list.valueSizer != nil {
+		return list.valueSizer(value)
+	}
+	return int64(valueSize(value))
+}
+
+// growth returns the number of value bytes replacing the value of element with value adds to
+// the list, negative if it frees some.
+func (list *SkipList) growth(element *Element, value interface{}) int64 {
+	return list.sizeValue(value) - list.sizeValue(element.Value())
+}
+
+// exceedsQuota reports whether adding n key and value bytes would take the list past the quota
+// set WithByteQuota. Writes that add nothing are always admitted. The caller must hold the list
+// mutex.
+func (list *SkipList) exceedsQuota(n int64) bool {
+	return list.byteQuota > 0 && n > 0 && list.keyBytes+list.valueBytes+n > list.byteQuota
+}
@@ -0,0 +1,239 @@
+package skiplist
+
+import (
+	"bytes"
+	"container/heap"
+)
+
+// MergeIterator walks several iterators as one, yielding their elements in key order, forwards
+// or backwards. When iterators hold equal keys, moving forwards yields the element of the
+// iterator passed first first, and moving backwards yields them in the exact opposite order, so
+// that Next and Prev always undo each other. Like Iterator, it starts out unpositioned.
+type MergeIterator struct {
+	heap mergeHeap
+	// latest restricts the iterator to the element of the first iterator holding each key.
+	latest bool
+}
+
+// NewMergeIterator returns an unpositioned iterator merging iters, which must all iterate
+// lists ordered by the same comparator.
+func NewMergeIterator(iters ...*Iterator) *MergeIterator {
+	it := &MergeIterator{heap: mergeHeap{iters: iters, compare: bytes.Compare}}
+	if len(iters) > 0 {
+		it.heap.compare = iters[0].list.compare
+	}
+	return it
+}
+
+// NewLatestMergeIterator is like NewMergeIterator, but yields each key once, with the element
+// of the first iterator holding it, in either direction. Passing the iterators of the newest
+// list first, such as the active memtable before the frozen ones, gives "latest value wins"
+// reads without materializing the merged result.
+func NewLatestMergeIterator(iters ...*Iterator) *MergeIterator {
+	it := NewMergeIterator(iters...)
+	it.latest = true
+	return it
+}
+
+// Valid reports whether the iterator is positioned at an element.
+func (it *MergeIterator) Valid() bool {
+	return len(it.heap.valid) > 0
+}
+
+// Element returns the element at the current position, or nil if the iterator is not valid.
+func (it *MergeIterator) Element() *Element {
+	if !it.Valid() {
+		return nil
+	}
+	return it.heap.top().Element()
+}
+
+// Key returns the key at the current position. The iterator must be valid.
+func (it *MergeIterator) Key() []byte {
+	return it.heap.top().Key()
+}
+
+// Value returns the value at the current position. The iterator must be valid.
+func (it *MergeIterator) Value() interface{} {
+	return it.heap.top().Value()
+}
+
+// SeekToFirst positions the iterator at the first element of any of the iterators.
+func (it *MergeIterator) SeekToFirst() {
+	for _, iter := range it.heap.iters {
+		iter.SeekToFirst()
+	}
+	it.init(false)
+}
+
+// SeekToLast positions the iterator at the last element of any of the iterators.
+func (it *MergeIterator) SeekToLast() {
+	for _, iter := range it.heap.iters {
+		iter.SeekToLast()
+	}
+	it.init(true)
+}
+
+// Seek positions the iterator at the first element whose key is greater than or equal to key.
+// The iterator is invalid if no such element exists.
+func (it *MergeIterator) Seek(key []byte) {
+	for _, iter := range it.heap.iters {
+		iter.Seek(key)
+	}
+	it.init(false)
+}
+
+// SeekForPrev positions the iterator at the last element whose key is less than or equal to key.
+// The iterator is invalid if no such element exists.
+func (it *MergeIterator) SeekForPrev(key []byte) {
+	for _, iter := range it.heap.iters {
+		iter.SeekForPrev(key)
+	}
+	it.init(true)
+}
+
+// Next advances the iterator to the following element. The iterator must be valid.
+func (it *MergeIterator) Next() {
+	if it.heap.reverse {
+		it.switchToForward()
+		return
+	}
+
+	if it.latest {
+		it.advanceKey()
+		return
+	}
+	it.advanceTop()
+}
+
+// Prev moves the iterator to the preceding element. The iterator must be valid.
+func (it *MergeIterator) Prev() {
+	if !it.heap.reverse {
+		it.switchToReverse()
+		return
+	}
+
+	if it.latest {
+		it.advanceKey()
+		return
+	}
+	it.advanceTop()
+}
+
+// advanceTop moves the iterator at the top of the heap one step in the current direction.
+func (it *MergeIterator) advanceTop() {
+	top := it.heap.top()
+	if it.heap.reverse {
+		top.Prev()
+	} else {
+		top.Next()
+	}
+
+	if top.Valid() {
+		heap.Fix(&it.heap, 0)
+	} else {
+		heap.Pop(&it.heap)
+	}
+}
+
+// advanceKey moves every iterator at the current key one step in the current direction.
+func (it *MergeIterator) advanceKey() {
+	key := it.Key()
+	for it.Valid() && it.heap.compare(it.Key(), key) == 0 {
+		it.advanceTop()
+	}
+}
+
+// switchToReverse positions the iterators for moving backwards from the current element.
+func (it *MergeIterator) switchToReverse() {
+	key, current := it.Key(), it.heap.valid[0]
+	for i, iter := range it.heap.iters {
+		switch {
+		case i == current && !it.latest:
+			iter.Prev()
+		case i < current && !it.latest:
+			// Iterators before the current one yielded their equal keys before it.
+			iter.SeekForPrev(key)
+		default:
+			iter.SeekLT(key)
+		}
+	}
+	it.init(true)
+}
+
+// switchToForward positions the iterators for moving forwards from the current element.
+func (it *MergeIterator) switchToForward() {
+	key, current := it.Key(), it.heap.valid[0]
+	for i, iter := range it.heap.iters {
+		switch {
+		case i == current && !it.latest:
+			iter.Next()
+		case i > current && !it.latest:
+			// Iterators after the current one yield their equal keys after it.
+			iter.Seek(key)
+		default:
+			iter.Seek(key)
+			if iter.Valid() && it.heap.compare(iter.Key(), key) == 0 {
+				iter.Next()
+			}
+		}
+	}
+	it.init(false)
+}
+
+func (it *MergeIterator) init(reverse bool) {
+	it.heap.reverse = reverse
+	// Yielding the first iterator's element of each key needs it on top in both directions.
+	it.heap.tiesAscending = !reverse || it.latest
+	it.heap.init()
+}
+
+// mergeHeap orders the indexes of the valid iterators by their current keys,
+// in descending order when reverse is set, breaking ties by index.
+type mergeHeap struct {
+	iters         []*Iterator
+	compare       func(a, b []byte) int
+	valid         []int
+	reverse       bool
+	tiesAscending bool
+}
+
+func (h *mergeHeap) init() {
+	h.valid = h.valid[:0]
+	for i, iter := range h.iters {
+		if iter.Valid() {
+			h.valid = append(h.valid, i)
+		}
+	}
+	heap.Init(h)
+}
+
+func (h *mergeHeap) top() *Iterator {
+	return h.iters[h.valid[0]]
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.valid)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.valid[i], h.valid[j]
+	if c := h.compare(h.iters[a].Key(), h.iters[b].Key()); c != 0 {
+		return (c < 0) != h.reverse
+	}
+	return (a < b) == h.tiesAscending
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.valid[i], h.valid[j] = h.valid[j], h.valid[i]
+}
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.valid = append(h.valid, x.(int))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	last := h.valid[len(h.valid)-1]
+	h.valid = h.valid[:len(h.valid)-1]
+	return last
+}
@@ -0,0 +1,145 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestMergeIterator(t *testing.T) {
+	a, b, c := New(), New(), New()
+	for i := uint64(0); i < 30; i++ {
+		switch i % 3 {
+		case 0:
+			a.Set(orderedKey(i), "a")
+		case 1:
+			b.Set(orderedKey(i), "b")
+		default:
+			c.Set(orderedKey(i), "c")
+		}
+	}
+	// A key present in several lists is yielded once per list, earliest iterator first.
+	c.Set(orderedKey(3), "c")
+
+	it := NewMergeIterator(a.NewIterator(), b.NewIterator(), c.NewIterator())
+	if it.Valid() || it.Element() != nil {
+		t.Fatal("a new merge iterator must be unpositioned")
+	}
+
+	var keys []uint64
+	var values []string
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		keys = append(keys, orderedKeyValue(it.Key()))
+		values = append(values, it.Value().(string))
+	}
+	if len(keys) != 31 || keys[3] != 3 || keys[4] != 3 || values[3] != "a" || values[4] != "c" || keys[30] != 29 {
+		t.Fatal("wrong merged order", keys, values)
+	}
+
+	if it.Seek(orderedKey(20)); !it.Valid() || orderedKeyValue(it.Key()) != 20 {
+		t.Fatal("Seek must position every iterator", it.Element())
+	}
+	if it.Seek(orderedKey(100)); it.Valid() {
+		t.Fatal("Seek past the end must invalidate the iterator")
+	}
+
+	empty := NewMergeIterator()
+	if empty.SeekToFirst(); empty.Valid() {
+		t.Fatal("merging nothing must be empty")
+	}
+}
+
+func TestMergeIteratorReverse(t *testing.T) {
+	a, b := New(), New()
+	for i := uint64(0); i < 20; i++ {
+		a.Set(orderedKey(i*2), "a")
+		b.Set(orderedKey(i*3), "b")
+	}
+
+	type entry struct {
+		key   uint64
+		value string
+	}
+	collect := func(it *MergeIterator) entry {
+		return entry{orderedKeyValue(it.Key()), it.Value().(string)}
+	}
+
+	it := NewMergeIterator(a.NewIterator(), b.NewIterator())
+	var forward []entry
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		forward = append(forward, collect(it))
+	}
+
+	var backward []entry
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		backward = append(backward, collect(it))
+	}
+
+	if len(forward) != 40 || len(backward) != len(forward) {
+		t.Fatal("wrong number of entries", len(forward), len(backward))
+	}
+	for i := range forward {
+		if forward[i] != backward[len(backward)-1-i] {
+			t.Fatal("backward iteration must reverse forward iteration", i, forward[i], backward[len(backward)-1-i])
+		}
+	}
+
+	// Switching direction at every step, including between equal keys, must retrace the order.
+	it.SeekToFirst()
+	for i := 0; i < len(forward)-1; i++ {
+		it.Next()
+		it.Prev()
+		if collect(it) != forward[i] {
+			t.Fatal("Prev must undo Next", i, collect(it), forward[i])
+		}
+		it.Next()
+	}
+
+	if it.SeekForPrev(orderedKey(7)); !it.Valid() || orderedKeyValue(it.Key()) != 6 || it.Value() != "b" {
+		t.Fatal("SeekForPrev must position at the last entry <= key", collect(it))
+	}
+}
+
+func TestLatestMergeIterator(t *testing.T) {
+	active, frozen := New(), New()
+	for i := uint64(0); i < 10; i++ {
+		frozen.Set(orderedKey(i), "old")
+	}
+	for i := uint64(0); i < 10; i += 3 {
+		active.Set(orderedKey(i), "new")
+	}
+	active.Set(orderedKey(20), "new")
+
+	want := map[uint64]string{0: "new", 3: "new", 6: "new", 9: "new", 20: "new"}
+	it := NewLatestMergeIterator(active.NewIterator(), frozen.NewIterator())
+
+	check := func(keys []uint64) {
+		if len(keys) != 11 {
+			t.Fatal("every key must be yielded once", keys)
+		}
+	}
+
+	var keys []uint64
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		k := orderedKeyValue(it.Key())
+		if v, ok := want[k]; (ok && it.Value() != v) || (!ok && it.Value() != "old") {
+			t.Fatal("the first iterator must win", k, it.Value())
+		}
+		keys = append(keys, k)
+	}
+	check(keys)
+
+	keys = keys[:0]
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		k := orderedKeyValue(it.Key())
+		if v, ok := want[k]; (ok && it.Value() != v) || (!ok && it.Value() != "old") {
+			t.Fatal("the first iterator must win backwards too", k, it.Value())
+		}
+		keys = append(keys, k)
+	}
+	check(keys)
+
+	it.Seek(orderedKey(3))
+	it.Next()
+	if it.Prev(); orderedKeyValue(it.Key()) != 3 || it.Value() != "new" {
+		t.Fatal("Prev must undo Next", orderedKeyValue(it.Key()), it.Value())
+	}
+}
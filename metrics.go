@@ -0,0 +1,50 @@
+package skiplist
+
+import "time"
+
+// Metrics receives counts and measurements of a list's operations, for forwarding to a metrics
+// library such as tally or Prometheus without wrapping every call to the list. Set it
+// WithMetrics. Its methods are called on the path of the operations, some with the list locked,
+// so they must be quick, safe for concurrent use, and must not use the list.
+//
+// Embed NopMetrics in an implementation to keep it compiling as methods are added.
+type Metrics interface {
+	// IncSet counts a key written by Set, SetWithTTL, Merge or Apply, or created by GetOrCreate.
+	IncSet()
+	// IncGet counts a lookup by Get, and whether it found the key.
+	IncGet(hit bool)
+	// IncRemove counts a key removed by Remove or Apply.
+	IncRemove()
+	// IncEvict counts an element dropped by the list itself, because it was evicted or expired.
+	IncEvict()
+	// ObserveSearchSteps records the number of links followed by a search, which grows with
+	// the logarithm of the list's length when its towers are well balanced.
+	ObserveSearchSteps(steps int)
+	// ObserveLatency records the time taken by a call to the operation op, one of "Get",
+	// "Set", "SetWithTTL", "Merge", "GetOrCreate", "Remove" and "Apply", including any wait for
+	// the list's lock.
+	ObserveLatency(op string, d time.Duration)
+}
+
+// ListMetrics is a Metrics shared by several lists that tells them apart, say by labelling what
+// it reports. A list constructed WithMetrics and a ListMetrics calls ForList once, with the name
+// and labels it was constructed with, and reports its operations to the Metrics returned.
+type ListMetrics interface {
+	Metrics
+	ForList(name string, labels map[string]string) Metrics
+}
+
+// NopMetrics is a Metrics that discards everything.
+type NopMetrics struct{}
+
+func (NopMetrics) IncSet()                              {}
+func (NopMetrics) IncGet(bool)                          {}
+func (NopMetrics) IncRemove()                           {}
+func (NopMetrics) IncEvict()                            {}
+func (NopMetrics) ObserveSearchSteps(int)               {}
+func (NopMetrics) ObserveLatency(string, time.Duration) {}
+
+// observeLatency reports the time since start taken by op to the list's metrics.
+func (list *SkipList) observeLatency(op string, start time.Time) {
+	list.metrics.ObserveLatency(op, time.Since(start))
+}
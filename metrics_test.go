@@ -0,0 +1,100 @@
+package skiplist
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingMetrics struct {
+	NopMetrics
+	sets, hits, misses, removes, evicts, searches, steps atomic.Int64
+	latencies                                            sync.Map
+}
+
+func (m *countingMetrics) IncSet()    { m.sets.Add(1) }
+func (m *countingMetrics) IncRemove() { m.removes.Add(1) }
+func (m *countingMetrics) IncEvict()  { m.evicts.Add(1) }
+
+func (m *countingMetrics) IncGet(hit bool) {
+	if hit {
+		m.hits.Add(1)
+	} else {
+		m.misses.Add(1)
+	}
+}
+
+func (m *countingMetrics) ObserveSearchSteps(steps int) {
+	m.searches.Add(1)
+	m.steps.Add(int64(steps))
+}
+
+func (m *countingMetrics) ObserveLatency(op string, d time.Duration) {
+	n, _ := m.latencies.LoadOrStore(op, new(atomic.Int64))
+	n.(*atomic.Int64).Add(1)
+}
+
+func (m *countingMetrics) observed(op string) int64 {
+	n, ok := m.latencies.Load(op)
+	if !ok {
+		return 0
+	}
+	return n.(*atomic.Int64).Load()
+}
+
+func TestMetrics(t *testing.T) {
+	m := &countingMetrics{}
+	list := New(WithMetrics(m), WithWeigher(func([]byte, interface{}) int64 { return 1 }), WithMaxWeight(900))
+
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	list.Get(orderedKey(999))
+	list.Get(orderedKey(0))
+	list.Remove(orderedKey(500))
+	list.Remove(orderedKey(0))
+
+	var batch WriteBatch
+	batch.Set(orderedKey(2000), 1)
+	batch.Remove(orderedKey(501))
+	list.Apply(&batch)
+
+	if m.sets.Load() != 1001 || m.hits.Load() != 1 || m.misses.Load() != 1 || m.removes.Load() != 2 || m.evicts.Load() != 100 {
+		t.Fatal("wrong counts", m.sets.Load(), m.hits.Load(), m.misses.Load(), m.removes.Load(), m.evicts.Load())
+	}
+	if m.searches.Load() == 0 || m.steps.Load() == 0 {
+		t.Fatal("searches not observed")
+	}
+	if m.observed("Set") != 1000 || m.observed("Get") != 2 || m.observed("Remove") != 2 || m.observed("Apply") != 1 {
+		t.Fatal("wrong latency observations", m.observed("Set"), m.observed("Get"), m.observed("Remove"), m.observed("Apply"))
+	}
+}
+
+// shardedMetrics keeps the counts of each list apart, by name.
+type shardedMetrics struct {
+	NopMetrics
+	lists map[string]*countingMetrics
+}
+
+func (m *shardedMetrics) ForList(name string, labels map[string]string) Metrics {
+	list := &countingMetrics{}
+	m.lists[name+"/"+labels["shard"]] = list
+	return list
+}
+
+func TestListMetrics(t *testing.T) {
+	m := &shardedMetrics{lists: map[string]*countingMetrics{}}
+	a := New(WithMetrics(m), WithName("cache"), WithLabels(map[string]string{"shard": "a"}))
+	b := New(WithName("cache"), WithLabels(map[string]string{"shard": "b"}), WithMetrics(m))
+
+	a.Set(orderedKey(1), 1)
+	b.Set(orderedKey(1), 1)
+	b.Set(orderedKey(2), 2)
+	if sets := m.lists["cache/a"].sets.Load(); sets != 1 {
+		t.Fatal("wrong sets for list a", sets)
+	}
+	if sets := m.lists["cache/b"].sets.Load(); sets != 2 {
+		t.Fatal("wrong sets for list b", sets)
+	}
+}
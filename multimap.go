@@ -0,0 +1,172 @@
+package skiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync/atomic"
+)
+
+// MultiMap is a map from []byte keys to any number of V values each, which iterates in key
+// order and, within a key, in the order the values were added. Each value is stamped with a
+// sequence number when it is added, and stored under its key followed by the stamp, so that the
+// order of the values of a key is fixed once they are added: every iteration replays them in the
+// same order, as consumers of event logs need, including after a round trip through the record
+// format or a Merge. It is safe for concurrent use, with the guarantees of a SkipList.
+// Concurrent adds under one key are ordered as they are stamped.
+type MultiMap[V any] struct {
+	list *SkipList
+	seq  atomic.Uint64
+}
+
+// NewMultiMap creates an empty multimap, backed by a list configured by opts. The keys are ordered
+// by the comparator given in opts, if any.
+func NewMultiMap[V any](opts ...Option) *MultiMap[V] {
+	return &MultiMap[V]{list: New(append(opts, withSeqSuffix)...)}
+}
+
+// LoadMultiMap builds a multimap from records written by a MultiMap's NewRecordReader, decoding
+// values with codec. The records carry the stamps of the values, so every key keeps its values in
+// the order they were added, and values added to the loaded map go after them. opts configure the
+// load as they do for LoadRecords.
+func LoadMultiMap[V any](r io.Reader, codec Codec, opts LoadOptions) (*MultiMap[V], error) {
+	opts.ListOptions = append(opts.ListOptions[:len(opts.ListOptions):len(opts.ListOptions)], withSeqSuffix)
+	opts.checkKey = func(key []byte) error {
+		if len(key) < 8 {
+			return errors.New("key has no multimap stamp")
+		}
+		return nil
+	}
+	list, err := LoadRecords(r, codec, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MultiMap[V]{list: list}
+	for element := list.Front(); element != nil; element = element.Next() {
+		if seq := stampOf(element.key); seq > m.seq.Load() {
+			m.seq.Store(seq)
+		}
+	}
+	return m, nil
+}
+
+// withSeqSuffix orders keys ending in a sequence stamp by the list's comparator, then by stamp.
+func withSeqSuffix(list *SkipList) {
+	compare := list.compare
+	if compare == nil {
+		compare = bytes.Compare
+	}
+	list.compare = func(a, b []byte) int {
+		if c := compare(a[:len(a)-8], b[:len(b)-8]); c != 0 {
+			return c
+		}
+		return bytes.Compare(a[len(a)-8:], b[len(b)-8:])
+	}
+}
+
+// stamped returns key followed by the big-endian stamp seq.
+func stamped(key []byte, seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(make([]byte, 0, len(key)+8), key...), seq)
+}
+
+// stampOf returns the stamp a stamped key ends with.
+func stampOf(key []byte) uint64 {
+	return binary.BigEndian.Uint64(key[len(key)-8:])
+}
+
+// Add adds value under key, after the values already there. The map keeps a copy of key.
+func (m *MultiMap[V]) Add(key []byte, value V) {
+	m.list.Set(stamped(key, m.seq.Add(1)), value)
+}
+
+// Get returns the values of key, in the order they were added, or nil if there are none.
+func (m *MultiMap[V]) Get(key []byte) []V {
+	it := m.list.AcquireIterator()
+	defer it.Release()
+
+	var values []V
+	it.lower, it.upper = stamped(key, 0), stamped(key, math.MaxUint64)
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		values = append(values, valueOf[V](it.Value()))
+	}
+	return values
+}
+
+// Merge adds every value of other to m, in key order and, within a key, after the values m
+// already has, in the order they were added to other. other must not be m.
+func (m *MultiMap[V]) Merge(other *MultiMap[V]) {
+	other.Range(func(key []byte, value V) bool {
+		m.Add(key, value)
+		return true
+	})
+}
+
+// NewRecordReader returns a reader of the values of the map in the record format of
+// NewRecordReader, with values encoded by codec, for LoadMultiMap to load. Each record's key is
+// the key of a value followed by its stamp, so that the values of a key are loaded back in the
+// order they were added.
+func (m *MultiMap[V]) NewRecordReader(codec Codec) *RecordReader {
+	return NewRecordReader(m.list, codec)
+}
+
+// Delete removes every value of key, returning how many there were.
+func (m *MultiMap[V]) Delete(key []byte) int {
+	return m.list.RemoveRange(stamped(key, 0), stamped(key, math.MaxUint64))
+}
+
+// Len returns the number of values in the map, counting every value of a key.
+func (m *MultiMap[V]) Len() int {
+	return m.list.Len()
+}
+
+// Range calls fn for every value of the map and its key, in ascending key order and, within a
+// key, in the order the values were added, until fn returns false.
+func (m *MultiMap[V]) Range(fn func(key []byte, value V) bool) {
+	m.Ascend(nil, nil, fn)
+}
+
+// Ascend is Range restricted to the keys with start <= key < end, in ascending order. A nil
+// start or end leaves that side of the range open.
+func (m *MultiMap[V]) Ascend(start, end []byte, fn func(key []byte, value V) bool) {
+	it := m.list.AcquireIterator()
+	defer it.Release()
+
+	it.lower, it.upper = m.bounds(start, end)
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		key := it.Key()
+		if !fn(key[:len(key)-8], valueOf[V](it.Value())) {
+			return
+		}
+	}
+}
+
+// Descend is Ascend in descending key order, visiting the values of each key in the reverse of
+// the order they were added.
+func (m *MultiMap[V]) Descend(start, end []byte, fn func(key []byte, value V) bool) {
+	it := m.list.AcquireIterator()
+	defer it.Release()
+
+	it.lower, it.upper = m.bounds(start, end)
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		key := it.Key()
+		if !fn(key[:len(key)-8], valueOf[V](it.Value())) {
+			return
+		}
+	}
+}
+
+// bounds returns the bounds of the list's keys for the map's keys in [start, end). Stamps start
+// at 1, so every value of a key sorts after the key followed by a zero stamp.
+func (m *MultiMap[V]) bounds(start, end []byte) ([]byte, []byte) {
+	var lower, upper []byte
+	if start != nil {
+		lower = stamped(start, 0)
+	}
+	if end != nil {
+		upper = stamped(end, 0)
+	}
+	return lower, upper
+}
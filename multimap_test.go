@@ -0,0 +1,111 @@
+package skiplist
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestMultiMap(t *testing.T) {
+	m := NewMultiMap[int]()
+	for i := 0; i < 30; i++ {
+		m.Add([]byte{"bca"[i%3]}, i)
+	}
+	m.Add([]byte("ab"), 100)
+
+	var keys []string
+	var values []int
+	m.Range(func(key []byte, value int) bool {
+		keys = append(keys, string(key))
+		values = append(values, value)
+		return true
+	})
+	if m.Len() != 31 || len(values) != 31 || keys[0] != "a" || keys[10] != "ab" || keys[11] != "b" {
+		t.Fatal("values must be in key order", keys)
+	}
+	for i := 1; i < 10; i++ {
+		if values[i] != values[i-1]+3 || values[11+i] != values[10+i]+3 {
+			t.Fatal("the values of a key must be in the order they were added", values)
+		}
+	}
+
+	if got := m.Get([]byte("b")); len(got) != 10 || got[0] != 0 || got[9] != 27 {
+		t.Fatal("wrong values of a key", got)
+	}
+	if m.Get([]byte("z")) != nil {
+		t.Fatal("a missing key must have no values")
+	}
+
+	var descended []string
+	m.Descend([]byte("ab"), []byte("c"), func(key []byte, value int) bool {
+		descended = append(descended, string(key)+strconv.Itoa(value))
+		return len(descended) < 3
+	})
+	if len(descended) != 3 || descended[0] != "b27" || descended[2] != "b21" {
+		t.Fatal("wrong descending range", descended)
+	}
+
+	if n := m.Delete([]byte("a")); n != 10 || m.Len() != 21 || m.Get([]byte("a")) != nil || len(m.Get([]byte("ab"))) != 1 {
+		t.Fatal("Delete must remove every value of the key, and only those", n, m.Len())
+	}
+}
+
+func TestMultiMapComparator(t *testing.T) {
+	m := NewMultiMap[int](WithComparator(func(a, b []byte) int { return bytes.Compare(b, a) }))
+	m.Add([]byte("a"), 1)
+	m.Add([]byte("b"), 2)
+	m.Add([]byte("a"), 3)
+
+	var values []int
+	m.Range(func(key []byte, value int) bool {
+		values = append(values, value)
+		return true
+	})
+	if len(values) != 3 || values[0] != 2 || values[1] != 1 || values[2] != 3 {
+		t.Fatal("keys must follow the comparator, and values the order they were added", values)
+	}
+}
+
+func TestMultiMapRecords(t *testing.T) {
+	m := NewMultiMap[[]byte]()
+	for i := 0; i < 30; i++ {
+		m.Add([]byte{"bca"[i%3]}, []byte(strconv.Itoa(i)))
+	}
+
+	loaded, err := LoadMultiMap[[]byte](m.NewRecordReader(BytesCodec{}), BytesCodec{}, LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.Add([]byte("b"), []byte("last"))
+
+	got := loaded.Get([]byte("b"))
+	if loaded.Len() != 31 || len(got) != 11 || string(got[0]) != "0" || string(got[9]) != "27" || string(got[10]) != "last" {
+		t.Fatal("loaded values must keep the order they were added in", loaded.Len(), got)
+	}
+
+	if _, err := LoadMultiMap[[]byte](NewRecordReader(New(), BytesCodec{}), BytesCodec{}, LoadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	plain := New()
+	plain.Set([]byte("short"), []byte("x"))
+	if _, err := LoadMultiMap[[]byte](NewRecordReader(plain, BytesCodec{}), BytesCodec{}, LoadOptions{}); err == nil {
+		t.Fatal("records without stamps must fail to load")
+	}
+}
+
+func TestMultiMapMerge(t *testing.T) {
+	m, other := NewMultiMap[int](), NewMultiMap[int]()
+	m.Add([]byte("a"), 1)
+	other.Add([]byte("a"), 2)
+	other.Add([]byte("b"), 3)
+	m.Add([]byte("a"), 4)
+	other.Add([]byte("a"), 5)
+
+	m.Merge(other)
+	if got := m.Get([]byte("a")); len(got) != 4 || got[0] != 1 || got[1] != 4 || got[2] != 2 || got[3] != 5 {
+		t.Fatal("merged values must follow the values already there, in the order they were added", got)
+	}
+	if got := m.Get([]byte("b")); len(got) != 1 || got[0] != 3 || other.Len() != 3 {
+		t.Fatal("wrong merge", got, other.Len())
+	}
+}
@@ -0,0 +1,223 @@
+package skiplist
+
+import (
+	"bytes"
+	"sort"
+	"time"
+)
+
+// Namespace is a view of the keys of a list that start with a common prefix, typically one
+// tenant of a list shared by many. Keys passed to and returned by a Namespace's methods are
+// relative to the prefix, except that elements and iterators expose the full key.
+type Namespace struct {
+	list   *SkipList
+	prefix []byte
+	stats  *NamespaceStats
+}
+
+// NamespaceStats describes the keys of a namespace.
+type NamespaceStats struct {
+	// Count is the number of elements in the namespace, not counting tombstones.
+	Count int
+	// Bytes is the total size of the keys in the namespace, plus the size of their values as
+	// counted by Stats' ValueBytes.
+	Bytes int64
+	// LastWrite is the time of the last insert, update or removal in the namespace.
+	LastWrite time.Time
+}
+
+// namespaceRegistry maintains the stats of every namespace opened on a list. It is
+// guarded by the list mutex.
+type namespaceRegistry struct {
+	list *SkipList
+	// namespaces is sorted by prefix, and lengths holds the lengths of their prefixes in
+	// increasing order, so that the namespaces of a key are found with a binary search for each
+	// prefix length rather than a scan of every namespace.
+	namespaces []*Namespace
+	lengths    []int
+}
+
+// Namespace returns the namespace of keys starting with prefix. The first call for a prefix
+// starts maintaining the namespace's statistics incrementally, which costs one scan of the
+// namespace's current keys; later writes update them without scanning.
+// It panics if the list was constructed WithComparator.
+func (list *SkipList) Namespace(prefix []byte) *Namespace {
+	if !list.byteOrder {
+		panic(list.String() + ": namespaces require the default byte-wise key order")
+	}
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if list.namespaces == nil {
+		list.namespaces = &namespaceRegistry{list: list}
+	}
+
+	if ns := list.namespaces.find(prefix); ns != nil {
+		return ns
+	}
+
+	ns := &Namespace{
+		list:   list,
+		prefix: append([]byte(nil), prefix...),
+		stats:  &NamespaceStats{},
+	}
+
+	end := prefixEnd(ns.prefix)
+	for e := list.searchGreaterOrEqual(ns.prefix); e != nil; e = e.Next() {
+		if end != nil && list.compare(e.key, end) >= 0 {
+			break
+		}
+		if e.IsTombstone() {
+			continue
+		}
+		ns.stats.Count++
+		ns.stats.Bytes += int64(len(e.key)) + list.sizeValue(e.Value())
+	}
+
+	list.namespaces.add(ns)
+	return ns
+}
+
+// NamespaceStats returns the statistics of the namespace with the given prefix.
+// It returns false if the namespace was never opened with Namespace.
+func (list *SkipList) NamespaceStats(prefix []byte) (NamespaceStats, bool) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if list.namespaces == nil {
+		return NamespaceStats{}, false
+	}
+
+	if ns := list.namespaces.find(prefix); ns != nil {
+		return *ns.stats, true
+	}
+	return NamespaceStats{}, false
+}
+
+// Prefix returns the prefix shared by the keys of the namespace.
+func (ns *Namespace) Prefix() []byte {
+	return ns.prefix
+}
+
+// Set stores value at key within the namespace. See SkipList.Set.
+func (ns *Namespace) Set(key []byte, value interface{}) *Element {
+	return ns.list.Set(ns.key(key), value)
+}
+
+// Get finds an element by key within the namespace. See SkipList.Get.
+func (ns *Namespace) Get(key []byte) *Element {
+	return ns.list.Get(ns.key(key))
+}
+
+// Remove deletes an element by key within the namespace. See SkipList.Remove.
+func (ns *Namespace) Remove(key []byte) *Element {
+	return ns.list.Remove(ns.key(key))
+}
+
+// NewIterator returns an unpositioned iterator over the elements of the namespace.
+func (ns *Namespace) NewIterator() *Iterator {
+	return ns.list.newBoundedIterator(ns.prefix, prefixEnd(ns.prefix))
+}
+
+// Stats returns the statistics of the namespace.
+func (ns *Namespace) Stats() NamespaceStats {
+	ns.list.mutex.RLock()
+	defer ns.list.mutex.RUnlock()
+
+	return *ns.stats
+}
+
+func (ns *Namespace) key(key []byte) []byte {
+	full := make([]byte, 0, len(ns.prefix)+len(key))
+	return append(append(full, ns.prefix...), key...)
+}
+
+// search returns the index of the first namespace whose prefix does not sort before prefix.
+func (r *namespaceRegistry) search(prefix []byte) int {
+	return sort.Search(len(r.namespaces), func(i int) bool {
+		return bytes.Compare(r.namespaces[i].prefix, prefix) >= 0
+	})
+}
+
+func (r *namespaceRegistry) find(prefix []byte) *Namespace {
+	if i := r.search(prefix); i < len(r.namespaces) && bytes.Equal(r.namespaces[i].prefix, prefix) {
+		return r.namespaces[i]
+	}
+	return nil
+}
+
+func (r *namespaceRegistry) add(ns *Namespace) {
+	i := r.search(ns.prefix)
+	r.namespaces = append(r.namespaces, nil)
+	copy(r.namespaces[i+1:], r.namespaces[i:])
+	r.namespaces[i] = ns
+
+	j := sort.SearchInts(r.lengths, len(ns.prefix))
+	if j == len(r.lengths) || r.lengths[j] != len(ns.prefix) {
+		r.lengths = append(r.lengths, 0)
+		copy(r.lengths[j+1:], r.lengths[j:])
+		r.lengths[j] = len(ns.prefix)
+	}
+}
+
+func (r *namespaceRegistry) inserted(element *Element) {
+	r.apply(element.key, 1, int64(len(element.key))+r.list.sizeValue(element.Value()))
+}
+
+// updated accounts for replacing the value of element with value. Tombstones are not counted, so
+// writing the key of a tombstone counts as inserting it.
+func (r *namespaceRegistry) updated(element *Element, value interface{}) {
+	if element.IsTombstone() {
+		r.apply(element.key, 1, int64(len(element.key))+r.list.sizeValue(value))
+		return
+	}
+	r.apply(element.key, 0, r.list.growth(element, value))
+}
+
+func (r *namespaceRegistry) removed(element *Element) {
+	r.apply(element.key, -1, -int64(len(element.key))-r.list.sizeValue(element.Value()))
+}
+
+func (r *namespaceRegistry) apply(key []byte, count int, size int64) {
+	var now time.Time
+	for _, n := range r.lengths {
+		if n > len(key) {
+			break
+		}
+		ns := r.find(key[:n])
+		if ns == nil {
+			continue
+		}
+		if now.IsZero() {
+			now = time.Now()
+		}
+		ns.stats.Count += count
+		ns.stats.Bytes += size
+		ns.stats.LastWrite = now
+	}
+}
+
+// prefixEnd returns the smallest key that sorts after every key starting with prefix,
+// or nil if there is no such key.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// valueSize returns the size in bytes of values whose size is knowable without reflection.
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case []byte:
+		return len(v)
+	case string:
+		return len(v)
+	}
+	return 0
+}
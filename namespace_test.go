@@ -0,0 +1,113 @@
+package skiplist
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNamespaceStats(t *testing.T) {
+	list := New()
+	list.Set([]byte("a/1"), "x")
+	list.Set([]byte("b/1"), "y")
+
+	a := list.Namespace([]byte("a/"))
+	if stats := a.Stats(); stats.Count != 1 || stats.Bytes != 4 {
+		t.Fatal("namespace stats must include existing keys", stats)
+	}
+
+	a.Set([]byte("2"), "xyz")
+	a.Set([]byte("1"), "xx")
+	list.Set([]byte("b/2"), "z")
+
+	stats, ok := list.NamespaceStats([]byte("a/"))
+	if !ok || stats.Count != 2 || stats.Bytes != 3+2+3+3 || stats.LastWrite.IsZero() {
+		t.Fatal("wrong namespace stats after writes", stats)
+	}
+
+	a.Remove([]byte("1"))
+	if stats = a.Stats(); stats.Count != 1 || stats.Bytes != 6 {
+		t.Fatal("wrong namespace stats after removal", stats)
+	}
+
+	if _, ok := list.NamespaceStats([]byte("b/")); ok {
+		t.Fatal("stats must only be reported for opened namespaces")
+	}
+
+	if list.Namespace([]byte("a/")) != a {
+		t.Fatal("opening a namespace twice must return the same namespace")
+	}
+}
+
+func TestNamespaceStatsTombstones(t *testing.T) {
+	list := New(WithTombstones())
+	a := list.Namespace([]byte("a/"))
+
+	a.Set([]byte("1"), "x")
+	a.Remove([]byte("1"))
+	a.Remove([]byte("2"))
+	if stats := a.Stats(); stats.Count != 0 || stats.Bytes != 0 {
+		t.Fatal("namespaces must not count tombstones", stats)
+	}
+
+	a.Set([]byte("2"), "xy")
+	if stats := a.Stats(); stats.Count != 1 || stats.Bytes != 5 {
+		t.Fatal("writing a tombstone's key must count as an insert", stats)
+	}
+
+	list.Set([]byte("b/1"), "x")
+	list.Remove([]byte("b/1"))
+	if stats := list.Namespace([]byte("b/")).Stats(); stats.Count != 0 {
+		t.Fatal("opening a namespace must not count tombstones", stats)
+	}
+}
+
+func TestNamespaceIteration(t *testing.T) {
+	list := New()
+	for _, k := range []string{"a", "a/1", "a/2", "a0", "b/1"} {
+		list.Set([]byte(k), k)
+	}
+
+	ns := list.Namespace([]byte("a/"))
+	if e := ns.Get([]byte("2")); e == nil || e.Value() != "a/2" {
+		t.Fatal("wrong namespace Get result", e)
+	}
+
+	var keys []string
+	it := ns.NewIterator()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+
+	if len(keys) != 2 || keys[0] != "a/1" || keys[1] != "a/2" {
+		t.Fatal("wrong namespace keys", keys)
+	}
+
+	if !bytes.Equal(prefixEnd([]byte{'a', 0xff}), []byte{'b'}) || prefixEnd([]byte{0xff}) != nil {
+		t.Fatal("wrong prefix end")
+	}
+}
+
+func TestNamespaceStatsNested(t *testing.T) {
+	list := New(WithValueSizer(func(interface{}) int64 { return 10 }))
+	all := list.Namespace(nil)
+	a := list.Namespace([]byte("a/"))
+	ab := list.Namespace([]byte("a/b/"))
+	list.Namespace([]byte("c/"))
+
+	list.Set([]byte("a/b/1"), 1)
+	list.Set([]byte("a/1"), 2)
+	list.Set([]byte("b"), 3)
+	if stats := ab.Stats(); stats.Count != 1 || stats.Bytes != 5+10 {
+		t.Fatal("wrong nested namespace stats", stats)
+	}
+	if stats := a.Stats(); stats.Count != 2 || stats.Bytes != 5+10+3+10 {
+		t.Fatal("wrong namespace stats", stats)
+	}
+	stats, listStats := all.Stats(), list.Stats()
+	if stats.Count != 3 || stats.Bytes != listStats.KeyBytes+listStats.ValueBytes {
+		t.Fatal("namespace bytes must agree with the list's", stats, listStats)
+	}
+	if stats, _ := list.NamespaceStats([]byte("c/")); stats.Count != 0 {
+		t.Fatal("wrong stats for an empty namespace", stats)
+	}
+}
@@ -0,0 +1,410 @@
+package skiplist
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/m3db/fast-skiplist/internal/tower"
+)
+
+// Option configures a SkipList at construction time.
+type Option func(*SkipList)
+
+// WithMaxLevel sets the maximum height of the towers in the list. It must be in [1, 64],
+// or New panics, unless clamping is enabled WithMaxLevelClamping.
+func WithMaxLevel(maxLevel int) Option {
+	return func(list *SkipList) {
+		list.maxLevel = maxLevel
+		// An explicit maximum level overrides one derived by NewForExpectedSize.
+		list.expectedSize = 0
+	}
+}
+
+// WithMaxLevelClamping makes New clamp a maximum level outside of [1, 64] to the nearest valid
+// level instead of panicking, for services that build lists from user or configuration supplied
+// parameters. warn, if not nil, is called with the requested and clamped levels when clamping.
+func WithMaxLevelClamping(warn func(requested, clamped int)) Option {
+	return func(list *SkipList) {
+		list.clampMaxLevel = true
+		list.onMaxLevelClamped = warn
+	}
+}
+
+// WithRankIndex makes the list record how many elements each link spans, as in Pugh's indexed
+// skip list, so that Rank and GetByRank take logarithmic rather than linear time, for pagination
+// and percentile lookups. Maintaining the spans retraces the search of every insert and costs a
+// word per level of every tower.
+func WithRankIndex() Option {
+	return func(list *SkipList) {
+		list.rankIndex = true
+	}
+}
+
+// WithProbability sets the P value used to calculate the height of new elements.
+func WithProbability(probability float64) Option {
+	return func(list *SkipList) {
+		list.probability = probability
+	}
+}
+
+// WithSeededRand makes the list draw the heights of new towers from a PCG generator seeded with
+// seed, so that lists built by the same writes from the same seed have the same structure, as
+// for reproducing a benchmark or a bug. By default each list uses a fast xorshift generator with
+// a seed unique to the list, which is not reproducible.
+func WithSeededRand(seed uint64) Option {
+	return func(list *SkipList) {
+		list.newRand = func() rand.Source {
+			return tower.NewPCG(seed, 0)
+		}
+	}
+}
+
+// WithCryptoSeededRand makes the list draw the heights of new towers from a PCG generator seeded
+// from crypto/rand, so that they cannot be predicted by clients choosing keys to degrade the
+// list's balance.
+func WithCryptoSeededRand() Option {
+	return func(list *SkipList) {
+		list.newRand = func() rand.Source {
+			return tower.NewPCG(tower.CryptoSeed(), tower.CryptoSeed())
+		}
+	}
+}
+
+// WithComparator orders the keys of the list by compare, which returns a negative number when
+// a < b, zero when a == b and a positive number when a > b. It must define a total order that
+// never changes for the lifetime of the list. The default is bytes.Compare.
+//
+// Namespaces rely on keys sharing a prefix being adjacent, which only holds for byte-wise
+// order, so they are not available on lists with a comparator.
+func WithComparator(compare func(a, b []byte) int) Option {
+	return func(list *SkipList) {
+		list.compare = compare
+	}
+}
+
+// WithInsertVerification is a debugging aid that makes Set check, after linking a new element,
+// that it sorts strictly between its neighbours on every level. Any violation is passed to report,
+// without holding the list's lock, along with a dump of the element's tower. This catches a
+// comparator that is not a consistent total order at the insert that corrupts the list, rather
+// than when a later search goes astray. It adds a few comparisons to every insert.
+func WithInsertVerification(report func(OrderViolation)) Option {
+	return func(list *SkipList) {
+		list.onOrderViolation = report
+	}
+}
+
+// WithName attaches a name to the list. The name identifies the list in its String form,
+// in error and panic messages, and in any statistics or debug output derived from it.
+func WithName(name string) Option {
+	return func(list *SkipList) {
+		list.name = name
+	}
+}
+
+// WithLabels attaches a set of labels to the list, carried alongside its name.
+// The map is copied, so later changes by the caller do not affect the list.
+func WithLabels(labels map[string]string) Option {
+	return func(list *SkipList) {
+		list.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			list.labels[k] = v
+		}
+	}
+}
+
+// WithHotKeyTracking enables tracking of the k most frequently accessed keys, reported by Stats.
+// Accesses by Get and Set are counted in a count-min sketch, so counts are estimates.
+func WithHotKeyTracking(k int) Option {
+	return func(list *SkipList) {
+		if k > 0 {
+			list.hotKeyCount = k
+		}
+	}
+}
+
+// WithStatsSampling makes the list collect access statistics, such as hot keys, from a random one in
+// every n operations, scaling the sampled counts up by n. This keeps the cost of observability low
+// enough to leave it enabled in production, at the expense of precision for rarely accessed keys.
+// Counters that the list relies on itself, such as Length and Inserts, are always exact.
+// n <= 1 samples every operation, which is the default.
+func WithStatsSampling(n int) Option {
+	return func(list *SkipList) {
+		list.statsSampling = n
+	}
+}
+
+// WithAccessTracking makes the list record when each element was last accessed, which
+// Element.LastAccess and Stale report. Gets are recorded at the rate set WithStatsSampling, while
+// inserts and updates are always recorded. The list's maximum weight is then enforced by evicting
+// the least recently accessed elements rather than the smallest keys.
+func WithAccessTracking() Option {
+	return func(list *SkipList) {
+		list.trackAccess = true
+	}
+}
+
+// WithExpirySweeper starts a goroutine that removes the list's expired elements every interval,
+// as ExpireNow does, but locking the list for at most batchSize elements at a time to bound the
+// pauses of writers. Without it, expired elements are only reclaimed lazily. Call Close to stop
+// the sweeper once the list is no longer needed, or the goroutine keeps the list alive.
+func WithExpirySweeper(interval time.Duration, batchSize int) Option {
+	return func(list *SkipList) {
+		if interval > 0 {
+			if batchSize < 1 {
+				batchSize = 1
+			}
+			list.sweeper = &sweeper{interval: interval, batchSize: batchSize}
+		}
+	}
+}
+
+// WithRetryLimit bounds the compare-and-swaps a write to a ConcurrentSkipList retries before it
+// falls back to a lock. Past n retries, the write waits for the writes linking elements to finish
+// and links its own while holding the others off, so that it retries at most once more however
+// pathological the contention. Writes share the lock until one falls back, so only the writes
+// racing with a fallback wait; the others remain lock-free. n <= 0 retries without a limit, which
+// is the default.
+func WithRetryLimit(n int) Option {
+	return func(list *SkipList) {
+		list.retryLimit = n
+	}
+}
+
+// WithTombstones makes Remove leave a tombstone in place of the key it removes, rather than
+// unlinking its element, and write a tombstone for a key that is not in the list, as storage
+// engines need for deletes to shadow older data in the files a list is flushed to. Tombstones
+// are elements like any other, with a nil value, which lookups return and iterators visit, and
+// which IsTombstone tells apart. Setting a key brings its tombstone back to life. Removing a
+// range, evicting or flushing still unlinks elements, tombstones included.
+func WithTombstones() Option {
+	return func(list *SkipList) {
+		list.tombstoneMode = true
+	}
+}
+
+// WithVersions makes the list keep up to n past versions of each key, counting its removals,
+// for reads at earlier sequence numbers by GetAtSeq and snapshots. Versions stay until they are
+// pushed out by newer ones, or released by ReleaseVersions, which also forgets removed keys.
+// Clearing or rotating the list discards its versions, though a frozen list made by Rotate keeps
+// those of its contents.
+func WithVersions(n int) Option {
+	return func(list *SkipList) {
+		list.maxVersions = n
+	}
+}
+
+// WithLockWaitTracking makes the list measure how long writes wait to lock it, reported by
+// operation in Stats, to tell a slow list from a contended one when diagnosing latency. Waits
+// are sampled at the rate set WithStatsSampling.
+func WithLockWaitTracking() Option {
+	return func(list *SkipList) {
+		list.lockWaits = new(lockWaits)
+	}
+}
+
+// WithWatermark registers a watermark on the list. It may be given several times, to watch
+// several metrics or thresholds.
+func WithWatermark(w Watermark) Option {
+	return func(list *SkipList) {
+		list.watermarks = append(list.watermarks, &watermark{Watermark: w})
+	}
+}
+
+// WithMaxKeySize limits the length of keys accepted by the list. Writes of larger keys
+// are rejected: SetE reports ErrKeyTooLarge and Set returns nil.
+func WithMaxKeySize(size int) Option {
+	return func(list *SkipList) {
+		list.maxKeySize = size
+	}
+}
+
+// WithRemoveCallback registers fn to be called whenever an element leaves the list, along with
+// the reason it left. fn is called after the element is unlinked and without holding the list's
+// lock, so it may safely use the list. The element's Seq is the sequence number of its removal.
+// Each of WithRemoveCallback, WithOnRemove and WithOnEvict adds a callback, and the callbacks are
+// called in the order of their options.
+func WithRemoveCallback(fn func(element *Element, reason RemoveReason)) Option {
+	return func(list *SkipList) {
+		list.addRemoveCallback(func(r removal, reason RemoveReason) {
+			fn(r.element, reason)
+		})
+	}
+}
+
+// WithOnRemove registers fn to be called with the key and value of every entry that leaves the
+// list, whether removed, evicted, expired, flushed or cleared, so that resources tied to values
+// can be released. Entries moved out by Rotate are not reported, as they live on in the rotated
+// list. Removing a key of a list constructed WithTombstones reports the value the tombstone
+// replaced. fn is a remove callback (see WithRemoveCallback) that is only given key and value.
+func WithOnRemove(fn func(key []byte, value interface{})) Option {
+	return func(list *SkipList) {
+		list.addRemoveCallback(func(r removal, reason RemoveReason) {
+			if r.released(reason) {
+				fn(r.element.key, r.value)
+			}
+		})
+	}
+}
+
+// WithOnEvict registers fn to be called with the key and value of every entry the list drops by
+// itself, because it was evicted or expired. Like WithOnRemove, it adds a remove callback.
+func WithOnEvict(fn func(key []byte, value interface{})) Option {
+	return func(list *SkipList) {
+		list.addRemoveCallback(func(r removal, reason RemoveReason) {
+			if (reason == Evicted || reason == Expired) && r.released(reason) {
+				fn(r.element.key, r.value)
+			}
+		})
+	}
+}
+
+// WithInsertHook registers fn to be called with the key and value of every entry inserted into
+// the list, so that derived indexes and metrics can be kept in step with the list. fn is called
+// with the list locked, in the order of the writes, so it must be quick and must not use the list.
+func WithInsertHook(fn func(key []byte, value interface{})) Option {
+	return func(list *SkipList) {
+		list.onInsert = fn
+	}
+}
+
+// WithUpdateHook registers fn to be called with the key, old and new value of every entry whose
+// value is overwritten, whether by Set, Update, Merge, Apply or TransformValues. Like the insert
+// hook, fn is called with the list locked, so it must be quick and must not use the list.
+func WithUpdateHook(fn func(key []byte, old, new interface{})) Option {
+	return func(list *SkipList) {
+		list.onUpdate = fn
+	}
+}
+
+// WithWatchBuffer sets how many events each watch of the list queues before writes of the keys
+// it watches wait for it to catch up, or before it applies the DeliveryPolicy chosen for it. The
+// default is 1024.
+func WithWatchBuffer(n int) Option {
+	return func(list *SkipList) {
+		list.watchBuffer = n
+	}
+}
+
+// WithFrozenPolicy sets how the list handles writes once it is frozen. The default is FrozenReject.
+func WithFrozenPolicy(policy FrozenPolicy) Option {
+	return func(list *SkipList) {
+		list.frozenPolicy = policy
+	}
+}
+
+// WithOverflowList makes the list forward writes to overflow once it is frozen,
+// implying the FrozenForward policy.
+func WithOverflowList(overflow *SkipList) Option {
+	return func(list *SkipList) {
+		list.frozenPolicy = FrozenForward
+		list.overflow = overflow
+	}
+}
+
+// WithWeigher sets the function that assigns each element a weight, such as its size in bytes.
+// Without a weigher every element weighs nothing.
+func WithWeigher(weigher Weigher) Option {
+	return func(list *SkipList) {
+		list.weigher = weigher
+	}
+}
+
+// WithMaxWeight bounds the total weight of the list's elements. When a Set takes the total
+// over maxWeight, elements are evicted, starting with the smallest keys, until it fits again.
+// Pinned elements are never evicted.
+func WithMaxWeight(maxWeight int64) Option {
+	return func(list *SkipList) {
+		list.maxWeight = maxWeight
+	}
+}
+
+// WithValueSizer sets the function that reports the size in bytes of values, which
+// ApproxMemoryUsage and Stats account for. It is called with the list locked, so it must be quick
+// and must not use the list. Without a sizer, only byte slices and strings are sized, by length.
+func WithValueSizer(sizer func(value interface{}) int64) Option {
+	return func(list *SkipList) {
+		list.valueSizer = sizer
+	}
+}
+
+// WithMetrics makes the list report its operations to m.
+// WithMetrics makes the list report its operations to m. If m is a ListMetrics, the list reports
+// them to the Metrics m returns for the list's name and labels instead.
+func WithMetrics(m Metrics) Option {
+	return func(list *SkipList) {
+		list.metrics = m
+	}
+}
+
+// WithCapacity makes the list hold at most capacity elements, tombstones included. Unlike
+// WithMaxWeight, which evicts elements to make room, writes inserting a key into a full list are
+// rejected: SetE reports ErrFull and Set returns nil, so that a buffer admitting writes can push
+// back on its producers rather than drop data. Writes of keys already in the list, and removals,
+// always succeed.
+func WithCapacity(capacity int) Option {
+	return func(list *SkipList) {
+		list.capacity = capacity
+	}
+}
+
+// WithByteQuota makes the list reject writes that would take the bytes of its keys and values,
+// as reported by Stats' KeyBytes and ValueBytes, past quota: SetE and the other error-returning
+// writes report ErrQuotaExceeded, and Set returns nil. Writes that shrink the list, and
+// removals, always succeed. Values are sized by the function set WithValueSizer, if any.
+func WithByteQuota(quota int64) Option {
+	return func(list *SkipList) {
+		list.byteQuota = quota
+	}
+}
+
+// WithPinDebug records the call site and time of every Pin, so that PinLeaks can report
+// pins that were never released.
+func WithPinDebug() Option {
+	return func(list *SkipList) {
+		list.pinSites = make(map[*Element][]pinSite)
+	}
+}
+
+// WithArena allocates elements, their towers and copies of their keys from large chunks holding
+// about chunkSize elements each, instead of individually. This saves allocations on insert and
+// leaves the garbage collector far fewer objects to track, which suits memtable-style lists that
+// are filled and then discarded as a whole. Memory of removed elements is only reclaimed once
+// every element of their chunk is unreachable. chunkSize <= 0 uses DefaultArenaChunkSize.
+//
+// Keys are copied into the arena, so callers may reuse key buffers after Set.
+func WithArena(chunkSize int) Option {
+	return func(list *SkipList) {
+		list.arena = newArena(chunkSize)
+	}
+}
+
+// WithFingerSearch makes lookups, by Get, Seek, SeekForPrev and the seeks of iterators, start
+// from the search path of a recent lookup made on the same processor, rather than from the top
+// of the list, whenever that path still brackets the key. Workloads that look up nearby keys in
+// turn, such as scans by Get over a key range or readers each working through their own region
+// of the list, then skip most of the descent. Lookups of unrelated keys pay a few extra
+// comparisons.
+func WithFingerSearch() Option {
+	return func(list *SkipList) {
+		list.fingerSearch = true
+	}
+}
+
+// WithMergeOperator sets the function with which Merge combines operands with existing values.
+// It is called with the list locked, so it must be quick and must not use the list. It should
+// return a new value rather than modify the existing one in place, which concurrent readers may
+// be looking at.
+func WithMergeOperator(merge MergeFunc) Option {
+	return func(list *SkipList) {
+		list.merge = merge
+	}
+}
+
+// WithGhostList makes the list remember the keys and weights of the last n elements it evicted,
+// which Ghost and Ghosts report, so that caches built on the list can implement admission
+// policies that take recent evictions into account.
+func WithGhostList(n int) Option {
+	return func(list *SkipList) {
+		list.ghostCapacity = n
+	}
+}
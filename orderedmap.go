@@ -0,0 +1,83 @@
+package skiplist
+
+// OrderedMap is a map from []byte keys to V values that iterates in key order. It wraps a
+// SkipList behind map-like methods, for callers that want a sorted map without dealing with
+// elements or iterators. It is safe for concurrent use, with the guarantees of a SkipList.
+type OrderedMap[V any] struct {
+	list *SkipList
+}
+
+// NewOrderedMap creates an empty map, backed by a list configured by opts.
+func NewOrderedMap[V any](opts ...Option) *OrderedMap[V] {
+	return &OrderedMap[V]{list: New(opts...)}
+}
+
+// Put sets the value of key, adding key to the map if it is not present. The map keeps key, so
+// the caller must not modify it afterwards.
+func (m *OrderedMap[V]) Put(key []byte, value V) {
+	m.list.Set(key, value)
+}
+
+// Get returns the value of key, and whether key is in the map.
+func (m *OrderedMap[V]) Get(key []byte) (V, bool) {
+	if element := m.list.Get(key); element != nil {
+		return valueOf[V](element.Value()), true
+	}
+	var zero V
+	return zero, false
+}
+
+// Delete removes key from the map, returning its value and whether it was in the map.
+func (m *OrderedMap[V]) Delete(key []byte) (V, bool) {
+	if element := m.list.Remove(key); element != nil {
+		return valueOf[V](element.Value()), true
+	}
+	var zero V
+	return zero, false
+}
+
+// Len returns the number of keys in the map.
+func (m *OrderedMap[V]) Len() int {
+	return m.list.Len()
+}
+
+// Range calls fn for every key of the map and its value, in ascending key order, until fn
+// returns false. As with iterating a list, keys put or deleted during the walk may or may not
+// be visited.
+func (m *OrderedMap[V]) Range(fn func(key []byte, value V) bool) {
+	m.Ascend(nil, nil, fn)
+}
+
+// Ascend is Range restricted to the keys with start <= key < end, in ascending order. A nil
+// start or end leaves that side of the range open.
+func (m *OrderedMap[V]) Ascend(start, end []byte, fn func(key []byte, value V) bool) {
+	it := m.list.AcquireIterator()
+	defer it.Release()
+
+	it.lower, it.upper = start, end
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if !fn(it.Key(), valueOf[V](it.Value())) {
+			return
+		}
+	}
+}
+
+// Descend is Ascend in descending key order.
+func (m *OrderedMap[V]) Descend(start, end []byte, fn func(key []byte, value V) bool) {
+	it := m.list.AcquireIterator()
+	defer it.Release()
+
+	it.lower, it.upper = start, end
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		if !fn(it.Key(), valueOf[V](it.Value())) {
+			return
+		}
+	}
+}
+
+// valueOf returns value as a V. Only nil values, put under an interface type V, fail the
+// assertion, and those are V's zero value.
+func valueOf[V any](value interface{}) V {
+	v, _ := value.(V)
+	return v
+}
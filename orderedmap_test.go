@@ -0,0 +1,59 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+	m := NewOrderedMap[int]()
+	for i, k := range []string{"d", "b", "a", "c", "e"} {
+		m.Put([]byte(k), i)
+	}
+	m.Put([]byte("a"), 10)
+
+	if v, ok := m.Get([]byte("a")); v != 10 || !ok {
+		t.Fatal("wrong value", v, ok)
+	}
+	if v, ok := m.Get([]byte("z")); v != 0 || ok {
+		t.Fatal("missing keys must return the zero value", v, ok)
+	}
+	if v, ok := m.Delete([]byte("e")); v != 4 || !ok || m.Len() != 4 {
+		t.Fatal("wrong deletion", v, ok, m.Len())
+	}
+	if _, ok := m.Delete([]byte("e")); ok {
+		t.Fatal("deleting a missing key must report it")
+	}
+
+	var keys string
+	m.Range(func(key []byte, value int) bool {
+		keys += string(key)
+		return true
+	})
+	if keys != "abcd" {
+		t.Fatal("wrong range", keys)
+	}
+
+	keys = ""
+	m.Descend([]byte("b"), nil, func(key []byte, value int) bool {
+		keys += string(key)
+		return key[0] != 'c'
+	})
+	if keys != "dc" {
+		t.Fatal("wrong descending range", keys)
+	}
+
+	keys = ""
+	m.Ascend(nil, []byte("c"), func(key []byte, value int) bool {
+		keys += string(key)
+		return true
+	})
+	if keys != "ab" {
+		t.Fatal("wrong ascending range", keys)
+	}
+
+	errs := NewOrderedMap[error]()
+	errs.Put([]byte("nil"), nil)
+	if v, ok := errs.Get([]byte("nil")); v != nil || !ok {
+		t.Fatal("nil values must be returned as such", v, ok)
+	}
+}
@@ -0,0 +1,97 @@
+package skiplist
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// PinLeak describes a pin held for longer than expected.
+type PinLeak struct {
+	Key []byte
+	// Site is the file:line that called Pin.
+	Site string
+	// Since is when the pin was taken.
+	Since time.Time
+}
+
+type pinSite struct {
+	site  string
+	since time.Time
+}
+
+// Pin exempts the element with the given key from eviction and expiry removal until a matching
+// Unpin, for example while an in-flight query references it. An expired pinned element reads as
+// absent but stays linked. Pins are counted, so every Pin needs its own Unpin. Pinning does not
+// prevent explicit removal. Returns false if the key is not in the list.
+func (list *SkipList) Pin(key []byte) bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	element := list.find(key)
+	if element == nil {
+		return false
+	}
+
+	element.pins++
+	if list.pinSites != nil {
+		site := "unknown"
+		if _, file, line, ok := runtime.Caller(1); ok {
+			site = fmt.Sprintf("%s:%d", file, line)
+		}
+		list.pinSites[element] = append(list.pinSites[element], pinSite{site: site, since: time.Now()})
+	}
+	return true
+}
+
+// Unpin releases a pin taken by Pin. Returns false if the key is not in the list or not pinned.
+func (list *SkipList) Unpin(key []byte) bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	element := list.find(key)
+	if element == nil || element.pins == 0 {
+		return false
+	}
+
+	element.pins--
+	if list.pinSites != nil {
+		if sites := list.pinSites[element]; len(sites) > 1 {
+			list.pinSites[element] = sites[:len(sites)-1]
+		} else {
+			delete(list.pinSites, element)
+		}
+	}
+	return true
+}
+
+// Pins returns the number of outstanding pins on the element with the given key.
+func (list *SkipList) Pins(key []byte) int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	element := list.find(key)
+	if element == nil {
+		return 0
+	}
+	return element.pins
+}
+
+// PinLeaks reports every pin that has been held for longer than olderThan, which usually
+// means a missing Unpin. It requires the list to be constructed WithPinDebug and returns
+// nil otherwise.
+func (list *SkipList) PinLeaks(olderThan time.Duration) []PinLeak {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	var leaks []PinLeak
+	cutoff := time.Now().Add(-olderThan)
+	for element, sites := range list.pinSites {
+		for _, s := range sites {
+			if s.since.Before(cutoff) {
+				leaks = append(leaks, PinLeak{Key: element.key, Site: s.site, Since: s.since})
+			}
+		}
+	}
+	return leaks
+}
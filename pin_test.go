@@ -0,0 +1,98 @@
+package skiplist
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinnedElementsAreNotEvicted(t *testing.T) {
+	list := New(
+		WithWeigher(func(key []byte, value interface{}) int64 { return 1 }),
+		WithMaxWeight(2),
+	)
+
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 2)
+	if !list.Pin([]byte("a")) || !list.Pin([]byte("a")) {
+		t.Fatal("failed to pin an existing key")
+	}
+	if list.Pin([]byte("missing")) {
+		t.Fatal("pinning a missing key must fail")
+	}
+
+	list.Set([]byte("c"), 3)
+	checkSanity(list, t)
+
+	if list.Get([]byte("a")) == nil || list.Get([]byte("b")) != nil || list.Pins([]byte("a")) != 2 {
+		t.Fatal("eviction must skip pinned elements")
+	}
+
+	list.Unpin([]byte("a"))
+	list.Set([]byte("d"), 4)
+	if list.Get([]byte("a")) == nil {
+		t.Fatal("an element must stay pinned until every pin is released")
+	}
+
+	list.Unpin([]byte("a"))
+	if list.Unpin([]byte("a")) {
+		t.Fatal("unpinning an unpinned element must fail")
+	}
+
+	list.Set([]byte("e"), 5)
+	if list.Get([]byte("a")) != nil {
+		t.Fatal("unpinned elements must be evictable")
+	}
+}
+
+func TestPinLeaks(t *testing.T) {
+	list := New(WithPinDebug())
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 2)
+
+	list.Pin([]byte("a"))
+	list.Pin([]byte("b"))
+	list.Unpin([]byte("b"))
+
+	if leaks := list.PinLeaks(time.Hour); len(leaks) != 0 {
+		t.Fatal("recent pins must not be reported", leaks)
+	}
+
+	leaks := list.PinLeaks(0)
+	if len(leaks) != 1 || string(leaks[0].Key) != "a" || !strings.Contains(leaks[0].Site, "pin_test.go") {
+		t.Fatal("wrong pin leaks", leaks)
+	}
+
+	list.Remove([]byte("a"))
+	if leaks := list.PinLeaks(0); len(leaks) != 0 {
+		t.Fatal("removed elements must not be reported", leaks)
+	}
+
+	if New().PinLeaks(0) != nil {
+		t.Fatal("pin leaks must only be tracked in debug mode")
+	}
+}
+
+func TestPinnedElementsAreNotExpired(t *testing.T) {
+	list := New()
+	list.SetWithTTL([]byte("a"), 1, time.Millisecond)
+	if !list.Pin([]byte("a")) {
+		t.Fatal("failed to pin an existing key")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if list.Get([]byte("a")) != nil {
+		t.Fatal("an expired element must read as absent")
+	}
+	list.sweep(0)
+	if list.Len() != 1 || list.Pins([]byte("a")) != 1 {
+		t.Fatal("expiry must skip pinned elements", list.Len())
+	}
+
+	list.Unpin([]byte("a"))
+	list.sweep(0)
+	if list.Len() != 0 {
+		t.Fatal("an unpinned expired element must be removed", list.Len())
+	}
+	checkSanity(list, t)
+}
@@ -0,0 +1,135 @@
+package skiplist
+
+import (
+	"errors"
+)
+
+// FrozenRange is a key range of a list made read-only by FreezeRange, to be flushed while the
+// rest of the list stays writable.
+type FrozenRange struct {
+	list       *SkipList
+	start, end []byte
+	done       bool
+}
+
+// FreezeRange makes the keys in [start, end) read-only, so that the range can be flushed, as a
+// cold part of a large list, while the rest of the list takes writes. A nil end leaves the range
+// unbounded above. The caller persists the range, read through Iterator, then calls
+// CompleteFlush to remove it from the list, or Thaw to make it writable again.
+//
+// Until then, writes and removals of keys in the range fail with ErrRangeFrozen, as do Clear,
+// ClearIncremental, Rotate and TransformValues, which rewrite the whole list. Eviction, expiry and
+// the list's CompleteFlush leave the elements of the range alone. Freezing a range that overlaps
+// another frozen range fails with ErrRangeFrozen, and one that overlaps a reservation with
+// ErrRangeReserved.
+func (list *SkipList) FreezeRange(start, end []byte) (*FrozenRange, error) {
+	if end != nil && list.compare(start, end) >= 0 {
+		return nil, list.newError("FreezeRange", start, errors.New("empty range"))
+	}
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if list.overlapsFrozen(start, end) {
+		return nil, list.newError("FreezeRange", start, ErrRangeFrozen)
+	}
+	for _, r := range list.reservations {
+		if (end == nil || list.compare(r.start, end) < 0) && (r.end == nil || list.compare(start, r.end) < 0) {
+			return nil, list.newError("FreezeRange", start, ErrRangeReserved)
+		}
+	}
+
+	r := &FrozenRange{list: list, start: start, end: end}
+	list.frozenRanges = append(list.frozenRanges, r)
+	return r, nil
+}
+
+// Iterator returns an iterator over the range, positioned at its first element.
+func (r *FrozenRange) Iterator() *Iterator {
+	return r.list.Range(r.start, r.end)
+}
+
+// CompleteFlush removes every element of the range from the list, returning how many were
+// removed, and makes the range writable again. Removed elements are reported to the remove
+// callback with the Flushed reason. It does nothing if the range was already flushed or thawed.
+func (r *FrozenRange) CompleteFlush() (int, error) {
+	removed, err := r.list.flushRange(r)
+	if err != nil {
+		return 0, r.list.newError("CompleteFlush", r.start, err)
+	}
+
+	for _, element := range removed {
+		r.list.notifyRemove(element, Flushed)
+	}
+	return len(removed), nil
+}
+
+// Thaw makes the range writable again, leaving its elements in the list. It does nothing if the
+// range was already flushed or thawed.
+func (r *FrozenRange) Thaw() {
+	r.list.mutex.Lock()
+	defer r.list.mutex.Unlock()
+
+	r.list.thaw(r)
+}
+
+func (list *SkipList) flushRange(r *FrozenRange) ([]*Element, error) {
+	list.lock(lockFlush)
+	defer list.unlock()
+
+	if r.done {
+		return nil, nil
+	}
+	if list.frozen {
+		return nil, ErrReadOnly
+	}
+
+	list.thaw(r)
+	prevs := list.getPrevElementNodes(r.start)
+	var removed []*Element
+	for element := prevs[0].Next(); element != nil && (r.end == nil || list.compare(element.key, r.end) < 0); {
+		next := element.Next()
+		list.unlink(prevs, element)
+		removed = append(removed, element)
+		element = next
+	}
+	return removed, nil
+}
+
+// thaw removes r from the list's frozen ranges. The caller must hold the list mutex.
+func (list *SkipList) thaw(r *FrozenRange) {
+	if r.done {
+		return
+	}
+	r.done = true
+	for i, other := range list.frozenRanges {
+		if other == r {
+			list.frozenRanges = append(list.frozenRanges[:i], list.frozenRanges[i+1:]...)
+			break
+		}
+	}
+	if len(list.frozenRanges) == 0 {
+		list.frozenRanges = nil
+	}
+}
+
+// rangeFrozen reports whether key is in a frozen range. The caller must hold the list mutex.
+func (list *SkipList) rangeFrozen(key []byte) bool {
+	for _, r := range list.frozenRanges {
+		if list.compare(key, r.start) >= 0 && (r.end == nil || list.compare(key, r.end) < 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsFrozen reports whether [start, end) overlaps a frozen range. The caller must hold the
+// list mutex.
+func (list *SkipList) overlapsFrozen(start, end []byte) bool {
+	for _, r := range list.frozenRanges {
+		if (end == nil || list.compare(r.start, end) < 0) && (r.end == nil || list.compare(start, r.end) < 0) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package skiplist
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFreezeRange(t *testing.T) {
+	var flushed []uint64
+	list := New(WithRemoveCallback(func(element *Element, reason RemoveReason) {
+		if reason == Flushed {
+			flushed = append(flushed, element.Value().(uint64))
+		}
+	}))
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	r, err := list.FreezeRange(orderedKey(10), orderedKey(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.FreezeRange(orderedKey(15), orderedKey(30)); !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("overlapping freeze not rejected", err)
+	}
+	if _, err := list.ReserveRange(orderedKey(0), orderedKey(11)); !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("overlapping reservation not rejected", err)
+	}
+
+	if _, err := list.SetE(orderedKey(15), "new"); !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("write in frozen range not rejected", err)
+	}
+	if _, err := list.RemoveE(orderedKey(10)); !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("removal in frozen range not rejected", err)
+	}
+	if n, err := list.RemoveRangeE(orderedKey(0), orderedKey(11)); n != 0 || !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("removal of an overlapping range not rejected", n, err)
+	}
+	if n, err := list.RemoveRangeContext(context.Background(), orderedKey(19), nil, 10); n != 0 || !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("incremental removal of an overlapping range not rejected", n, err)
+	}
+	if err := list.ClearE(); !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("clear with frozen range not rejected", err)
+	}
+	// The rest of the list stays writable.
+	if _, err := list.SetE(orderedKey(20), "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.RemoveE(orderedKey(9)); err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for it := r.Iterator(); it.Valid(); it.Next() {
+		n++
+	}
+	if n != 10 {
+		t.Fatal("wrong number of elements in frozen range", n)
+	}
+
+	if n, err := r.CompleteFlush(); err != nil || n != 10 {
+		t.Fatal("wrong flush result", n, err)
+	}
+	checkSanity(list, t)
+	if list.Len() != 89 || len(flushed) != 10 || flushed[0] != 10 {
+		t.Fatal("wrong state after flush", list.Len(), flushed)
+	}
+	if n, _ := r.CompleteFlush(); n != 0 {
+		t.Fatal("second flush removed elements", n)
+	}
+	if _, err := list.SetE(orderedKey(15), "new"); err != nil {
+		t.Fatal("write after flush rejected", err)
+	}
+
+	r, err = list.FreezeRange(orderedKey(50), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.SetE(orderedKey(1000), "new"); !errors.Is(err, ErrRangeFrozen) {
+		t.Fatal("write in unbounded frozen range not rejected", err)
+	}
+	r.Thaw()
+	if _, err := list.SetE(orderedKey(1000), "new"); err != nil {
+		t.Fatal("write after thaw rejected", err)
+	}
+	if list.Len() != 91 {
+		t.Fatal("thaw changed the list", list.Len())
+	}
+}
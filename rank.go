@@ -0,0 +1,140 @@
+package skiplist
+
+// Rank returns the number of elements whose keys sort before key, which is the zero-based
+// position of key in the list, and whether key is in the list. On a list constructed
+// WithRankIndex it takes logarithmic time; otherwise it counts the elements one by one.
+func (list *SkipList) Rank(key []byte) (int, bool) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.rank(key)
+}
+
+// CountRange returns the number of elements with start <= key < end, to estimate the selectivity
+// of a range without iterating it. A nil start or end leaves that side of the range open. On a
+// list constructed WithRankIndex it takes logarithmic time; otherwise it counts the elements one
+// by one.
+func (list *SkipList) CountRange(start, end []byte) int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if end != nil && start != nil && list.compare(start, end) >= 0 {
+		return 0
+	}
+
+	if list.spans == nil {
+		element := list.Front()
+		if start != nil {
+			element = list.searchGreaterOrEqual(start)
+		}
+		n := 0
+		for ; element != nil && (end == nil || list.compare(element.key, end) < 0); element = element.Next() {
+			n++
+		}
+		return n
+	}
+
+	first, last := 0, list.Length
+	if start != nil {
+		first, _ = list.rank(start)
+	}
+	if end != nil {
+		last, _ = list.rank(end)
+	}
+	return last - first
+}
+
+// rank is Rank for a caller that holds the list mutex.
+func (list *SkipList) rank(key []byte) (int, bool) {
+	node, rank := &list.elementNode, 0
+	if list.spans == nil {
+		for next := node.Next(); next != nil && list.compare(next.key, key) < 0; next = next.Next() {
+			node = &next.elementNode
+			rank++
+		}
+	} else {
+		for i := list.maxLevel - 1; i >= 0; i-- {
+			for next := node.NextAt(i); next != nil && list.compare(next.key, key) < 0; next = node.NextAt(i) {
+				rank += node.spans[i]
+				node = &next.elementNode
+			}
+		}
+	}
+
+	next := node.Next()
+	return rank, next != nil && list.compare(next.key, key) == 0
+}
+
+// GetByRank returns the element at zero-based position i in key order, or nil if i is out of
+// range, so that a page of a list or a percentile can be found without iterating from the front.
+// On a list constructed WithRankIndex it takes logarithmic time; otherwise it walks i elements.
+func (list *SkipList) GetByRank(i int) *Element {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if i < 0 || i >= list.Length {
+		return nil
+	}
+
+	if list.spans == nil {
+		element := list.Front()
+		for ; i > 0; i-- {
+			element = element.Next()
+		}
+		return element
+	}
+
+	// Ranks count from 1 so that the head is at rank 0.
+	node, rank := &list.elementNode, 0
+	for level := list.maxLevel - 1; level >= 0; level-- {
+		for next := node.NextAt(level); next != nil && rank+node.spans[level] <= i+1; next = node.NextAt(level) {
+			rank += node.spans[level]
+			node = &next.elementNode
+		}
+		if rank == i+1 {
+			break
+		}
+	}
+	return list.elementOf(node)
+}
+
+// linkSpans updates the spans of the links around element, which is about to be linked after
+// prevs. The caller must hold the list mutex.
+//
+// A node's span on a level is the number of elements from it to its successor on that level, or
+// to the end of the list if it has none. Linking element needs the rank of each of prevs, which
+// is found by retracing the search that found them: each is reached from the one above it along
+// its level.
+func (list *SkipList) linkSpans(prevs []*elementNode, element *Element) {
+	ranks := list.rankCache
+	node, rank := &list.elementNode, 0
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		for node != prevs[i] {
+			rank += node.spans[i]
+			node = &node.NextAt(i).elementNode
+		}
+		ranks[i] = rank
+	}
+
+	element.spans = make([]int, len(element.next))
+	for i := range prevs {
+		if i < len(element.next) {
+			element.spans[i] = prevs[i].spans[i] - (ranks[0] - ranks[i])
+			prevs[i].spans[i] = ranks[0] - ranks[i] + 1
+		} else {
+			prevs[i].spans[i]++
+		}
+	}
+}
+
+// unlinkSpans updates the spans of the links around element, which is about to be unlinked
+// given its previous nodes prevs. The caller must hold the list mutex.
+func (list *SkipList) unlinkSpans(prevs []*elementNode, element *Element) {
+	for i := range prevs {
+		if i < len(element.next) {
+			prevs[i].spans[i] += element.spans[i] - 1
+		} else {
+			prevs[i].spans[i]--
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package skiplist
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// checkSpans checks that the span of every link of a list with a rank index counts the elements
+// up to the link's successor, or to the end of the list.
+func checkSpans(list *SkipList, t *testing.T) {
+	t.Helper()
+
+	ranks := map[*elementNode]int{&list.elementNode: 0}
+	rank := 0
+	for e := list.Front(); e != nil; e = e.Next() {
+		rank++
+		ranks[&e.elementNode] = rank
+	}
+
+	check := func(node *elementNode) {
+		for i := range node.next {
+			want := list.Length - ranks[node]
+			if next := node.NextAt(i); next != nil {
+				want = ranks[&next.elementNode] - ranks[node]
+			}
+			if node.spans[i] != want {
+				t.Fatalf("span of rank %d on level %d is %d, want %d", ranks[node], i, node.spans[i], want)
+			}
+		}
+	}
+	check(&list.elementNode)
+	for e := list.Front(); e != nil; e = e.Next() {
+		check(&e.elementNode)
+	}
+}
+
+func TestRank(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	indexed := New(WithRankIndex())
+	plain := New()
+	keys := map[uint64]bool{}
+
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 100; i++ {
+			k := uint64(rng.Intn(1000))
+			if rng.Intn(3) == 0 {
+				indexed.Remove(orderedKey(k))
+				plain.Remove(orderedKey(k))
+				delete(keys, k)
+			} else {
+				indexed.Set(orderedKey(k), k)
+				plain.Set(orderedKey(k), k)
+				keys[k] = true
+			}
+		}
+
+		var batch WriteBatch
+		for i := 0; i < 20; i++ {
+			batch.Set(orderedKey(uint64(1000+round*20+i)), nil)
+		}
+		batch.Remove(orderedKey(uint64(rng.Intn(1000))))
+		indexed.Apply(&batch)
+		plain.Apply(&batch)
+
+		start := uint64(rng.Intn(1000))
+		indexed.RemoveRange(orderedKey(start), orderedKey(start+20))
+		plain.RemoveRange(orderedKey(start), orderedKey(start+20))
+
+		checkSanity(indexed, t)
+		checkSpans(indexed, t)
+	}
+
+	var sorted [][]byte
+	for e := plain.Front(); e != nil; e = e.Next() {
+		sorted = append(sorted, e.Key())
+	}
+	for _, list := range []*SkipList{indexed, plain} {
+		for i, key := range sorted {
+			if e := list.GetByRank(i); e == nil || !bytes.Equal(e.Key(), key) {
+				t.Fatal("wrong element at rank", i, e)
+			}
+			if rank, ok := list.Rank(key); rank != i || !ok {
+				t.Fatal("wrong rank of", key, rank, ok)
+			}
+		}
+		if list.GetByRank(-1) != nil || list.GetByRank(len(sorted)) != nil {
+			t.Fatal("ranks out of range must return nil")
+		}
+
+		// Absent keys rank at the position they would be inserted at.
+		for k := uint64(0); k < 1000; k += 7 {
+			key := append(orderedKey(k), 0)
+			want := sort.Search(len(sorted), func(i int) bool { return bytes.Compare(sorted[i], key) >= 0 })
+			if rank, ok := list.Rank(key); rank != want || ok {
+				t.Fatal("wrong rank of an absent key", key, rank, want, ok)
+			}
+		}
+	}
+
+	// Appends link at the tails of the list without searching.
+	var i uint64
+	appended, _ := NewFromSorted(func() ([]byte, interface{}, bool) {
+		i++
+		return orderedKey(i), nil, i <= 500
+	}, WithRankIndex())
+	for ; i < 1000; i++ {
+		appended.Set(orderedKey(i), nil)
+	}
+	checkSpans(appended, t)
+
+	frozen, _ := indexed.Rotate()
+	checkSpans(frozen, t)
+	checkSpans(indexed, t)
+	indexed.Set([]byte("a"), nil)
+	if e := indexed.GetByRank(0); e == nil || string(e.Key()) != "a" || frozen.GetByRank(len(sorted)-1) == nil {
+		t.Fatal("rotation must carry the rank index over", e)
+	}
+}
+
+func TestCountRange(t *testing.T) {
+	indexed := New(WithRankIndex())
+	plain := New()
+	for i := uint64(0); i < 500; i += 2 {
+		indexed.Set(orderedKey(i), i)
+		plain.Set(orderedKey(i), i)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		lo, hi := uint64(rng.Intn(600)), uint64(rng.Intn(600))
+		start, end := orderedKey(lo), orderedKey(hi)
+		switch n % 10 {
+		case 0:
+			start = nil
+		case 1:
+			end = nil
+		}
+
+		want := 0
+		for i := uint64(0); i < 500; i += 2 {
+			if (start == nil || i >= lo) && (end == nil || i < hi) {
+				want++
+			}
+		}
+		for _, list := range []*SkipList{indexed, plain} {
+			if got := list.CountRange(start, end); got != want {
+				t.Fatal("wrong count of range", lo, hi, n%10, got, want)
+			}
+		}
+	}
+
+	if indexed.CountRange(nil, nil) != 250 || plain.CountRange(nil, nil) != 250 {
+		t.Fatal("an open range must count the whole list")
+	}
+}
@@ -0,0 +1,477 @@
+package skiplist
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// Codec converts element values to and from bytes for serialization.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// BytesCodec is a Codec for lists whose values are all []byte.
+type BytesCodec struct{}
+
+// Encode returns value, which must be a []byte.
+func (BytesCodec) Encode(value interface{}) ([]byte, error) {
+	b, ok := value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("BytesCodec cannot encode value of type %T", value)
+	}
+	return b, nil
+}
+
+// Decode returns data as the value.
+func (BytesCodec) Decode(data []byte) (interface{}, error) {
+	return data, nil
+}
+
+// RecordReader is an io.Reader over the elements of a list, encoded as a sequence of records.
+// Each record is the uvarint length of the key, the key, the uvarint length of the encoded
+// value and the encoded value, optionally followed by a checksum.
+type RecordReader struct {
+	// Checksums appends to each record the little-endian CRC-32C of the record's bytes, which
+	// LoadRecords verifies when its Checksums option is set. It must be set before reading.
+	Checksums bool
+
+	list    *SkipList
+	codec   Codec
+	next    *Element
+	started bool
+	buf     []byte
+	err     error
+}
+
+// NewRecordReader returns a reader of the elements of list in key order, with values encoded
+// by codec. Elements are read as the reader advances, so no copy of the list is buffered;
+// concurrent writes may or may not be observed, as with any iteration.
+func NewRecordReader(list *SkipList, codec Codec) *RecordReader {
+	return &RecordReader{list: list, codec: codec}
+}
+
+// Read implements io.Reader.
+func (r *RecordReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 && !r.fill() {
+			break
+		}
+		copied := copy(p[n:], r.buf)
+		r.buf = r.buf[copied:]
+		n += copied
+	}
+
+	if n == 0 {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+// fill encodes the next element into the buffer. It returns false once there are no more
+// elements or encoding failed, with the reason recorded in r.err.
+func (r *RecordReader) fill() bool {
+	if r.err != nil {
+		return false
+	}
+
+	if !r.started {
+		r.next = r.list.Front()
+		r.started = true
+	}
+
+	if r.next == nil {
+		r.err = io.EOF
+		return false
+	}
+
+	value, err := r.codec.Encode(r.next.Value())
+	if err != nil {
+		r.err = fmt.Errorf("encoding value of key %s: %v", quoteKey(r.next.key), err)
+		return false
+	}
+
+	r.buf = appendRecord(r.buf[:0], r.next.key, value)
+	if r.Checksums {
+		r.buf = binary.LittleEndian.AppendUint32(r.buf, crc32.Checksum(r.buf, castagnoli))
+	}
+	r.next = r.next.Next()
+	return true
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func appendRecord(buf, key, value []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+// LoadOptions configure LoadRecords.
+type LoadOptions struct {
+	// Unsorted accepts records in any key order. Records are then sorted in chunks of
+	// ChunkSize before insertion; otherwise out-of-order records are an error.
+	Unsorted bool
+	// ChunkSize is the number of records sorted at a time when Unsorted is set.
+	// Defaults to DefaultLoadChunkSize.
+	ChunkSize int
+	// Progress, if set, is called with the number of records loaded so far after every
+	// ProgressInterval records, and once more when loading completes.
+	Progress func(records int)
+	// ProgressInterval defaults to DefaultLoadProgressInterval.
+	ProgressInterval int
+	// Duplicates decides what happens when several records share a key.
+	// The default, DuplicateError, fails the load.
+	Duplicates DuplicatePolicy
+	// Merge combines the values of records sharing a key under DuplicateMerge.
+	Merge MergeFunc
+	// Checksums expects every record to end with a checksum, as written by a RecordReader with
+	// Checksums set, and fails the load at the first record that does not match it.
+	Checksums bool
+	// MaxFieldSize bounds the length of keys and values. A larger length fails the load before
+	// anything is allocated for it, which is usually a sign of a corrupt length prefix.
+	// Defaults to DefaultLoadMaxFieldSize.
+	MaxFieldSize int
+	// ListOptions configure the list being built.
+	ListOptions []Option
+
+	// checkKey, if set, validates each key further, as LoadMultiMap does its stamps.
+	checkKey func(key []byte) error
+}
+
+const (
+	DefaultLoadChunkSize        = 4096
+	DefaultLoadProgressInterval = 10000
+	DefaultLoadMaxFieldSize     = 1 << 30
+)
+
+// RecordError describes a record that failed to load. Loading stops at the first such record.
+type RecordError struct {
+	// Index is the position of the record in the input, starting at 0.
+	Index int
+	// Offset is the byte offset in the input at which the record starts.
+	Offset int64
+	// Err describes what is wrong with the record.
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d at offset %d: %v", e.Index, e.Offset, e.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrChecksum is the cause of a RecordError for a record whose checksum does not match.
+	ErrChecksum = errors.New("checksum mismatch")
+	// ErrFieldTooLarge is the cause of a RecordError for a key or value longer than allowed.
+	ErrFieldTooLarge = errors.New("field too large")
+)
+
+// DuplicatePolicy decides how bulk loads treat records whose key was already loaded.
+type DuplicatePolicy int
+
+const (
+	// DuplicateError fails the load at the first duplicate key.
+	DuplicateError DuplicatePolicy = iota
+	// DuplicateKeepFirst keeps the value of the first record with a key.
+	DuplicateKeepFirst
+	// DuplicateKeepLast keeps the value of the last record with a key.
+	DuplicateKeepLast
+	// DuplicateMerge combines the values of records sharing a key with a MergeFunc.
+	DuplicateMerge
+)
+
+// MergeFunc combines the existing value of key with an incoming one, returning the value to keep.
+type MergeFunc func(key []byte, existing, incoming interface{}) interface{}
+
+// LoadRecords builds a list from records in the format produced by RecordReader, decoding
+// values with codec. Unless opts.Unsorted is set, keys must be increasing and the load fails
+// at the first record that is not. Repeated keys are resolved according to opts.Duplicates.
+// Sorted records are linked in a single pass without searching, as by NewFromSorted, so the
+// list is built in time linear in their number; unsorted ones are inserted one by one.
+//
+// Records are validated as they are read, so a corrupt input fails the load at the first bad
+// record, with a *RecordError giving its index and byte offset, rather than producing a list
+// that misbehaves later.
+func LoadRecords(r io.Reader, codec Codec, opts LoadOptions) (*SkipList, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultLoadChunkSize
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = DefaultLoadProgressInterval
+	}
+	if opts.MaxFieldSize <= 0 {
+		opts.MaxFieldSize = DefaultLoadMaxFieldSize
+	}
+	if opts.Duplicates == DuplicateMerge && opts.Merge == nil {
+		return nil, errors.New("DuplicateMerge requires a Merge function")
+	}
+
+	list := New(opts.ListOptions...)
+	scanner := &recordScanner{
+		r:            bufio.NewReader(r),
+		checksums:    opts.Checksums,
+		maxFieldSize: opts.MaxFieldSize,
+	}
+
+	count := 0
+	read := func() (loadedRecord, error) {
+		offset := scanner.offset
+		fail := func(err error) (loadedRecord, error) {
+			return loadedRecord{}, &RecordError{Index: count, Offset: offset, Err: err}
+		}
+
+		key, data, err := scanner.next()
+		if err == io.EOF {
+			return loadedRecord{}, err
+		}
+		if err != nil {
+			return fail(err)
+		}
+
+		if err := list.checkKey("Load", key); err != nil {
+			return fail(err)
+		}
+		if opts.checkKey != nil {
+			if err := opts.checkKey(key); err != nil {
+				return fail(err)
+			}
+		}
+
+		value, err := codec.Decode(data)
+		if err != nil {
+			return fail(fmt.Errorf("decoding value of key %s: %v", quoteKey(key), err))
+		}
+
+		rec := loadedRecord{index: count, offset: offset, key: key, value: value}
+		count++
+		if opts.Progress != nil && count%opts.ProgressInterval == 0 {
+			opts.Progress(count)
+		}
+		return rec, nil
+	}
+
+	var err error
+	if opts.Unsorted {
+		err = opts.loadUnsorted(list, read)
+	} else {
+		err = opts.loadSorted(list, read)
+	}
+	if err != nil {
+		list.Close()
+		return nil, err
+	}
+	if opts.Progress != nil {
+		opts.Progress(count)
+	}
+	return list, nil
+}
+
+type loadedRecord struct {
+	index  int
+	offset int64
+	key    []byte
+	value  interface{}
+}
+
+// loadSorted builds list from records in increasing key order, linking each after the last
+// without searching, as NewFromSorted does. Records sharing a key are adjacent, so they are
+// resolved into one before it is linked.
+func (opts *LoadOptions) loadSorted(list *SkipList, read func() (loadedRecord, error)) error {
+	pending, err := read()
+	next := func() ([]byte, interface{}, bool) {
+		for err == nil {
+			rec, readErr := read()
+			if readErr == io.EOF {
+				err = readErr
+				return pending.key, pending.value, true
+			}
+			if readErr != nil {
+				err = readErr
+				return nil, nil, false
+			}
+
+			switch c := list.compare(rec.key, pending.key); {
+			case c < 0:
+				err = &RecordError{Index: rec.index, Offset: rec.offset,
+					Err: fmt.Errorf("key %s is less than the previous key %s", quoteKey(rec.key), quoteKey(pending.key))}
+			case c > 0:
+				key, value := pending.key, pending.value
+				pending = rec
+				return key, value, true
+			default:
+				pending.value, err = opts.resolve(pending.value, rec)
+			}
+		}
+		return nil, nil, false
+	}
+
+	violations, buildErr := list.appendSorted(context.Background(), next)
+	if buildErr != nil {
+		return buildErr
+	}
+	if err != io.EOF {
+		return err
+	}
+
+	for _, violation := range violations {
+		list.onOrderViolation(violation)
+	}
+	list.enforceMaxWeight()
+	return nil
+}
+
+// loadUnsorted sorts records in chunks and inserts them into list one by one.
+func (opts *LoadOptions) loadUnsorted(list *SkipList, read func() (loadedRecord, error)) error {
+	var chunk []loadedRecord
+	flush := func() error {
+		sort.SliceStable(chunk, func(i, j int) bool {
+			return list.compare(chunk[i].key, chunk[j].key) < 0
+		})
+		for _, rec := range chunk {
+			if err := opts.insert(list, rec); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		rec, err := read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		chunk = append(chunk, rec)
+		if len(chunk) == opts.ChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// insert adds a loaded record to list, resolving duplicate keys according to the options.
+func (opts *LoadOptions) insert(list *SkipList, rec loadedRecord) error {
+	if opts.Duplicates != DuplicateKeepLast {
+		if existing := list.Get(rec.key); existing != nil {
+			if opts.Duplicates == DuplicateKeepFirst {
+				return nil
+			}
+			value, err := opts.resolve(existing.Value(), rec)
+			if err != nil {
+				return err
+			}
+			rec.value = value
+		}
+	}
+
+	list.Set(rec.key, rec.value)
+	return nil
+}
+
+// resolve returns the value to keep for a record whose key was already loaded with value
+// existing, according to the options.
+func (opts *LoadOptions) resolve(existing interface{}, rec loadedRecord) (interface{}, error) {
+	switch opts.Duplicates {
+	case DuplicateError:
+		return nil, &RecordError{Index: rec.index, Offset: rec.offset,
+			Err: fmt.Errorf("duplicate key %s", quoteKey(rec.key))}
+	case DuplicateKeepFirst:
+		return existing, nil
+	case DuplicateMerge:
+		return opts.Merge(rec.key, existing, rec.value), nil
+	}
+	return rec.value, nil
+}
+
+// recordScanner reads records, keeping track of the offset of the next one and verifying
+// field sizes and checksums as it goes.
+type recordScanner struct {
+	r            *bufio.Reader
+	offset       int64
+	checksums    bool
+	maxFieldSize int
+	// crc is the checksum of the current record's bytes so far.
+	crc uint32
+}
+
+// next reads a record. It returns io.EOF only if the input is exhausted at a record boundary.
+func (s *recordScanner) next() (key, value []byte, err error) {
+	s.crc = 0
+	if key, err = s.readField(); err != nil {
+		return nil, nil, err
+	}
+	if value, err = s.readField(); err != nil {
+		return nil, nil, unexpectedEOF(err)
+	}
+
+	if s.checksums {
+		want := s.crc
+		var sum [4]byte
+		if _, err := s.read(sum[:]); err != nil {
+			return nil, nil, unexpectedEOF(err)
+		}
+		if binary.LittleEndian.Uint32(sum[:]) != want {
+			return nil, nil, ErrChecksum
+		}
+	}
+	return key, value, nil
+}
+
+func (s *recordScanner) readField() ([]byte, error) {
+	n, err := binary.ReadUvarint(s)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(s.maxFieldSize) {
+		return nil, fmt.Errorf("%w: length %d exceeds %d", ErrFieldTooLarge, n, s.maxFieldSize)
+	}
+
+	field := make([]byte, n)
+	if _, err := s.read(field); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return field, nil
+}
+
+// ReadByte implements io.ByteReader for binary.ReadUvarint.
+func (s *recordScanner) ReadByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err == nil {
+		s.offset++
+		s.crc = crc32.Update(s.crc, castagnoli, []byte{b})
+	}
+	return b, err
+}
+
+func (s *recordScanner) read(p []byte) (int, error) {
+	n, err := io.ReadFull(s.r, p)
+	s.offset += int64(n)
+	s.crc = crc32.Update(s.crc, castagnoli, p[:n])
+	return n, err
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
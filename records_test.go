@@ -0,0 +1,220 @@
+package skiplist
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRecordReader(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), bytes.Repeat([]byte{byte(i)}, int(i%7)))
+	}
+
+	r := bufio.NewReader(NewRecordReader(list, BytesCodec{}))
+	scanner := &recordScanner{r: r, maxFieldSize: DefaultLoadMaxFieldSize}
+	for i := uint64(0); i < 1000; i++ {
+		key, value, err := scanner.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if orderedKeyValue(key) != i || len(value) != int(i%7) {
+			t.Fatal("wrong record", i, key, value)
+		}
+	}
+
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Fatal("expected EOF after the last record, got", err)
+	}
+}
+
+func TestRecordReaderEncodingError(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), []byte("ok"))
+	list.Set([]byte("b"), 42)
+
+	data, err := ioutil.ReadAll(NewRecordReader(list, BytesCodec{}))
+	if err == nil || err == io.EOF {
+		t.Fatal("expected an encoding error, got", err)
+	}
+
+	if !bytes.Equal(data, []byte{1, 'a', 2, 'o', 'k'}) {
+		t.Fatal("records before the failure must be read", data)
+	}
+}
+
+func TestLoadRecords(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), orderedKey(i*2))
+	}
+
+	var progress []int
+	loaded, err := LoadRecords(NewRecordReader(list, BytesCodec{}), BytesCodec{}, LoadOptions{
+		Progress:         func(n int) { progress = append(progress, n) },
+		ProgressInterval: 400,
+		ListOptions:      []Option{WithName("restored")},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(loaded, t)
+
+	if loaded.Name() != "restored" || loaded.Length != 1000 {
+		t.Fatal("wrong loaded list", loaded, loaded.Length)
+	}
+
+	for e := loaded.Front(); e != nil; e = e.Next() {
+		if orderedKeyValue(e.Value().([]byte)) != orderedKeyValue(e.Key())*2 {
+			t.Fatal("wrong loaded value", e.Key(), e.Value())
+		}
+	}
+
+	if len(progress) != 3 || progress[0] != 400 || progress[2] != 1000 {
+		t.Fatal("wrong progress reports", progress)
+	}
+}
+
+func TestLoadRecordsOrder(t *testing.T) {
+	var buf []byte
+	for _, k := range []string{"b", "a", "c"} {
+		buf = appendRecord(buf, []byte(k), []byte(k))
+	}
+
+	_, err := LoadRecords(bytes.NewReader(buf), BytesCodec{}, LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "record 1") {
+		t.Fatal("expected an ordering error at record 1, got", err)
+	}
+
+	list, err := LoadRecords(bytes.NewReader(buf), BytesCodec{}, LoadOptions{Unsorted: true, ChunkSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(list, t)
+
+	if list.Length != 3 || string(list.Front().Key()) != "a" {
+		t.Fatal("unsorted records must be loaded in order")
+	}
+
+	if _, err := LoadRecords(bytes.NewReader(buf[:len(buf)-1]), BytesCodec{}, LoadOptions{Unsorted: true}); err == nil ||
+		!strings.Contains(err.Error(), io.ErrUnexpectedEOF.Error()) {
+		t.Fatal("expected a truncation error, got", err)
+	}
+}
+
+func TestLoadRecordsDuplicates(t *testing.T) {
+	// Sorted input is linked in a single pass, and unsorted input inserted in chunks; both
+	// resolve duplicates alike.
+	for _, unsorted := range []bool{false, true} {
+		order := []string{"a1", "b1", "b2", "b3", "c1"}
+		if unsorted {
+			order = []string{"b1", "a1", "b2", "c1", "b3"}
+		}
+		var buf []byte
+		for _, kv := range order {
+			buf = appendRecord(buf, []byte(kv[:1]), []byte(kv[1:]))
+		}
+
+		load := func(opts LoadOptions) (*SkipList, error) {
+			opts.Unsorted = unsorted
+			opts.ChunkSize = 2
+			return LoadRecords(bytes.NewReader(buf), BytesCodec{}, opts)
+		}
+
+		if _, err := load(LoadOptions{}); err == nil || !strings.Contains(err.Error(), "duplicate key \"b\"") {
+			t.Fatal("expected a duplicate key error, got", err)
+		}
+
+		cases := []struct {
+			opts LoadOptions
+			want string
+		}{
+			{LoadOptions{Duplicates: DuplicateKeepFirst}, "1"},
+			{LoadOptions{Duplicates: DuplicateKeepLast}, "3"},
+			{LoadOptions{Duplicates: DuplicateMerge, Merge: func(key []byte, existing, incoming interface{}) interface{} {
+				return append(append([]byte(nil), existing.([]byte)...), incoming.([]byte)...)
+			}}, "123"},
+		}
+
+		for _, c := range cases {
+			list, err := load(c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSanity(list, t)
+
+			if list.Length != 3 || string(list.Get([]byte("b")).Value().([]byte)) != c.want {
+				t.Fatal("wrong value for duplicate key", unsorted, c.opts.Duplicates, list.Get([]byte("b")).Value())
+			}
+		}
+
+		if _, err := load(LoadOptions{Duplicates: DuplicateMerge}); err == nil {
+			t.Fatal("DuplicateMerge without a Merge function must fail")
+		}
+	}
+}
+
+func TestLoadRecordsValidation(t *testing.T) {
+	list := New()
+	for _, k := range []string{"a", "b", "c"} {
+		list.Set([]byte(k), []byte(k+k))
+	}
+
+	reader := NewRecordReader(list, BytesCodec{})
+	reader.Checksums = true
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each record is 1+1+1+2 bytes of key and value, then 4 bytes of checksum.
+	const recordSize = 9
+	if len(data) != 3*recordSize {
+		t.Fatal("wrong record size", len(data))
+	}
+
+	loaded, err := LoadRecords(bytes.NewReader(data), BytesCodec{}, LoadOptions{Checksums: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Length != 3 || string(loaded.Get([]byte("c")).Value().([]byte)) != "cc" {
+		t.Fatal("wrong loaded list", loaded.Length)
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[recordSize+3]++
+	_, err = LoadRecords(bytes.NewReader(corrupt), BytesCodec{}, LoadOptions{Checksums: true})
+
+	var recErr *RecordError
+	if !errors.As(err, &recErr) || !errors.Is(err, ErrChecksum) {
+		t.Fatal("expected a checksum error, got", err)
+	}
+	if recErr.Index != 1 || recErr.Offset != recordSize {
+		t.Fatal("wrong position of the corrupt record", recErr.Index, recErr.Offset)
+	}
+
+	// A corrupt length must fail before the field is allocated.
+	huge := appendRecord(nil, []byte("a"), []byte("a"))
+	huge = append(huge, 0xff, 0xff, 0xff, 0xff, 0x0f)
+	_, err = LoadRecords(bytes.NewReader(huge), BytesCodec{}, LoadOptions{MaxFieldSize: 1 << 20})
+	if !errors.As(err, &recErr) || !errors.Is(err, ErrFieldTooLarge) || recErr.Index != 1 || recErr.Offset != 4 {
+		t.Fatal("expected a field size error at record 1, got", err)
+	}
+
+	_, err = LoadRecords(bytes.NewReader(data[:len(data)-1]), BytesCodec{}, LoadOptions{Checksums: true})
+	if !errors.As(err, &recErr) || !errors.Is(err, io.ErrUnexpectedEOF) || recErr.Index != 2 {
+		t.Fatal("expected a truncation error at record 2, got", err)
+	}
+
+	long := appendRecord(appendRecord(nil, []byte("a"), nil), []byte("bb"), nil)
+	_, err = LoadRecords(bytes.NewReader(long), BytesCodec{}, LoadOptions{ListOptions: []Option{WithMaxKeySize(1)}})
+	if !errors.As(err, &recErr) || !errors.Is(err, ErrKeyTooLarge) || recErr.Index != 1 {
+		t.Fatal("expected a key size error at record 1, got", err)
+	}
+}
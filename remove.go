@@ -0,0 +1,210 @@
+package skiplist
+
+import (
+	"context"
+)
+
+// RemoveReason describes why an element left the list.
+type RemoveReason int
+
+const (
+	// Removed means the element was deleted by an explicit call such as Remove.
+	Removed RemoveReason = iota
+	// Expired means the element outlived its time to live.
+	Expired
+	// Evicted means the element was dropped by an eviction policy to stay within a limit.
+	Evicted
+	// Rotated means the element moved out of the list when it was rotated.
+	Rotated
+	// Flushed means the element was removed by CompleteFlush after being flushed.
+	Flushed
+	// Cleared means the element was removed by clearing the whole list.
+	Cleared
+)
+
+func (r RemoveReason) String() string {
+	switch r {
+	case Removed:
+		return "removed"
+	case Expired:
+		return "expired"
+	case Evicted:
+		return "evicted"
+	case Rotated:
+		return "rotated"
+	case Flushed:
+		return "flushed"
+	case Cleared:
+		return "cleared"
+	}
+	return "unknown"
+}
+
+// removal is an element removed from the list along with its value, which a tombstone no
+// longer holds. buried is set for a tombstone that left the list, whose value was reported when
+// its key was removed.
+type removal struct {
+	element *Element
+	value   interface{}
+	buried  bool
+}
+
+// released reports whether the removal releases its value, which the callbacks registered
+// WithOnRemove and WithOnEvict are given. Rotated values are not released, as they live on in the
+// rotated list.
+func (r removal) released(reason RemoveReason) bool {
+	return reason != Rotated && !r.buried
+}
+
+// addRemoveCallback adds fn to the callbacks called for elements that left the list.
+func (list *SkipList) addRemoveCallback(fn func(removal, RemoveReason)) {
+	prev := list.onRemove
+	if prev == nil {
+		list.onRemove = fn
+		return
+	}
+	list.onRemove = func(r removal, reason RemoveReason) {
+		prev(r, reason)
+		fn(r, reason)
+	}
+}
+
+// notifyRemove invokes the remove callbacks, if any, for an element that left the list.
+// It must be called without holding the list mutex, so that the callbacks may use the list.
+func (list *SkipList) notifyRemove(element *Element, reason RemoveReason) {
+	if list.metrics != nil && (reason == Evicted || reason == Expired) {
+		list.metrics.IncEvict()
+	}
+	if list.onRemove != nil {
+		list.onRemove(removal{element: element, value: element.Value(), buried: element.IsTombstone()}, reason)
+	}
+}
+
+// notifyRemoval is notifyRemove for an element that may have been turned into a tombstone.
+func (list *SkipList) notifyRemoval(r removal, reason RemoveReason) {
+	if list.onRemove != nil {
+		list.onRemove(r, reason)
+	}
+}
+
+// notifiesRemove reports whether the list has callbacks for removed elements, so that callers
+// need not collect the elements otherwise.
+func (list *SkipList) notifiesRemove() bool {
+	return list.onRemove != nil
+}
+
+// RemoveRange deletes every element with start <= key < end, returning how many were removed.
+// A nil end leaves the range unbounded above. The range is found with a single search, after
+// which its elements are unlinked in one pass, so deleting a window of keys costs far less than
+// removing them one by one. Removed elements are reported to the remove callback once the list
+// is unlocked. Writes rejected by a frozen list remove nothing (see RemoveRangeE).
+func (list *SkipList) RemoveRange(start, end []byte) int {
+	n, _ := list.RemoveRangeE(start, end)
+	return n
+}
+
+// RemoveRangeE is like RemoveRange, but returns an *Error describing why the removal was rejected.
+func (list *SkipList) RemoveRangeE(start, end []byte) (int, error) {
+	n, removed, _, err := list.removeRange(start, end, 0)
+	if err == ErrReadOnly {
+		var forwarded int
+		_, err = list.frozenWrite("RemoveRange", start, func(overflow *SkipList) (*Element, error) {
+			var err error
+			forwarded, err = overflow.RemoveRangeE(start, end)
+			return nil, err
+		})
+		return forwarded, err
+	}
+	if err != nil {
+		return 0, list.newError("RemoveRange", start, err)
+	}
+
+	for _, element := range removed {
+		list.notifyRemove(element, Removed)
+	}
+	return n, nil
+}
+
+// RemoveRangeContext is like RemoveRangeE, but removes the range in batches of at most batchSize
+// elements, taking the list's lock once per batch and checking ctx between batches, so that
+// deleting a large range can be paused when the list is under load. Unlike RemoveRange, the
+// removal is not atomic: keys inserted in the range behind the batches already removed are kept.
+//
+// If ctx is done before the range is removed, returns the number of elements removed so far and
+// an *Error wrapping the context's error, whose Key is the key to resume the removal from.
+func (list *SkipList) RemoveRangeContext(ctx context.Context, start, end []byte, batchSize int) (int, error) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	total := 0
+	for resume := start; ; {
+		if err := ctx.Err(); err != nil {
+			return total, list.newError("RemoveRange", resume, err)
+		}
+
+		n, removed, next, err := list.removeRange(resume, end, batchSize)
+		if err == ErrReadOnly {
+			var forwarded int
+			_, err = list.frozenWrite("RemoveRange", resume, func(overflow *SkipList) (*Element, error) {
+				var err error
+				forwarded, err = overflow.RemoveRangeContext(ctx, resume, end, batchSize)
+				return nil, err
+			})
+			return total + forwarded, err
+		}
+		if err != nil {
+			return total, list.newError("RemoveRange", resume, err)
+		}
+
+		for _, element := range removed {
+			list.notifyRemove(element, Removed)
+		}
+		total += n
+		if next == nil {
+			return total, nil
+		}
+		resume = next
+	}
+}
+
+// removeRange unlinks the elements in [start, end), up to limit of them if limit is positive,
+// returning their number, and the elements themselves if the list has a remove callback to
+// report them to. If the limit stopped the removal short of end, also returns the key of the
+// next element of the range.
+func (list *SkipList) removeRange(start, end []byte, limit int) (int, []*Element, []byte, error) {
+	list.lock(lockRemoveRange)
+	defer list.unlock()
+
+	if list.frozen {
+		return 0, nil, nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil && list.overlapsFrozen(start, end) {
+		return 0, nil, nil, ErrRangeFrozen
+	}
+
+	// The previous nodes of start stay the previous nodes of every element in the range as the
+	// elements before them are unlinked.
+	prevs := list.getPrevElementNodes(start)
+
+	n := 0
+	var removed []*Element
+	for element := prevs[0].Next(); element != nil; {
+		if end != nil && list.compare(element.key, end) >= 0 {
+			break
+		}
+		if limit > 0 && n == limit {
+			return n, removed, element.key, nil
+		}
+
+		next := element.Next()
+		list.unlink(prevs, element)
+		if list.notifiesRemove() {
+			removed = append(removed, element)
+		}
+		n++
+		element = next
+	}
+
+	return n, removed, nil, nil
+}
@@ -0,0 +1,168 @@
+package skiplist
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemoveCallback(t *testing.T) {
+	var removed []string
+	var list *SkipList
+	list = New(WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		if reason != Removed {
+			t.Fatal("wrong remove reason", reason)
+		}
+		// The list must not be locked while the callback runs.
+		if list.Get(e.Key()) != nil {
+			t.Fatal("element must be unlinked before the callback runs")
+		}
+		removed = append(removed, string(e.Key()))
+	}))
+
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 2)
+	list.Remove([]byte("a"))
+	list.Remove([]byte("missing"))
+
+	if len(removed) != 1 || removed[0] != "a" {
+		t.Fatal("wrong removed elements", removed)
+	}
+}
+
+func TestOnRemoveOnEvict(t *testing.T) {
+	var removed, evicted []string
+	list := New(
+		WithOnRemove(func(key []byte, value interface{}) {
+			removed = append(removed, string(key)+"="+value.(string))
+		}),
+		WithOnEvict(func(key []byte, value interface{}) {
+			evicted = append(evicted, string(key)+"="+value.(string))
+		}),
+		WithWeigher(func(key []byte, value interface{}) int64 { return 1 }),
+		WithMaxWeight(3),
+	)
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		list.Set([]byte(key), key)
+	}
+	list.Remove([]byte("c"))
+	list.RemoveRange([]byte("d"), nil)
+	list.SetWithTTL([]byte("f"), "f", -time.Second)
+	list.ExpireNow()
+
+	// Keeping the weight within 3 evicts a and b.
+	if strings.Join(removed, ",") != "a=a,b=b,c=c,d=d,e=e,f=f" || strings.Join(evicted, ",") != "a=a,b=b,f=f" {
+		t.Fatal("wrong released values", removed, evicted)
+	}
+
+	removed = nil
+	list = New(WithTombstones(), WithOnRemove(func(key []byte, value interface{}) {
+		removed = append(removed, string(key)+"="+value.(string))
+	}))
+	list.Set([]byte("a"), "a")
+	list.Remove([]byte("a"))
+	var batch WriteBatch
+	batch.Set([]byte("b"), "b")
+	list.Apply(&batch)
+	batch.Reset()
+	batch.Remove([]byte("b"))
+	list.Apply(&batch)
+	list.Clear()
+	if len(removed) != 2 || removed[0] != "a=a" || removed[1] != "b=b" {
+		t.Fatal("wrong values released by tombstones", removed)
+	}
+}
+
+func TestRemoveCallbacksCombine(t *testing.T) {
+	var calls []string
+	list := New(
+		WithOnEvict(func(key []byte, value interface{}) {
+			calls = append(calls, "evict "+string(key))
+		}),
+		WithRemoveCallback(func(element *Element, reason RemoveReason) {
+			calls = append(calls, reason.String()+" "+string(element.Key()))
+		}),
+		WithOnRemove(func(key []byte, value interface{}) {
+			calls = append(calls, "remove "+string(key))
+		}),
+	)
+	list.Set([]byte("a"), "a")
+	list.SetWithTTL([]byte("b"), "b", -time.Second)
+	list.Remove([]byte("a"))
+	list.ExpireNow()
+
+	if got := strings.Join(calls, ","); got != "removed a,remove a,evict b,expired b,remove b" {
+		t.Fatal("callbacks must all be called, in the order of their options", got)
+	}
+}
+
+func TestRemoveReasonString(t *testing.T) {
+	if Removed.String() != "removed" || Rotated.String() != "rotated" || RemoveReason(-1).String() != "unknown" {
+		t.Fatal("wrong remove reason names")
+	}
+}
+
+func TestRemoveRange(t *testing.T) {
+	var removed int
+	list := New(WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		removed++
+	}))
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	if n := list.RemoveRange(orderedKey(100), orderedKey(300)); n != 200 || removed != 200 {
+		t.Fatal("wrong number of removed elements", n, removed)
+	}
+	checkSanity(list, t)
+
+	if list.Len() != 800 || list.Get(orderedKey(99)) == nil || list.Get(orderedKey(100)) != nil ||
+		list.Get(orderedKey(299)) != nil || list.Get(orderedKey(300)) == nil {
+		t.Fatal("wrong elements after RemoveRange")
+	}
+
+	if n := list.RemoveRange(orderedKey(100), orderedKey(300)); n != 0 {
+		t.Fatal("an empty range must remove nothing", n)
+	}
+
+	if n := list.RemoveRange(orderedKey(900), nil); n != 100 || list.MaxKey() == nil || orderedKeyValue(list.MaxKey()) != 899 {
+		t.Fatal("an unbounded range must remove the rest of the list", n)
+	}
+	checkSanity(list, t)
+
+	list.Freeze()
+	if _, err := list.RemoveRangeE(nil, nil); err == nil || list.Len() != 700 {
+		t.Fatal("a frozen list must not be written")
+	}
+}
+
+func TestRemoveRangeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	removed := 0
+	list := New(WithRemoveCallback(func(*Element, RemoveReason) {
+		if removed++; removed == 10 {
+			cancel()
+		}
+	}))
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	n, err := list.RemoveRangeContext(ctx, orderedKey(10), orderedKey(90), 8)
+	var e *Error
+	if !errors.As(err, &e) || !errors.Is(err, context.Canceled) || n != 16 {
+		t.Fatal("a cancelled removal must report its progress", n, err)
+	}
+	if string(e.Key) != string(orderedKey(26)) || list.Get(orderedKey(25)) != nil || list.Get(orderedKey(26)) == nil {
+		t.Fatal("the error must hold the key to resume from", e.Key)
+	}
+
+	n, err = list.RemoveRangeContext(context.Background(), e.Key, orderedKey(90), 8)
+	if err != nil || n != 64 || list.Len() != 20 {
+		t.Fatal("a resumed removal must remove the rest of the range", n, err, list.Len())
+	}
+	checkSanity(list, t)
+}
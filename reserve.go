@@ -0,0 +1,178 @@
+package skiplist
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Reservation is a key range of a list reserved by ReserveRange for a single loader.
+type Reservation struct {
+	list       *SkipList
+	start, end []byte
+
+	// mutex guards staged, which holds the writes to the range until they are committed.
+	mutex  sync.Mutex
+	staged []batchOp
+	sorted bool
+	done   bool
+}
+
+// ReserveRange reserves the keys in [start, end) for a loader, which then writes them through
+// the returned Reservation. A nil end leaves the range unbounded above. Writes to a reservation
+// are staged under the reservation's own lock, and only take the list's lock once, when they
+// are committed, so parallel loaders importing disjoint ranges do not contend with each other
+// or with other writers of the list.
+//
+// Until the reservation is committed or released, other writes of keys in the range fail with
+// ErrRangeReserved; removals are still allowed. Reserving a range that overlaps another
+// reservation fails with ErrRangeReserved too.
+func (list *SkipList) ReserveRange(start, end []byte) (*Reservation, error) {
+	if end != nil && list.compare(start, end) >= 0 {
+		return nil, list.newError("ReserveRange", start, errors.New("empty range"))
+	}
+
+	list.lock(lockReserve)
+	defer list.unlock()
+
+	for _, r := range list.reservations {
+		if (end == nil || list.compare(r.start, end) < 0) && (r.end == nil || list.compare(start, r.end) < 0) {
+			return nil, list.newError("ReserveRange", start, ErrRangeReserved)
+		}
+	}
+	if list.overlapsFrozen(start, end) {
+		return nil, list.newError("ReserveRange", start, ErrRangeFrozen)
+	}
+
+	r := &Reservation{list: list, start: start, end: end, sorted: true}
+	list.reservations = append(list.reservations, r)
+	return r, nil
+}
+
+// reserved reports whether key is in a reserved range. The caller must hold the list mutex.
+func (list *SkipList) reserved(key []byte) bool {
+	for _, r := range list.reservations {
+		if r.contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// release removes r from the list's reservations. The caller must hold the list mutex.
+func (list *SkipList) release(r *Reservation) {
+	for i, other := range list.reservations {
+		if other == r {
+			list.reservations = append(list.reservations[:i], list.reservations[i+1:]...)
+			break
+		}
+	}
+	if len(list.reservations) == 0 {
+		list.reservations = nil
+	}
+}
+
+func (r *Reservation) contains(key []byte) bool {
+	return r.list.compare(key, r.start) >= 0 && (r.end == nil || r.list.compare(key, r.end) < 0)
+}
+
+// Set stages the insert or update of key, which must be in the reserved range.
+// It fails once the reservation is committed or released.
+func (r *Reservation) Set(key []byte, value interface{}) error {
+	if err := r.list.checkKey("Set", key); err != nil {
+		return err
+	}
+	if !r.contains(key) {
+		return r.list.newError("Set", key, errors.New("key outside of the reserved range"))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.done {
+		return r.list.newError("Set", key, errors.New("reservation is closed"))
+	}
+	// Loaders typically write in key order, which keeps the staged writes sorted for free.
+	if n := len(r.staged); n > 0 && r.list.compare(key, r.staged[n-1].key) < 0 {
+		r.sorted = false
+	}
+	r.staged = append(r.staged, batchOp{key: key, value: value})
+	return nil
+}
+
+// Len returns the number of staged writes.
+func (r *Reservation) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.staged)
+}
+
+// Commit applies the staged writes to the list under a single acquisition of its lock, like
+// Apply, and releases the range. Writes of the same key take effect in the order they were
+// staged. If the list is frozen, or the writes would insert more keys than a list constructed
+// WithCapacity has room for, nothing is applied and the range stays reserved.
+func (r *Reservation) Commit() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.done {
+		return r.list.newError("Commit", r.start, errors.New("reservation is closed"))
+	}
+
+	if !r.sorted {
+		sort.SliceStable(r.staged, func(i, j int) bool {
+			return r.list.compare(r.staged[i].key, r.staged[j].key) < 0
+		})
+	}
+
+	violations, err := r.commit()
+	if err != nil {
+		return r.list.newError("Commit", r.start, err)
+	}
+	r.done = true
+	r.staged = nil
+
+	for _, violation := range violations {
+		r.list.onOrderViolation(violation)
+	}
+	r.list.enforceMaxWeight()
+	return nil
+}
+
+func (r *Reservation) commit() ([]OrderViolation, error) {
+	list := r.list
+	list.lock(lockReserve)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, ErrReadOnly
+	}
+	if list.capacity > 0 && list.Length+list.newKeys(r.staged) > list.capacity {
+		return nil, ErrFull
+	}
+	if list.byteQuota > 0 && list.exceedsQuota(list.newBytes(r.staged)) {
+		return nil, ErrQuotaExceeded
+	}
+
+	list.release(r)
+	_, violations := list.applyLocked(r.staged)
+	return violations, nil
+}
+
+// Release discards the staged writes and releases the range. It does nothing if the
+// reservation is already committed or released.
+func (r *Reservation) Release() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.done {
+		return
+	}
+	r.done = true
+	r.staged = nil
+
+	r.list.lock(lockReserve)
+	defer r.list.unlock()
+	r.list.release(r)
+}
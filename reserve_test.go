@@ -0,0 +1,99 @@
+package skiplist
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestReserveRange(t *testing.T) {
+	list := New()
+	list.Set(orderedKey(5000), "outside")
+
+	var wg sync.WaitGroup
+	for part := uint64(0); part < 4; part++ {
+		r, err := list.ReserveRange(orderedKey(part*1000), orderedKey((part+1)*1000))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func(part uint64, r *Reservation) {
+			defer wg.Done()
+			// Loaders need not write in order.
+			for i := uint64(999); i < 1000; i-- {
+				if err := r.Set(orderedKey(part*1000+i), i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if err := r.Commit(); err != nil {
+				t.Error(err)
+			}
+		}(part, r)
+	}
+
+	// Writers outside the reserved ranges are not held up.
+	list.Set(orderedKey(6000), "outside")
+	wg.Wait()
+	checkSanity(list, t)
+
+	if list.Len() != 4002 {
+		t.Fatal("wrong length after commits", list.Len())
+	}
+	for i := uint64(0); i < 4000; i++ {
+		if e := list.Get(orderedKey(i)); e == nil || e.Value() != i%1000 {
+			t.Fatal("committed key not found", i, e)
+		}
+	}
+}
+
+func TestReserveRangeExclusion(t *testing.T) {
+	list := New()
+	r, err := list.ReserveRange([]byte("b"), []byte("d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := list.ReserveRange([]byte("c"), nil); !errors.Is(err, ErrRangeReserved) {
+		t.Fatal("overlapping reservations must fail, got", err)
+	}
+	if _, err := list.SetE([]byte("c"), 1); !errors.Is(err, ErrRangeReserved) {
+		t.Fatal("writes in a reserved range must fail, got", err)
+	}
+	if err := r.Set([]byte("e"), 1); err == nil {
+		t.Fatal("a reservation must only take keys in its range")
+	}
+
+	adjacent, err := list.ReserveRange([]byte("d"), nil)
+	if err != nil {
+		t.Fatal("adjacent ranges must not overlap", err)
+	}
+	adjacent.Release()
+
+	if err := r.Set([]byte("c"), 1); err != nil {
+		t.Fatal(err)
+	}
+	r.Release()
+	if err := r.Commit(); err == nil || list.Get([]byte("c")) != nil {
+		t.Fatal("released writes must be discarded")
+	}
+	if _, err := list.SetE([]byte("c"), 2); err != nil {
+		t.Fatal("a released range must be writable", err)
+	}
+}
+
+func TestReserveRangeCapacity(t *testing.T) {
+	list := New(WithCapacity(2))
+	r, err := list.ReserveRange(orderedKey(0), orderedKey(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 4; i++ {
+		r.Set(orderedKey(i), i)
+	}
+	if err := r.Commit(); !errors.Is(err, ErrFull) || list.Len() != 0 {
+		t.Fatal("commit over capacity must be rejected", err, list.Len())
+	}
+	r.Release()
+}
@@ -0,0 +1,92 @@
+package skiplist
+
+import (
+	"sync/atomic"
+)
+
+// epoch identifies the contents of a list between two rotations. Iterators remember the epoch
+// of their list when they are created, so that they can follow its contents to the frozen list
+// they move to when the list is rotated.
+type epoch struct {
+	// frozen is set by Rotate to the list holding the contents of the epoch. frozenEpoch is the
+	// epoch of that list, and is set before frozen is published.
+	frozen      atomic.Pointer[SkipList]
+	frozenEpoch *epoch
+}
+
+// Rotate moves the contents of the list to a new, frozen list, which it returns, and leaves the
+// list empty and writable, as when a database swaps a full memtable for a fresh one and flushes
+// the old one in the background. The move takes the list's lock once and does not copy elements.
+// Moved elements are reported to the remove callback with the Rotated reason once the list is
+// unlocked.
+//
+// Iterators created before Rotate keep iterating the pre-rotation contents, now in the frozen
+// list, however their seeks interleave with the rotation, while iterators created afterwards see
+// only the writes made after it. A reader therefore never observes part of the old contents
+// alongside part of the new. Other reads are not ordered with Rotate and see either.
+//
+// The frozen list keeps the list's configuration, other than its callbacks and eviction, and the
+// pins of its elements. Namespaces, hot keys, ghosts and reservations stay with the list, with
+// the namespace statistics restarting from zero. Rotating a frozen list fails with ErrReadOnly.
+func (list *SkipList) Rotate() (*SkipList, error) {
+	frozen, err := list.rotate()
+	if err != nil {
+		return nil, list.newError("Rotate", nil, err)
+	}
+
+	if list.onRemove != nil {
+		for element := frozen.Front(); element != nil; element = element.Next() {
+			list.notifyRemove(element, Rotated)
+		}
+	}
+	return frozen, nil
+}
+
+func (list *SkipList) rotate() (*SkipList, error) {
+	list.lock(lockRotate)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil {
+		return nil, ErrRangeFrozen
+	}
+
+	frozen := list.newLike()
+	frozen.frozen = true
+	frozen.Length = list.Length
+	frozen.last = atomic.LoadPointer(&list.last)
+	frozen.seq = list.seq
+	frozen.weight = list.weight
+	frozen.keyBytes, frozen.valueBytes, frozen.nodeBytes = list.keyBytes, list.valueBytes, list.nodeBytes
+	frozen.tombstones = list.tombstones
+	frozen.pinSites = list.pinSites
+	copy(frozen.levelCounts, list.levelCounts)
+	for i := range list.next {
+		frozen.next[i] = atomic.LoadPointer(&list.next[i])
+		frozen.tails[i] = list.tails[i]
+		if frozen.tails[i] == &list.elementNode {
+			frozen.tails[i] = &frozen.elementNode
+		}
+	}
+	copy(frozen.spans, list.spans)
+	frozen.history = list.history
+	frozen.versionsFloor = list.versionsFloor
+	frozen.lostSeq = list.lostSeq
+
+	// Publish the frozen list to the iterators of the current epoch before emptying the list,
+	// so that an iterator that finds the list empty can tell that it was rotated.
+	current := list.epoch.Load()
+	current.frozenEpoch = frozen.epoch.Load()
+	current.frozen.Store(frozen)
+
+	// The pins moved with the elements, so the frozen list keeps the record of their sites.
+	if list.pinSites != nil {
+		list.pinSites = make(map[*Element][]pinSite)
+	}
+	list.reset()
+
+	list.epoch.Store(&epoch{})
+	return frozen, nil
+}
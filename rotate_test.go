@@ -0,0 +1,139 @@
+package skiplist
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	var rotated int
+	list := New(WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		if reason == Rotated {
+			rotated++
+		}
+	}))
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	it := list.NewIterator()
+	it.SeekToFirst()
+	unpositioned := list.Range(orderedKey(10), orderedKey(20))
+	unpositioned.SeekToLast()
+	late := list.NewIterator()
+
+	frozen, err := list.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSanity(frozen, t)
+	checkSanity(list, t)
+	if !frozen.Frozen() || frozen.Len() != 100 || list.Len() != 0 || list.Frozen() || rotated != 100 {
+		t.Fatal("the contents must move to a frozen list", frozen.Len(), list.Len(), rotated)
+	}
+
+	list.Set(orderedKey(5), "new")
+	list.Set(orderedKey(500), "new")
+	if frozen.Get(orderedKey(500)) != nil || frozen.Get(orderedKey(5)).Value() != uint64(5) {
+		t.Fatal("writes after Rotate must not reach the frozen list")
+	}
+
+	// Iterators created before Rotate see the pre-rotation contents, whether they were
+	// positioned before it or not.
+	n := 0
+	for ; it.Valid(); it.Next() {
+		n++
+	}
+	for late.SeekToLast(); late.Valid(); late.Prev() {
+		if _, ok := late.Value().(uint64); !ok {
+			t.Fatal("an iterator created before Rotate must not see later writes", late.Value())
+		}
+		n++
+	}
+	if !unpositioned.Valid() || !bytes.Equal(unpositioned.Key(), orderedKey(19)) || n != 200 {
+		t.Fatal("iterators created before Rotate must see the pre-rotation contents", n)
+	}
+	late.Seek(orderedKey(5))
+	if !late.Valid() || late.Value() != uint64(5) {
+		t.Fatal("seeks after Rotate must search the frozen list", late.Element())
+	}
+
+	fresh := list.NewIterator()
+	n = 0
+	for fresh.SeekToFirst(); fresh.Valid(); fresh.Next() {
+		n++
+	}
+	if n != 2 {
+		t.Fatal("iterators created after Rotate must only see later writes", n)
+	}
+
+	if _, err := frozen.Rotate(); !errors.Is(err, ErrReadOnly) {
+		t.Fatal("rotating a frozen list must fail", err)
+	}
+}
+
+// TestRotateConsistentIteration checks that readers never observe a mix of the contents of two
+// generations of a list that is rotated while they iterate.
+func TestRotateConsistentIteration(t *testing.T) {
+	const keys = 50
+	list := New()
+	fill := func(generation int) {
+		for i := uint64(0); i < keys; i++ {
+			list.Set(orderedKey(i), generation)
+		}
+	}
+	fill(0)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	errs := make(chan string, 4)
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			it := list.AcquireIterator()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				it.Release()
+				it = list.AcquireIterator()
+				generation, n := -1, 0
+				for it.SeekToFirst(); it.Valid(); it.Next() {
+					if generation == -1 {
+						generation = it.Value().(int)
+					} else if it.Value().(int) != generation {
+						errs <- "observed two generations in one iteration"
+						return
+					}
+					n++
+				}
+				if n > keys {
+					errs <- "observed a key twice"
+					return
+				}
+			}
+		}()
+	}
+
+	for generation := 1; generation < 200; generation++ {
+		frozen, err := list.Rotate()
+		if err != nil || frozen.Len() != keys {
+			t.Fatal("wrong rotation", err, frozen.Len())
+		}
+		fill(generation)
+	}
+	close(stop)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+}
@@ -0,0 +1,86 @@
+package skiplist
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// ParallelScan splits the keyspace into up to parts ranges using ApproxSplitPoints and calls fn
+// concurrently for each range, passing the range's index and an iterator bounded to it.
+// The iterator is positioned at the first element of its range.
+//
+// The first error returned by fn is returned by ParallelScan. Once any fn fails or ctx is done,
+// the iterators of the remaining ranges become invalid so that their scans wind down early.
+func (list *SkipList) ParallelScan(ctx context.Context, parts int, fn func(part int, it *Iterator) error) error {
+	if parts < 1 {
+		parts = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	points := list.ApproxSplitPoints(parts - 1)
+	for part := 0; part <= len(points); part++ {
+		var lower, upper []byte
+		if part > 0 {
+			lower = points[part-1]
+		}
+		if part < len(points) {
+			upper = points[part]
+		}
+
+		it := list.newBoundedIterator(lower, upper)
+		it.done = ctx.Done()
+
+		wg.Add(1)
+		go func(part int, it *Iterator) {
+			defer wg.Done()
+
+			it.SeekToFirst()
+			if err := fn(part, it); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(part, it)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// GroupByPrefix walks the list once in key order, calling agg for every element with its group,
+// the first prefixLen bytes of its key, or the whole key if it is shorter. The elements of a group
+// are adjacent in byte-wise order, so agg sees each group whole before the next begins and can
+// compute per-tenant or per-metric rollups in a single pass, emitting a group's rollup when the
+// prefix changes. Elements of a group are passed the same prefix, which must not be modified.
+// Like any iteration, the walk does not lock the list.
+// It panics if the list was constructed WithComparator.
+func (list *SkipList) GroupByPrefix(prefixLen int, agg func(prefix []byte, e *Element)) {
+	if !list.byteOrder {
+		panic(list.String() + ": grouping by prefix requires the default byte-wise key order")
+	}
+
+	var prefix []byte
+	for element := list.Front(); element != nil; element = element.Next() {
+		key := element.key
+		if len(key) > prefixLen {
+			key = key[:prefixLen]
+		}
+		if prefix == nil || !bytes.Equal(key, prefix) {
+			prefix = key[:len(key):len(key)]
+		}
+		agg(prefix, element)
+	}
+}
@@ -0,0 +1,103 @@
+package skiplist
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelScan(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 10000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	var total int64
+	seen := make([]int32, 10000)
+	err := list.ParallelScan(context.Background(), 4, func(part int, it *Iterator) error {
+		prev := int64(-1)
+		for ; it.Valid(); it.Next() {
+			v := int64(it.Value().(uint64))
+			if v <= prev {
+				return errors.New("out of order")
+			}
+			prev = v
+			atomic.AddInt32(&seen[v], 1)
+			atomic.AddInt64(&total, 1)
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 10000 {
+		t.Fatal("wrong number of scanned elements", total)
+	}
+
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatal("element scanned the wrong number of times", i, n)
+		}
+	}
+}
+
+func TestParallelScanError(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 10000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	failure := errors.New("failure")
+	err := list.ParallelScan(context.Background(), 4, func(part int, it *Iterator) error {
+		if part == 0 {
+			return failure
+		}
+		for ; it.Valid(); it.Next() {
+		}
+		return nil
+	})
+
+	if err != failure {
+		t.Fatal("expected the error returned by fn, got", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = list.ParallelScan(ctx, 2, func(part int, it *Iterator) error {
+		if it.Valid() {
+			return errors.New("iterator must be invalid once the context is done")
+		}
+		return nil
+	})
+
+	if err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+}
+
+func TestGroupByPrefix(t *testing.T) {
+	list := New()
+	for _, k := range []string{"cpu.a", "cpu.b", "mem.a", "m", "net.a", "net.b", "net.c"} {
+		list.Set([]byte(k), len(k))
+	}
+
+	var groups []string
+	var sums []int
+	list.GroupByPrefix(3, func(prefix []byte, e *Element) {
+		if len(groups) == 0 || groups[len(groups)-1] != string(prefix) {
+			groups = append(groups, string(prefix))
+			sums = append(sums, 0)
+		}
+		sums[len(sums)-1] += e.Value().(int)
+	})
+
+	if len(groups) != 4 || groups[0] != "cpu" || groups[1] != "m" || groups[2] != "mem" || groups[3] != "net" {
+		t.Fatal("wrong groups", groups)
+	}
+	if sums[0] != 10 || sums[1] != 1 || sums[2] != 5 || sums[3] != 15 {
+		t.Fatal("wrong rollups", sums)
+	}
+}
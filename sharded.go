@@ -0,0 +1,110 @@
+package skiplist
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// ShardedSkipList partitions keys across several skip lists, each with its own lock, so that
+// writes to different shards proceed in parallel. Keys are assigned to shards either by hash,
+// which spreads any workload evenly, or by range, which keeps each shard a contiguous slice
+// of the keyspace. Either way, iteration merges the shards back into a single ordered view.
+type ShardedSkipList struct {
+	shards []*SkipList
+	// bounds holds the first key of every shard but the first when sharding by range,
+	// and is nil when sharding by hash.
+	bounds [][]byte
+}
+
+// NewSharded creates a list of n shards, assigning keys to shards by hash. Each shard is
+// constructed with opts.
+func NewSharded(n int, opts ...Option) *ShardedSkipList {
+	if n < 1 {
+		n = 1
+	}
+
+	sharded := &ShardedSkipList{shards: make([]*SkipList, n)}
+	for i := range sharded.shards {
+		sharded.shards[i] = New(opts...)
+	}
+	return sharded
+}
+
+// NewRangeSharded creates a list of len(bounds)+1 shards, assigning keys to shards by range:
+// shard 0 holds keys below bounds[0], shard i holds keys in [bounds[i-1], bounds[i]), and the
+// last shard holds the rest. bounds must be increasing. Each shard is constructed with opts.
+func NewRangeSharded(bounds [][]byte, opts ...Option) *ShardedSkipList {
+	sharded := &ShardedSkipList{shards: make([]*SkipList, len(bounds)+1)}
+	for i := range sharded.shards {
+		sharded.shards[i] = New(opts...)
+	}
+
+	compare := sharded.shards[0].compare
+	sharded.bounds = make([][]byte, len(bounds))
+	for i, bound := range bounds {
+		if i > 0 && compare(bounds[i-1], bound) >= 0 {
+			panic(sharded.shards[0].String() + ": shard bounds must be increasing")
+		}
+		sharded.bounds[i] = append([]byte(nil), bound...)
+	}
+	return sharded
+}
+
+// Shards returns the number of shards.
+func (sharded *ShardedSkipList) Shards() int {
+	return len(sharded.shards)
+}
+
+// Shard returns the i-th shard, for operations that are not provided across shards.
+func (sharded *ShardedSkipList) Shard(i int) *SkipList {
+	return sharded.shards[i]
+}
+
+// shardFor returns the shard key belongs to.
+func (sharded *ShardedSkipList) shardFor(key []byte) *SkipList {
+	if sharded.bounds != nil {
+		compare := sharded.shards[0].compare
+		i := sort.Search(len(sharded.bounds), func(i int) bool {
+			return compare(key, sharded.bounds[i]) < 0
+		})
+		return sharded.shards[i]
+	}
+
+	h := fnv.New64a()
+	h.Write(key)
+	return sharded.shards[h.Sum64()%uint64(len(sharded.shards))]
+}
+
+// Set inserts a value with the specified key in the key's shard.
+func (sharded *ShardedSkipList) Set(key []byte, value interface{}) *Element {
+	return sharded.shardFor(key).Set(key, value)
+}
+
+// Get finds an element by key. It returns element pointer if found, nil if not found.
+func (sharded *ShardedSkipList) Get(key []byte) *Element {
+	return sharded.shardFor(key).Get(key)
+}
+
+// Remove deletes an element from its shard.
+// Returns removed element pointer if found, nil if not found.
+func (sharded *ShardedSkipList) Remove(key []byte) *Element {
+	return sharded.shardFor(key).Remove(key)
+}
+
+// Len returns the total number of elements across shards.
+func (sharded *ShardedSkipList) Len() int {
+	n := 0
+	for _, shard := range sharded.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// NewIterator returns an unpositioned iterator over every shard, in key order.
+func (sharded *ShardedSkipList) NewIterator() *MergeIterator {
+	iters := make([]*Iterator, len(sharded.shards))
+	for i, shard := range sharded.shards {
+		iters[i] = shard.NewIterator()
+	}
+	return NewMergeIterator(iters...)
+}
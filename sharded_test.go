@@ -0,0 +1,72 @@
+package skiplist
+
+import (
+	"sync"
+	"testing"
+)
+
+func checkShardedOrder(sharded *ShardedSkipList, t *testing.T, n int) {
+	var count uint64
+	it := sharded.NewIterator()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if orderedKeyValue(it.Key()) != count {
+			t.Fatal("wrong merged order at", count, orderedKeyValue(it.Key()))
+		}
+		count++
+	}
+	if int(count) != n || sharded.Len() != n {
+		t.Fatal("wrong number of elements", count, sharded.Len())
+	}
+}
+
+func TestShardedByHash(t *testing.T) {
+	sharded := NewSharded(4)
+
+	var wg sync.WaitGroup
+	for w := uint64(0); w < 4; w++ {
+		wg.Add(1)
+		go func(w uint64) {
+			defer wg.Done()
+			for i := w; i < 1000; i += 4 {
+				sharded.Set(orderedKey(i), i)
+			}
+		}(w)
+	}
+	wg.Wait()
+	checkShardedOrder(sharded, t, 1000)
+
+	for i := 0; i < sharded.Shards(); i++ {
+		if n := sharded.Shard(i).Len(); n < 150 {
+			t.Fatal("hashing must spread keys across shards", i, n)
+		}
+	}
+
+	if e := sharded.Get(orderedKey(500)); e == nil || e.Value().(uint64) != 500 {
+		t.Fatal("Get must find the key in its shard", e)
+	}
+	if sharded.Remove(orderedKey(500)) == nil || sharded.Get(orderedKey(500)) != nil {
+		t.Fatal("Remove must delete the key from its shard")
+	}
+}
+
+func TestShardedByRange(t *testing.T) {
+	sharded := NewRangeSharded([][]byte{orderedKey(100), orderedKey(200)})
+	for i := uint64(0); i < 300; i++ {
+		sharded.Set(orderedKey(i), i)
+	}
+	checkShardedOrder(sharded, t, 300)
+
+	for i := 0; i < 3; i++ {
+		shard := sharded.Shard(i)
+		if shard.Len() != 100 || orderedKeyValue(shard.MinKey()) != uint64(i*100) {
+			t.Fatal("each shard must hold its range", i, shard.Len(), shard.MinKey())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("decreasing bounds must be rejected")
+		}
+	}()
+	NewRangeSharded([][]byte{orderedKey(2), orderedKey(1)})
+}
@@ -4,14 +4,26 @@ import (
 	"bytes"
 	"math"
 	"math/rand"
+	"sort"
+	"strconv"
 	"sync/atomic"
 	"time"
 	"unsafe"
+
+	"github.com/m3db/fast-skiplist/internal/tower"
 )
 
 const (
 	DefaultMaxLevel    int     = 18
 	DefaultProbability float64 = 1 / math.E
+
+	splitOversampling = 16
+	// appendModeMinInserts is the number of inserts observed before the tail fast path may kick in.
+	appendModeMinInserts = 64
+	// appendModeWindow bounds how many recent inserts decide whether the fast path is used.
+	appendModeWindow = 1024
+	// progressSampleSize bounds the number of elements visited to estimate an iterator's progress.
+	progressSampleSize = 1024
 )
 
 // Front returns the head node of the list.
@@ -19,84 +31,454 @@ func (list *SkipList) Front() *Element {
 	return list.elementNode.Next()
 }
 
+// Back returns the last element of the list, the one with the largest key, or nil if the list
+// is empty. The last element is cached as the list changes, so this takes constant time and does
+// not search or lock the list.
+func (list *SkipList) Back() *Element {
+	return list.lastElement()
+}
+
 // Set inserts a value in the list with the specified key, ordered by the key.
 // If the key exists, it updates the value in the existing node.
-// Returns a pointer to the new element.
+// Returns a pointer to the new element, or nil if the write was rejected (see SetE).
 // Locking is optimistic and happens only after searching.
 func (list *SkipList) Set(key []byte, value interface{}) *Element {
-	list.mutex.Lock()
-	defer list.mutex.Unlock()
+	element, _ := list.SetE(key, value)
+	return element
+}
+
+// SetE is like Set, but returns an *Error describing why a write was rejected
+// instead of silently dropping it.
+func (list *SkipList) SetE(key []byte, value interface{}) (*Element, error) {
+	if list.metrics != nil {
+		defer list.observeLatency("Set", time.Now())
+	}
+	if err := list.checkKey("Set", key); err != nil {
+		return nil, err
+	}
+
+	if list.hotKeys != nil {
+		list.hotKeys.record(key)
+	}
+
+	element, _, err := list.set(key, value, nil, nil, 0)
+	if err == ErrReadOnly {
+		return list.frozenWrite("Set", key, func(overflow *SkipList) (*Element, error) {
+			element, err := overflow.SetE(key, value)
+			return list.replaced("Set", key, element, err)
+		})
+	}
+	if err != nil {
+		return nil, list.newError("Set", key, err)
+	}
+
+	if list.metrics != nil {
+		list.metrics.IncSet()
+	}
+	list.enforceMaxWeight()
+	return element, nil
+}
+
+// GetOrCreate returns the element of key if it is in the list, or else inserts the value
+// returned by create, in a single search. The boolean result reports whether the element was
+// created. create is called with the list locked, so concurrent callers never create the same
+// key twice; it must be quick and must not use the list. Like Set, GetOrCreate returns a nil
+// element if the write was rejected.
+func (list *SkipList) GetOrCreate(key []byte, create func() interface{}) (*Element, bool) {
+	if list.metrics != nil {
+		defer list.observeLatency("GetOrCreate", time.Now())
+	}
+	if err := list.checkKey("GetOrCreate", key); err != nil {
+		return nil, false
+	}
+
+	if list.hotKeys != nil {
+		list.hotKeys.record(key)
+	}
+
+	element, created, err := list.set(key, nil, create, nil, 0)
+	if err == ErrReadOnly {
+		element, _ = list.frozenWrite("GetOrCreate", key, func(overflow *SkipList) (*Element, error) {
+			element, created = overflow.GetOrCreate(key, create)
+			return element, nil
+		})
+		return element, created
+	}
+
+	if created {
+		if list.metrics != nil {
+			list.metrics.IncSet()
+		}
+		list.enforceMaxWeight()
+	}
+	return element, created
+}
+
+// Merge combines operand with the value of key using the list's merge operator, in a single
+// search with the list locked, so concurrent merges of the same key are never lost. If the key is
+// not in the list, it is inserted with operand as its value. This saves the Get and Set round
+// trips of read-modify-write patterns such as counters or values accumulating a list of items.
+//
+// Returns the element, or nil if the write was rejected (see MergeE).
+func (list *SkipList) Merge(key []byte, operand interface{}) *Element {
+	element, _ := list.MergeE(key, operand)
+	return element
+}
+
+// MergeE is like Merge, but returns an *Error describing why a write was rejected, wrapping
+// ErrNoMergeOperator if the list was not constructed WithMergeOperator.
+func (list *SkipList) MergeE(key []byte, operand interface{}) (*Element, error) {
+	if list.metrics != nil {
+		defer list.observeLatency("Merge", time.Now())
+	}
+	if list.merge == nil {
+		return nil, list.newError("Merge", key, ErrNoMergeOperator)
+	}
+	if err := list.checkKey("Merge", key); err != nil {
+		return nil, err
+	}
+
+	if list.hotKeys != nil {
+		list.hotKeys.record(key)
+	}
+
+	element, _, err := list.set(key, operand, nil, list.merge, 0)
+	if err == ErrReadOnly {
+		return list.frozenWrite("Merge", key, func(overflow *SkipList) (*Element, error) {
+			element, err := overflow.MergeE(key, operand)
+			return list.replaced("Merge", key, element, err)
+		})
+	}
+	if err != nil {
+		return nil, list.newError("Merge", key, err)
+	}
+
+	if list.metrics != nil {
+		list.metrics.IncSet()
+	}
+	list.enforceMaxWeight()
+	return element, nil
+}
+
+// set inserts key with value, or updates the value of an existing element. If create is set,
+// an existing element is left unchanged, and a new element takes its value from create.
+// If merge is set, an existing element takes the merge of its value with value instead, keeping
+// its expiry. Otherwise, the element expires at expires, in nanoseconds since the Unix epoch,
+// or never if expires is zero.
+func (list *SkipList) set(key []byte, value interface{}, create func() interface{}, merge MergeFunc, expires int64) (*Element, bool, error) {
+	// Violations are reported once the mutex is released, so that the callback may use the list.
+	var violation *OrderViolation
+	defer func() {
+		if violation != nil {
+			list.onOrderViolation(*violation)
+		}
+	}()
+
+	// Drawing the height of the element a write may insert, and allocating it when its value is
+	// known and it does not go in an arena, keeps both out of the critical section, at the cost
+	// of a wasted allocation when the key is already in the list.
+	level := list.randLevel()
+	var fresh *Element
+	if create == nil && list.arena == nil {
+		fresh = list.allocate(key, value, level)
+	}
+
+	list.lock(lockSet)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, false, ErrReadOnly
+	}
+	if list.reservations != nil && list.reserved(key) {
+		return nil, false, ErrRangeReserved
+	}
+	if list.frozenRanges != nil && list.rangeFrozen(key) {
+		return nil, false, ErrRangeFrozen
+	}
 
 	var element *Element
-	prevs := list.getPrevElementNodes(key)
+	prevs := list.getInsertPrevElementNodes(key)
 
-	if element = prevs[0].Next(); element != nil && bytes.Compare(element.key, key) <= 0 {
-		element.value = value
-		return element
+	if element = prevs[0].Next(); element != nil && list.compare(element.key, key) <= 0 {
+		switch {
+		case element.IsTombstone() || element.expired():
+			// A tombstone or an expired element stands for an absent key, which the write inserts.
+			if create != nil {
+				value = create()
+			}
+			if list.exceedsQuota(list.growth(element, value)) {
+				return nil, false, ErrQuotaExceeded
+			}
+			list.update(element, value)
+			element.expires.Store(expires)
+			return element, true, nil
+		case merge != nil:
+			merged := merge(key, element.Value(), value)
+			if list.exceedsQuota(list.growth(element, merged)) {
+				return nil, false, ErrQuotaExceeded
+			}
+			list.update(element, merged)
+		case create == nil:
+			if list.exceedsQuota(list.growth(element, value)) {
+				return nil, false, ErrQuotaExceeded
+			}
+			list.update(element, value)
+			element.expires.Store(expires)
+		}
+		return element, false, nil
 	}
 
-	element = &Element{
-		elementNode: elementNode{
-			list: list,
-			next: make([]unsafe.Pointer, list.randLevel()),
-		},
-		key:   key,
-		value: value,
+	if list.capacity > 0 && list.Length >= list.capacity {
+		return nil, false, ErrFull
+	}
+	if fresh == nil && create != nil {
+		value = create()
+	}
+	if list.exceedsQuota(int64(len(key)) + list.sizeValue(value)) {
+		return nil, false, ErrQuotaExceeded
+	}
+	if fresh == nil {
+		fresh = list.allocate(key, value, level)
 	}
 
-	for i := range element.next {
-		atomic.StorePointer(&element.next[i], prevs[i].next[i])
-		atomic.StorePointer(&prevs[i].next[i], unsafe.Pointer(element))
+	element, violation = list.insertElement(prevs, fresh)
+	if expires != 0 {
+		element.expires.Store(expires)
 	}
+	return element, true, nil
+}
 
-	list.Length++
+// insert creates an element and links it after the previous nodes found by a search, returning
+// any order violation found when the list verifies inserts. The caller must hold the list mutex.
+func (list *SkipList) insert(prevs []*elementNode, key []byte, value interface{}) (*Element, *OrderViolation) {
+	return list.insertElement(prevs, list.allocate(key, value, list.randLevel()))
+}
+
+// insertElement is insert for an element already created by allocate.
+func (list *SkipList) insertElement(prevs []*elementNode, element *Element) (*Element, *OrderViolation) {
+	violation := list.linkNew(prevs, element)
+	if list.onInsert != nil {
+		list.onInsert(element.key, element.Value())
+	}
+	if list.watchers != nil {
+		list.emit(EventInsert, element.key, element.Value(), element.Seq())
+	}
+	return element, violation
+}
+
+// linkNew links a new element without calling the insert hook, for elements such as tombstones
+// that do not hold a value, returning any order violation. The caller must hold the list mutex.
+func (list *SkipList) linkNew(prevs []*elementNode, element *Element) *OrderViolation {
+	list.link(prevs, element)
+	if list.onOrderViolation != nil {
+		return list.verifyInsert(prevs, element)
+	}
+	return nil
+}
+
+// allocate creates an element of key and value with a tower of the given height, in the list's
+// arena if it has one, in which case the caller must hold the list mutex.
+func (list *SkipList) allocate(key []byte, value interface{}, level int) *Element {
+	var element *Element
+	if list.arena != nil {
+		element = list.arena.newElement(list, key, value, level)
+	} else {
+		element = newElement(list, key, value, level)
+	}
+	element.weight = list.weigh(key, value)
 	return element
 }
 
+// IsEmpty reports whether the list has no elements. Like Front, it does not lock the list.
+func (list *SkipList) IsEmpty() bool {
+	return list.Front() == nil
+}
+
+// MinKey returns the smallest key in the list, or nil if the list is empty.
+// It does not search or lock the list.
+func (list *SkipList) MinKey() []byte {
+	if front := list.Front(); front != nil {
+		return front.key
+	}
+	return nil
+}
+
+// MaxKey returns the largest key in the list, or nil if the list is empty.
+// The last element is cached as the list changes, so this does not search or lock the list.
+func (list *SkipList) MaxKey() []byte {
+	if last := list.lastElement(); last != nil {
+		return last.key
+	}
+	return nil
+}
+
+// lastElement returns the last element of the list, or nil if it is empty.
+// Unlike tails, it is safe to call without holding the list mutex.
+func (list *SkipList) lastElement() *Element {
+	return (*Element)(atomic.LoadPointer(&list.last))
+}
+
+// Len returns the number of elements in the list. Unlike reading Length, it is safe to call
+// concurrently with writes.
+func (list *SkipList) Len() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.Length
+}
+
 // Get finds an element by key. It returns element pointer if found, nil if not found.
-// Locking is optimistic and happens only after searching with a fast check for deletion after locking.
+// Get does not lock the list, so readers never wait for writers or for each other, except to
+// reclaim the element of key if it has expired, which Get treats as absent.
 func (list *SkipList) Get(key []byte) *Element {
-	list.mutex.Lock()
-	defer list.mutex.Unlock()
+	if list.metrics != nil {
+		defer list.observeLatency("Get", time.Now())
+	}
+	if list.hotKeys != nil {
+		list.hotKeys.record(key)
+	}
 
 	var prev *elementNode = &list.elementNode
 	var next *Element
 
-	for i := list.maxLevel - 1; i >= 0; i-- {
-		next = prev.NextAt(i)
+	steps := 0
+	if list.fingerSearch {
+		var last *Element
+		if last, steps = list.fingerSearchLess(key, false); last != nil {
+			prev = &last.elementNode
+		}
+		next = prev.Next()
+	} else {
+		for i := list.maxLevel - 1; i >= 0; i-- {
+			next = prev.NextAt(i)
 
-		for next != nil && bytes.Compare(key, next.key) > 0 {
-			prev = &next.elementNode
-			next = next.NextAt(i)
+			for next != nil && list.compare(key, next.key) > 0 {
+				prev = &next.elementNode
+				next = next.NextAt(i)
+				steps++
+			}
 		}
 	}
 
-	if next != nil && bytes.Compare(next.key, key) <= 0 {
-		return next
+	found := next != nil && list.compare(next.key, key) <= 0
+	if found && next.expired() {
+		list.reclaim(next)
+		found = false
+	}
+	if list.metrics != nil {
+		list.metrics.ObserveSearchSteps(steps)
+		list.metrics.IncGet(found)
+	}
+
+	if !found {
+		return nil
+	}
+	if list.trackAccess {
+		list.sampleAccess(next)
 	}
+	return next
+}
 
-	return nil
+// Contains reports whether key is in the list and has not expired. Unlike Get, it neither
+// returns the element nor counts as an access for hot key or access tracking, and it does not
+// lock or allocate, which suits membership checks on read-heavy paths.
+func (list *SkipList) Contains(key []byte) bool {
+	element := list.find(key)
+	return element != nil && !element.expired()
+}
+
+// GetE is like Get, but returns an *Error wrapping ErrNotFound if the key is not in the list,
+// or ErrKeyTooLarge if the key could never have been stored.
+func (list *SkipList) GetE(key []byte) (*Element, error) {
+	if err := list.checkKey("Get", key); err != nil {
+		return nil, err
+	}
+
+	if element := list.Get(key); element != nil {
+		return element, nil
+	}
+	return nil, list.newError("Get", key, ErrNotFound)
 }
 
 // Remove deletes an element from the list.
 // Returns removed element pointer if found, nil if not found.
 // Locking is optimistic and happens only after searching with a fast check on adjacent nodes after locking.
 func (list *SkipList) Remove(key []byte) *Element {
-	list.mutex.Lock()
-	defer list.mutex.Unlock()
+	element, _ := list.RemoveE(key)
+	return element
+}
+
+// RemoveE is like Remove, but returns an *Error wrapping ErrNotFound if the key is not in the list,
+// or describing why the removal was rejected.
+func (list *SkipList) RemoveE(key []byte) (*Element, error) {
+	if list.metrics != nil {
+		defer list.observeLatency("Remove", time.Now())
+	}
+	if err := list.checkKey("Remove", key); err != nil {
+		return nil, err
+	}
+
+	element, value, err := list.remove(key)
+	if err == ErrReadOnly {
+		return list.frozenWrite("Remove", key, func(overflow *SkipList) (*Element, error) {
+			return overflow.RemoveE(key)
+		})
+	}
+	if err != nil {
+		return nil, list.newError("Remove", key, err)
+	}
+
+	if element == nil {
+		return nil, list.newError("Remove", key, ErrNotFound)
+	}
+
+	if list.metrics != nil {
+		list.metrics.IncRemove()
+	}
+	list.notifyRemoval(removal{element: element, value: value}, Removed)
+	return element, nil
+}
+
+// remove removes key, returning its element and the value it held.
+func (list *SkipList) remove(key []byte) (*Element, interface{}, error) {
+	// Violations are reported once the mutex is released, so that the callback may use the list.
+	var violation *OrderViolation
+	defer func() {
+		if violation != nil {
+			list.onOrderViolation(*violation)
+		}
+	}()
+
+	list.lock(lockRemove)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil && list.rangeFrozen(key) {
+		return nil, nil, ErrRangeFrozen
+	}
+
 	prevs := list.getPrevElementNodes(key)
 
 	// found the element, remove it
-	if element := prevs[0].Next(); element != nil && bytes.Compare(element.key, key) <= 0 {
-		for k := range element.next {
-			atomic.StorePointer(&prevs[k].next[k], atomic.LoadPointer(&element.next[k]))
+	if element := prevs[0].Next(); element != nil && list.compare(element.key, key) <= 0 {
+		value := element.Value()
+		switch {
+		case !list.tombstoneMode:
+			list.unlink(prevs, element)
+		case element.IsTombstone():
+			return nil, nil, nil
+		default:
+			list.bury(element)
 		}
-
-		list.Length--
-		return element
+		return element, value, nil
 	}
 
-	return nil
+	if list.tombstoneMode {
+		violation = list.insertTombstone(prevs, key)
+	}
+	return nil, nil, nil
 }
 
 // getPrevElementNodes is the private search mechanism that other functions use.
@@ -109,68 +491,636 @@ func (list *SkipList) getPrevElementNodes(key []byte) []*elementNode {
 
 	prevs := list.prevNodesCache
 
+	steps := 0
 	for i := list.maxLevel - 1; i >= 0; i-- {
 		next = prev.NextAt(i)
 
-		for next != nil && bytes.Compare(key, next.key) > 0 {
+		for next != nil && list.compare(key, next.key) > 0 {
 			prev = &next.elementNode
 			next = next.NextAt(i)
+			steps++
 		}
 
 		prevs[i] = prev
 	}
 
+	if list.metrics != nil {
+		list.metrics.ObserveSearchSteps(steps)
+	}
 	return prevs
 }
 
+// getInsertPrevElementNodes is getPrevElementNodes for inserts. When the workload has been
+// inserting in ascending key order and key sorts after the last element, the tails of each level
+// are already the previous nodes, and the search is skipped entirely.
+func (list *SkipList) getInsertPrevElementNodes(key []byte) []*elementNode {
+	if list.appendMode() {
+		if last := list.elementOf(list.tails[0]); last != nil && list.compare(key, last.key) > 0 {
+			copy(list.prevNodesCache, list.tails)
+			return list.prevNodesCache
+		}
+	}
+	return list.getPrevElementNodes(key)
+}
+
+// appendMode reports whether enough of the recent inserts were appends to the end of the list
+// for the tail fast path to pay off.
+func (list *SkipList) appendMode() bool {
+	return list.recentInserts >= appendModeMinInserts && list.recentAppends*8 >= list.recentInserts*7
+}
+
+// link inserts element after the previous nodes found by a search and updates the
+// list's bookkeeping. The caller must hold the list mutex.
+func (list *SkipList) link(prevs []*elementNode, element *Element) {
+	// Set the back pointer before the element becomes reachable, so that walking back from
+	// it is always possible. Its successor is pointed back at it once it is linked.
+	atomic.StorePointer(&element.prev, unsafe.Pointer(list.elementOf(prevs[0])))
+	if list.spans != nil {
+		list.linkSpans(prevs, element)
+	}
+
+	for i := range element.next {
+		atomic.StorePointer(&element.next[i], prevs[i].next[i])
+		atomic.StorePointer(&prevs[i].next[i], unsafe.Pointer(element))
+
+		if element.next[i] == nil {
+			list.tails[i] = &element.elementNode
+			if i == 0 {
+				atomic.StorePointer(&list.last, unsafe.Pointer(element))
+			}
+		}
+		list.levelCounts[i]++
+	}
+
+	if next := element.Next(); next != nil {
+		atomic.StorePointer(&next.prev, unsafe.Pointer(element))
+	}
+
+	// The recent counters decay so that appendMode follows changes in the workload.
+	if list.recentInserts == appendModeWindow {
+		list.recentInserts /= 2
+		list.recentAppends /= 2
+	}
+	element.seq.Store(list.nextSeq())
+	list.linkVersion.Add(1)
+	if list.trackAccess {
+		element.accessed.Store(time.Now().UnixNano())
+	}
+	if list.ghosts != nil {
+		list.ghosts.forget(element.key)
+	}
+	list.inserts++
+	list.recentInserts++
+	if element.next[0] == nil {
+		list.appends++
+		list.recentAppends++
+	}
+	list.Length++
+	list.weight += element.weight
+	list.keyBytes += int64(len(element.key))
+	list.valueBytes += list.sizeValue(element.Value())
+	list.nodeBytes += list.footprint(element)
+
+	if list.namespaces != nil && !element.IsTombstone() {
+		list.namespaces.inserted(element)
+	}
+}
+
+// update replaces the value of an element in the list. The caller must hold the list mutex.
+func (list *SkipList) update(element *Element, value interface{}) {
+	tombstone := element.IsTombstone()
+	switch {
+	case tombstone && list.onInsert != nil:
+		// Writing the key of a tombstone inserts it again.
+		list.onInsert(element.key, value)
+	case !tombstone && list.onUpdate != nil:
+		list.onUpdate(element.key, element.Value(), value)
+	}
+	list.store(element, value)
+
+	if list.watchers != nil {
+		t := EventUpdate
+		if tombstone {
+			t = EventInsert
+		}
+		list.emit(t, element.key, value, element.Seq())
+	}
+}
+
+// store is update without the hooks, for values such as those of tombstones that are not
+// written by the user.
+func (list *SkipList) store(element *Element, value interface{}) {
+	weight := list.weigh(element.key, value)
+	list.weight += weight - element.weight
+	element.weight = weight
+
+	if list.namespaces != nil {
+		list.namespaces.updated(element, value)
+	}
+	if list.history != nil {
+		list.recordVersion(element, 0)
+	}
+	if element.IsTombstone() {
+		list.tombstones--
+	}
+	list.valueBytes += list.sizeValue(value) - list.sizeValue(element.Value())
+	element.storeValue(value)
+	element.seq.Store(list.nextSeq())
+	if list.trackAccess {
+		element.accessed.Store(time.Now().UnixNano())
+	}
+}
+
+// nextSeq allocates the sequence number of a mutation. The caller must hold the list mutex.
+func (list *SkipList) nextSeq() uint64 {
+	list.seq++
+	return list.seq
+}
+
+// Seq returns the sequence number of the last mutation of the list.
+func (list *SkipList) Seq() uint64 {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.seq
+}
+
+// unlink removes element, given the previous nodes found by a search, and updates the
+// list's bookkeeping. The caller must hold the list mutex.
+func (list *SkipList) unlink(prevs []*elementNode, element *Element) {
+	if list.spans != nil {
+		list.unlinkSpans(prevs, element)
+	}
+	for k := range element.next {
+		atomic.StorePointer(&prevs[k].next[k], atomic.LoadPointer(&element.next[k]))
+
+		if list.tails[k] == &element.elementNode {
+			list.tails[k] = prevs[k]
+			if k == 0 {
+				atomic.StorePointer(&list.last, unsafe.Pointer(list.elementOf(prevs[0])))
+			}
+		}
+		list.levelCounts[k]--
+	}
+
+	if next := element.Next(); next != nil {
+		atomic.StorePointer(&next.prev, atomic.LoadPointer(&element.prev))
+	}
+
+	seq := list.nextSeq()
+	if list.history != nil {
+		list.recordVersion(element, seq)
+	}
+	if element.IsTombstone() {
+		list.tombstones--
+		// Followers that have seen the tombstone lose nothing when it goes.
+		if s := element.Seq(); s > list.lostSeq {
+			list.lostSeq = s
+		}
+	} else {
+		list.lostSeq = seq
+		if list.watchers != nil {
+			list.emit(EventDelete, element.key, element.Value(), seq)
+		}
+	}
+	element.seq.Store(seq)
+	list.linkVersion.Add(1)
+	list.Length--
+	list.weight -= element.weight
+	list.keyBytes -= int64(len(element.key))
+	list.valueBytes -= list.sizeValue(element.Value())
+	list.nodeBytes -= list.footprint(element)
+	if list.pinSites != nil {
+		delete(list.pinSites, element)
+	}
+
+	if list.namespaces != nil && !element.IsTombstone() {
+		list.namespaces.removed(element)
+	}
+}
+
+// elementOf returns the Element embedding node, or nil if node is the head of the list.
+func (list *SkipList) elementOf(node *elementNode) *Element {
+	if node == &list.elementNode {
+		return nil
+	}
+	// elementNode is the first field of Element, so they share an address.
+	return (*Element)(unsafe.Pointer(node))
+}
+
+// searchLess returns the last element whose key is strictly less than key, or less than
+// or equal to key when orEqual is set. Returns nil if no element sorts before key.
+//
+// Like every search that only follows next pointers, it is safe to call without holding the
+// list mutex. Links are published with atomic stores from the bottom level up, so an element
+// reachable on some level is already linked on every level below it, and an unlinked element
+// keeps pointing forward into the list, so a search standing on it still finds its way.
+func (list *SkipList) searchLess(key []byte, orEqual bool) *Element {
+	if list.fingerSearch {
+		last, _ := list.fingerSearchLess(key, orEqual)
+		return last
+	}
+
+	var prev *elementNode = &list.elementNode
+	var last *Element
+
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next := prev.NextAt(i)
+
+		for next != nil && list.before(next.key, key, orEqual) {
+			last = next
+			prev = &next.elementNode
+			next = next.NextAt(i)
+		}
+	}
+
+	return last
+}
+
+// fingerSearchLess is searchLess starting from a finger, which it leaves holding the new path.
+// It also returns the number of elements the search stepped over.
+func (list *SkipList) fingerSearchLess(key []byte, orEqual bool) (*Element, int) {
+	f := list.fingers.Get().(*finger)
+	defer list.fingers.Put(f)
+
+	// The version is read before the search, so that a path that raced with a write is not reused.
+	version := list.linkVersion.Load()
+	last, level := f.start(list, key, orEqual, version)
+	var prev *elementNode = &list.elementNode
+	if last != nil {
+		prev = &last.elementNode
+	}
+
+	steps := 0
+	for i := level; i >= 0; i-- {
+		next := prev.NextAt(i)
+
+		for next != nil && list.before(next.key, key, orEqual) {
+			last = next
+			prev = &next.elementNode
+			next = next.NextAt(i)
+			steps++
+		}
+
+		f.prevs[i] = list.elementOf(prev)
+	}
+
+	// Levels above where the search started are kept from the cached path, which still
+	// precedes key if the version is unchanged. Otherwise they are stale, and reset to the head.
+	if version != f.version {
+		for i := level + 1; i < len(f.prevs); i++ {
+			f.prevs[i] = nil
+		}
+		f.version = version
+	}
+	return last, steps
+}
+
+// countOnLevel counts the elements on the given level whose keys are in [from, to), stopping
+// once the count exceeds limit unless limit is negative. A nil bound leaves that side open.
+// Every level is a uniform sample of the list, so counts on higher levels estimate the count
+// on the bottom level at a fraction of the cost.
+func (list *SkipList) countOnLevel(level int, from, to []byte, limit int) int {
+	var prev *elementNode = &list.elementNode
+	next := prev.NextAt(level)
+	if from != nil {
+		for i := list.maxLevel - 1; i >= level; i-- {
+			next = prev.NextAt(i)
+			for next != nil && list.compare(next.key, from) < 0 {
+				prev = &next.elementNode
+				next = next.NextAt(i)
+			}
+		}
+	}
+
+	count := 0
+	for ; next != nil && (to == nil || list.compare(next.key, to) < 0); next = next.NextAt(level) {
+		count++
+		if limit >= 0 && count > limit {
+			break
+		}
+	}
+	return count
+}
+
+// find returns the element with the given key, or nil if there is none.
+func (list *SkipList) find(key []byte) *Element {
+	if element := list.searchGreaterOrEqual(key); element != nil && list.compare(element.key, key) == 0 {
+		return element
+	}
+	return nil
+}
+
+// searchGreaterOrEqual returns the first element whose key is greater than or equal to key,
+// or nil if every element sorts before key.
+func (list *SkipList) searchGreaterOrEqual(key []byte) *Element {
+	if last := list.searchLess(key, false); last != nil {
+		return last.Next()
+	}
+	return list.Front()
+}
+
+// Seek returns the first element whose key is greater than or equal to key,
+// or nil if every element sorts before key.
+func (list *SkipList) Seek(key []byte) *Element {
+	return list.searchGreaterOrEqual(key)
+}
+
+// SeekForPrev returns the last element whose key is less than or equal to key,
+// or nil if every element sorts after key.
+func (list *SkipList) SeekForPrev(key []byte) *Element {
+	return list.searchLess(key, true)
+}
+
+// KeysBetween calls fn with each key in [start, end) in order, until fn returns false.
+// A nil end leaves the range unbounded above. Only keys are visited; element values are never
+// loaded, which keeps the walk cheap for workloads that only need keys.
+// The list is not locked.
+func (list *SkipList) KeysBetween(start, end []byte, fn func(key []byte) bool) {
+	for element := list.searchGreaterOrEqual(start); element != nil; element = element.Next() {
+		if end != nil && list.compare(element.key, end) >= 0 {
+			return
+		}
+		if !fn(element.key) {
+			return
+		}
+	}
+}
+
+// ApproxSplitPoints proposes up to n keys that split the list into n+1 ranges of roughly equal
+// cardinality. Rather than walking every element, it uses the sparsest level that still holds
+// enough elements as a statistical sample of the whole list, which costs time proportional
+// to n rather than to the length of the list.
+func (list *SkipList) ApproxSplitPoints(n int) [][]byte {
+	if n <= 0 {
+		return nil
+	}
+
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	var sample []*Element
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		sample = sample[:0]
+		for e := list.NextAt(i); e != nil; e = e.NextAt(i) {
+			sample = append(sample, e)
+		}
+		// Oversample so that the random tower heights even out across the ranges.
+		if len(sample) > splitOversampling*(n+1) {
+			break
+		}
+	}
+
+	var points [][]byte
+	for i := 1; i <= n; i++ {
+		// The first sampled element only starts the first range, so it is never a split point.
+		idx := i * len(sample) / (n + 1)
+		if idx == 0 || (len(points) > 0 && bytes.Equal(points[len(points)-1], sample[idx].key)) {
+			continue
+		}
+		points = append(points, sample[idx].key)
+	}
+
+	return points
+}
+
+// SampleRange returns up to n elements chosen uniformly at random among those with
+// start <= key < end. A nil end leaves the range unbounded above.
+// The returned elements are in no particular order. Like iteration, it does not lock the list,
+// so concurrent writes may or may not be reflected in the sample.
+func (list *SkipList) SampleRange(start, end []byte, n int) []*Element {
+	if n <= 0 {
+		return nil
+	}
+
+	// Reservoir sampling over the range keeps this to a single pass without knowing its size.
+	sample := make([]*Element, 0, n)
+	seen := 0
+	for e := list.searchGreaterOrEqual(start); e != nil; e = e.Next() {
+		if end != nil && list.compare(e.key, end) >= 0 {
+			break
+		}
+
+		if seen < n {
+			sample = append(sample, e)
+		} else if j := int(list.randInt63() % int64(seen+1)); j < n {
+			sample[j] = e
+		}
+		seen++
+	}
+
+	return sample
+}
+
 // SetProbability changes the current P value of the list.
 // It doesn't alter any existing data, only changes how future insert heights are calculated.
 func (list *SkipList) SetProbability(newProbability float64) {
 	list.probability = newProbability
-	list.probTable = probabilityTable(list.probability, list.maxLevel)
+	list.probTable = tower.ProbabilityTable(list.probability, list.maxLevel)
 }
 
-func (list *SkipList) randLevel() (level int) {
-	// Our random number source only has Int63(), so we have to produce a float64 from it
-	// Reference: https://golang.org/src/math/rand/rand.go#L150
-	r := float64(list.randSource.Int63()) / (1 << 63)
+// randLevel draws the height of a new tower. It does not need the list mutex, so that writers
+// can draw heights before locking the list.
+func (list *SkipList) randLevel() int {
+	list.randMutex.Lock()
+	defer list.randMutex.Unlock()
 
-	level = 1
-	for level < list.maxLevel && r < list.probTable[level] {
-		level++
-	}
-	return
+	return tower.Level(list.randSource, list.probTable)
 }
 
-// probabilityTable calculates in advance the probability of a new node having a given level.
-// probability is in [0, 1], MaxLevel is (0, 64]
-// Returns a table of floating point probabilities that each level should be included during an insert.
-func probabilityTable(probability float64, MaxLevel int) (table []float64) {
-	for i := 1; i <= MaxLevel; i++ {
-		prob := math.Pow(probability, float64(i-1))
-		table = append(table, prob)
+// randInt63 draws a non-negative random number from the list's generator.
+func (list *SkipList) randInt63() int64 {
+	list.randMutex.Lock()
+	defer list.randMutex.Unlock()
+
+	return list.randSource.Int63()
+}
+
+// newRandSource returns a new generator of the kind chosen by the list's options, by default a
+// xorshift generator with a seed unique to the list.
+func (list *SkipList) newRandSource() rand.Source {
+	if list.newRand != nil {
+		return list.newRand()
 	}
-	return table
+	return tower.NewXorshift(tower.UniqueSeed())
 }
 
 // NewWithMaxLevel creates a new skip list with MaxLevel set to the provided number.
 // Returns a pointer to the new list.
 func NewWithMaxLevel(maxLevel int) *SkipList {
-	if maxLevel < 1 || maxLevel > 64 {
-		panic("maxLevel for a SkipList must be a positive integer <= 64")
+	return New(WithMaxLevel(maxLevel))
+}
+
+// NewForExpectedSize creates a new skip list sized for about n elements, adjusted by any
+// provided options. The maximum level is log_{1/p}(n) rounded up, the height above which towers
+// would rarely be built, where p is DefaultProbability unless set WithProbability.
+// DefaultProbability minimizes the expected search cost. An explicit WithMaxLevel takes
+// precedence, and n <= 0 keeps DefaultMaxLevel. Lists may grow past n, only searching more
+// slowly as they do.
+func NewForExpectedSize(n int, opts ...Option) *SkipList {
+	return New(append([]Option{func(list *SkipList) { list.expectedSize = n }}, opts...)...)
+}
+
+// maxLevelForSize returns log_{1/probability}(n) rounded up, clamped to [1, 64].
+func maxLevelForSize(n int, probability float64) int {
+	if probability <= 0 || probability >= 1 {
+		return DefaultMaxLevel
+	}
+	if n <= 1 {
+		return 1
+	}
+
+	level := int(math.Ceil(math.Log(float64(n)) / math.Log(1/probability)))
+	if level < 1 {
+		return 1
+	}
+	if level > 64 {
+		return 64
+	}
+	return level
+}
+
+// NewWithComparator creates a new skip list ordering keys by compare instead of bytes.Compare.
+// It is shorthand for New(WithComparator(compare)).
+func NewWithComparator(compare func(a, b []byte) int) *SkipList {
+	return New(WithComparator(compare))
+}
+
+// New creates a new skip list with default parameters, adjusted by any provided options.
+// Returns a pointer to the new list.
+func New(opts ...Option) *SkipList {
+	list := configure(opts...)
+
+	for _, w := range list.watermarks {
+		if w.Low >= w.High {
+			panic(list.String() + ": the low of a watermark must be less than its high")
+		}
+	}
+
+	if list.frozenPolicy == FrozenForward && list.overflow == nil {
+		panic(list.String() + ": the FrozenForward policy requires an overflow list")
 	}
 
-	return &SkipList{
-		elementNode:    elementNode{next: make([]unsafe.Pointer, DefaultMaxLevel)},
-		prevNodesCache: make([]*elementNode, DefaultMaxLevel),
-		maxLevel:       maxLevel,
-		randSource:     rand.New(rand.NewSource(time.Now().UnixNano())),
-		probability:    DefaultProbability,
-		probTable:      probabilityTable(DefaultProbability, DefaultMaxLevel),
+	list.elementNode = elementNode{next: make([]unsafe.Pointer, list.maxLevel)}
+	list.prevNodesCache = make([]*elementNode, list.maxLevel)
+	// tails holds the last node on each level, which is the head while the level is empty.
+	list.tails = make([]*elementNode, list.maxLevel)
+	list.levelCounts = make([]int, list.maxLevel)
+	for i := range list.tails {
+		list.tails[i] = &list.elementNode
+	}
+	if list.maxVersions > 0 {
+		list.history = list.newHistory()
+	}
+	list.fingers.New = list.newFinger
+	if list.rankIndex {
+		list.spans = make([]int, list.maxLevel)
+		list.rankCache = make([]int, list.maxLevel)
+	}
+	list.epoch.Store(&epoch{})
+
+	if list.statsSampling < 1 {
+		list.statsSampling = 1
+	}
+	if m, ok := list.metrics.(ListMetrics); ok {
+		list.metrics = m.ForList(list.name, list.labels)
 	}
+	if list.hotKeyCount > 0 {
+		list.hotKeys = newHotKeyTracker(list.hotKeyCount, uint64(list.statsSampling))
+	}
+	if list.ghostCapacity > 0 {
+		list.ghosts = newGhostList(list.ghostCapacity)
+	}
+	if list.sweeper != nil {
+		list.sweeper.done = make(chan struct{})
+		list.sweeper.stopped = make(chan struct{})
+		go list.sweeper.run(list)
+	}
+	return list
 }
 
-// New creates a new skip list with default parameters. Returns a pointer to the new list.
-func New() *SkipList {
-	return NewWithMaxLevel(DefaultMaxLevel)
+// configure applies opts to a list with the default parameters and resolves the parameters that
+// depend on each other, without allocating the list's structures or starting its goroutines, for
+// New and for the lists that only borrow its configuration, such as ConcurrentSkipList.
+func configure(opts ...Option) *SkipList {
+	list := &SkipList{
+		maxLevel:    DefaultMaxLevel,
+		probability: DefaultProbability,
+	}
+
+	for _, opt := range opts {
+		opt(list)
+	}
+	list.randSource = list.newRandSource()
+
+	if list.expectedSize > 0 {
+		list.maxLevel = maxLevelForSize(list.expectedSize, list.probability)
+	}
+
+	if list.compare == nil {
+		list.compare = bytes.Compare
+		list.byteOrder = true
+	}
+
+	if list.clampMaxLevel && (list.maxLevel < 1 || list.maxLevel > 64) {
+		requested := list.maxLevel
+		list.maxLevel = 1
+		if requested > 64 {
+			list.maxLevel = 64
+		}
+		if list.onMaxLevelClamped != nil {
+			list.onMaxLevelClamped(requested, list.maxLevel)
+		}
+	}
+
+	if list.maxLevel < 1 || list.maxLevel > 64 {
+		panic(list.String() + ": maxLevel for a SkipList must be a positive integer <= 64")
+	}
+	list.probTable = tower.ProbabilityTable(list.probability, list.maxLevel)
+	return list
+}
+
+// Name returns the name the list was constructed with, if any.
+func (list *SkipList) Name() string {
+	return list.name
+}
+
+// Labels returns a copy of the labels the list was constructed with.
+func (list *SkipList) Labels() map[string]string {
+	labels := make(map[string]string, len(list.labels))
+	for k, v := range list.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// String identifies the list by its name and labels, e.g. `skiplist "series" {shard=3}`.
+func (list *SkipList) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("skiplist")
+	if list.name != "" {
+		buf.WriteString(" ")
+		buf.WriteString(strconv.Quote(list.name))
+	}
+
+	if len(list.labels) > 0 {
+		keys := make([]string, 0, len(list.labels))
+		for k := range list.labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString(" {")
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(k)
+			buf.WriteString("=")
+			buf.WriteString(list.labels[k])
+		}
+		buf.WriteString("}")
+	}
+
+	return buf.String()
 }
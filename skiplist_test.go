@@ -3,9 +3,13 @@ package skiplist
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -67,7 +71,7 @@ func checkSanity(list *SkipList, t *testing.T) {
 		cnt := 1
 
 		for next.next[k] != nil {
-			if !(bytes.Compare(next.NextAt(k).key, next.key) >= 0) {
+			if !(list.compare(next.NextAt(k).key, next.key) >= 0) {
 				t.Fatalf("next key value must be greater than prev key value. [next:%v] [prev:%v]", next.NextAt(k).key, next.key)
 			}
 
@@ -80,6 +84,12 @@ func checkSanity(list *SkipList, t *testing.T) {
 		}
 
 		if k == 0 {
+			for e := list.Front(); e != nil; e = e.Next() {
+				if next := e.Next(); next != nil && next.Prev() != e {
+					t.Fatalf("back pointer of %v must point to %v", next.key, e.key)
+				}
+			}
+
 			if cnt != list.Length {
 				t.Fatalf("list len must match the level 0 nodes count. [cur:%v] [level0:%v]", cnt, list.Length)
 			}
@@ -113,15 +123,15 @@ func TestBasicIntCRUD(t *testing.T) {
 	v5 := list.Get([]byte("90"))
 	v6 := list.Get([]byte("0"))
 
-	if v1 == nil || v1.value.(int) != 1 || bytes.Compare(v1.key, []byte("10")) != 0 {
+	if v1 == nil || v1.Value().(int) != 1 || bytes.Compare(v1.key, []byte("10")) != 0 {
 		t.Fatal(`wrong "10" value (expected "1")`, v1)
 	}
 
-	if v2 == nil || v2.value.(int) != 2 {
+	if v2 == nil || v2.Value().(int) != 2 {
 		t.Fatal(`wrong "60" value (expected "2")`)
 	}
 
-	if v3 == nil || v3.value.(int) != 9 {
+	if v3 == nil || v3.Value().(int) != 9 {
 		t.Fatal(`wrong "30" value (expected "9")`)
 	}
 
@@ -129,7 +139,7 @@ func TestBasicIntCRUD(t *testing.T) {
 		t.Fatal(`found value for key "20", which should have been deleted`)
 	}
 
-	if v5 == nil || v5.value.(int) != 5 {
+	if v5 == nil || v5.Value().(int) != 5 {
 		t.Fatal(`wrong "90" value`)
 	}
 
@@ -162,7 +172,7 @@ func TestChangeLevel(t *testing.T) {
 	}
 
 	for c := list.Front(); c != nil; c = c.Next() {
-		if orderedKeyValue(c.key)*10 != c.value.(uint64) {
+		if orderedKeyValue(c.key)*10 != c.Value().(uint64) {
 			t.Fatal("wrong list element value")
 		}
 	}
@@ -181,6 +191,85 @@ func TestChangeProbability(t *testing.T) {
 	}
 }
 
+func TestMaxLevelAboveDefault(t *testing.T) {
+	list := NewWithMaxLevel(32)
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	checkSanity(list, t)
+}
+
+func TestNameAndLabels(t *testing.T) {
+	list := New(WithName("series"), WithLabels(map[string]string{"shard": "3", "ns": "metrics"}))
+
+	if list.Name() != "series" {
+		t.Fatal("wrong list name", list.Name())
+	}
+
+	if list.Labels()["shard"] != "3" {
+		t.Fatal("wrong list labels", list.Labels())
+	}
+
+	if s := list.String(); s != `skiplist "series" {ns=metrics, shard=3}` {
+		t.Fatal("wrong list description", s)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), `"bad"`) {
+			t.Fatal("expected a panic naming the list, got", r)
+		}
+	}()
+	New(WithName("bad"), WithMaxLevel(0))
+}
+
+func TestMaxLevelClamping(t *testing.T) {
+	var warnings [][2]int
+	warn := func(requested, clamped int) {
+		warnings = append(warnings, [2]int{requested, clamped})
+	}
+
+	if list := New(WithMaxLevel(0), WithMaxLevelClamping(warn)); list.maxLevel != 1 {
+		t.Fatal("a level below the range must be clamped to 1", list.maxLevel)
+	}
+	list := New(WithMaxLevel(100), WithMaxLevelClamping(warn))
+	if list.maxLevel != 64 {
+		t.Fatal("a level above the range must be clamped to 64", list.maxLevel)
+	}
+	list.Set([]byte("a"), 1)
+	checkSanity(list, t)
+
+	if list := New(WithMaxLevel(8), WithMaxLevelClamping(warn)); list.maxLevel != 8 {
+		t.Fatal("a valid level must be kept", list.maxLevel)
+	}
+	if len(warnings) != 2 || warnings[0] != [2]int{0, 1} || warnings[1] != [2]int{100, 64} {
+		t.Fatal("wrong warnings", warnings)
+	}
+
+	if list := New(WithMaxLevel(-1), WithMaxLevelClamping(nil)); list.maxLevel != 1 {
+		t.Fatal("clamping must not require a warning callback", list.maxLevel)
+	}
+}
+
+func TestSequenceNumbers(t *testing.T) {
+	list := New()
+	a := list.Set([]byte("a"), 1)
+	b := list.Set([]byte("b"), 2)
+
+	if a.Seq() != 1 || b.Seq() != 2 || list.Seq() != 2 {
+		t.Fatal("inserts must be stamped in order", a.Seq(), b.Seq(), list.Seq())
+	}
+
+	list.Set([]byte("a"), 3)
+	if a.Seq() != 3 || b.Seq() != 2 {
+		t.Fatal("updates must restamp only the updated element", a.Seq(), b.Seq())
+	}
+
+	list.Remove([]byte("b"))
+	if b.Seq() != 4 || list.Seq() != 4 {
+		t.Fatal("removals must be stamped", b.Seq(), list.Seq())
+	}
+}
+
 func TestConcurrency(t *testing.T) {
 	list := New()
 
@@ -206,6 +295,101 @@ func TestConcurrency(t *testing.T) {
 	}
 }
 
+func TestKeysBetween(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	var keys []uint64
+	list.KeysBetween(orderedKey(10), orderedKey(20), func(key []byte) bool {
+		keys = append(keys, orderedKeyValue(key))
+		return true
+	})
+
+	if len(keys) != 10 || keys[0] != 10 || keys[9] != 19 {
+		t.Fatal("wrong keys in range", keys)
+	}
+
+	n := 0
+	list.KeysBetween(orderedKey(95), nil, func(key []byte) bool {
+		n++
+		return n < 3
+	})
+
+	if n != 3 {
+		t.Fatal("iteration must stop when fn returns false", n)
+	}
+}
+
+func TestApproxSplitPoints(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 10000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	points := list.ApproxSplitPoints(3)
+	if len(points) != 3 {
+		t.Fatal("wrong number of split points", len(points))
+	}
+
+	prev := uint64(0)
+	for _, p := range points {
+		v := orderedKeyValue(p)
+		if v <= prev {
+			t.Fatal("split points must be increasing", v, prev)
+		}
+		// Each range should hold a quarter of the keys; allow for sampling noise.
+		if v-prev < 1000 || v-prev > 4000 {
+			t.Fatal("unbalanced split point", v, prev)
+		}
+		prev = v
+	}
+
+	if 10000-prev < 1000 || 10000-prev > 4000 {
+		t.Fatal("unbalanced last range", prev)
+	}
+
+	small := New()
+	small.Set([]byte("a"), 1)
+	small.Set([]byte("b"), 2)
+	if points := small.ApproxSplitPoints(5); len(points) != 1 || string(points[0]) != "b" {
+		t.Fatal("wrong split points for a small list", points)
+	}
+
+	if points := New().ApproxSplitPoints(5); len(points) != 0 {
+		t.Fatal("an empty list has no split points", points)
+	}
+}
+
+func TestSampleRange(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	sample := list.SampleRange(orderedKey(100), orderedKey(200), 10)
+	if len(sample) != 10 {
+		t.Fatal("wrong sample size", len(sample))
+	}
+
+	seen := make(map[uint64]bool)
+	for _, e := range sample {
+		v := e.Value().(uint64)
+		if v < 100 || v >= 200 {
+			t.Fatal("sampled element outside of the range", v)
+		}
+		if seen[v] {
+			t.Fatal("element sampled twice", v)
+		}
+		seen[v] = true
+	}
+
+	if sample = list.SampleRange(orderedKey(995), nil, 10); len(sample) != 5 {
+		t.Fatal("sample of a small range must contain the whole range", len(sample))
+	}
+}
+
 func BenchmarkIncSet(b *testing.B) {
 	b.ReportAllocs()
 	list := New()
@@ -229,6 +413,19 @@ func BenchmarkIncGet(b *testing.B) {
 	b.SetBytes(int64(b.N))
 }
 
+func BenchmarkKeysBetween(b *testing.B) {
+	b.ReportAllocs()
+	n := 0
+	for i := 0; i < b.N; i++ {
+		benchList.KeysBetween(benchKey(i), benchKey(i+100), func(key []byte) bool {
+			n++
+			return true
+		})
+	}
+
+	b.SetBytes(int64(b.N))
+}
+
 func BenchmarkDecSet(b *testing.B) {
 	b.ReportAllocs()
 	list := New()
@@ -251,3 +448,469 @@ func BenchmarkDecGet(b *testing.B) {
 
 	b.SetBytes(int64(b.N))
 }
+
+func TestComparatorDescending(t *testing.T) {
+	list := NewWithComparator(func(a, b []byte) int {
+		return bytes.Compare(b, a)
+	})
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	list.Remove(orderedKey(99))
+	checkSanity(list, t)
+
+	if v := list.Front().Value().(uint64); v != 98 {
+		t.Fatal("descending list must start with the largest key", v)
+	}
+
+	if e := list.Seek(orderedKey(1000)); e == nil || e.Value().(uint64) != 98 {
+		t.Fatal("Seek must follow the comparator", e)
+	}
+
+	var got []uint64
+	for it := list.Range(orderedKey(50), orderedKey(45)); it.Valid(); it.Next() {
+		got = append(got, it.Value().(uint64))
+	}
+	if len(got) != 5 || got[0] != 50 || got[4] != 46 {
+		t.Fatal("wrong descending range", got)
+	}
+}
+
+func TestComparatorComposite(t *testing.T) {
+	// Keys are an 8 byte timestamp followed by a variable length series ID. Ordering by
+	// timestamp first differs from byte order when IDs have different lengths.
+	compositeKey := func(ts uint64, id string) []byte {
+		return append(orderedKey(ts), id...)
+	}
+	list := New(WithComparator(func(a, b []byte) int {
+		if c := bytes.Compare(a[:8], b[:8]); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a[8:]), string(b[8:]))
+	}))
+
+	list.Set(compositeKey(2, "a"), 1)
+	list.Set(compositeKey(1, "b"), 2)
+	list.Set(compositeKey(1, "a"), 3)
+	list.Set(compositeKey(1, "a"), 4)
+	checkSanity(list, t)
+
+	var got []int
+	for e := list.Front(); e != nil; e = e.Next() {
+		got = append(got, e.Value().(int))
+	}
+	if len(got) != 3 || got[0] != 4 || got[1] != 2 || got[2] != 1 {
+		t.Fatal("wrong composite order", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("namespaces must be rejected on lists with a comparator")
+		}
+	}()
+	list.Namespace([]byte("x"))
+}
+
+func TestNewForExpectedSize(t *testing.T) {
+	cases := []struct {
+		n, maxLevel int
+	}{
+		{0, DefaultMaxLevel},
+		{1, 1},
+		{100, 5},
+		{1000000, 14},
+		{1 << 62, 43},
+	}
+	for _, c := range cases {
+		if list := NewForExpectedSize(c.n); list.maxLevel != c.maxLevel {
+			t.Fatalf("NewForExpectedSize(%d): expected maxLevel %d, got %d", c.n, c.maxLevel, list.maxLevel)
+		}
+	}
+
+	if list := NewForExpectedSize(1<<20, WithProbability(0.5)); list.maxLevel != 20 {
+		t.Fatal("the maximum level must follow the configured probability", list.maxLevel)
+	}
+	if list := NewForExpectedSize(1<<20, WithMaxLevel(8)); list.maxLevel != 8 {
+		t.Fatal("an explicit maximum level must take precedence", list.maxLevel)
+	}
+
+	list := NewForExpectedSize(100)
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	checkSanity(list, t)
+}
+
+func TestContains(t *testing.T) {
+	for _, list := range []*SkipList{New(), New(WithFingerSearch(), WithHotKeyTracking(4))} {
+		for i := uint64(0); i < 100; i += 2 {
+			list.Set(orderedKey(i), i)
+		}
+
+		for i := uint64(0); i < 100; i++ {
+			if list.Contains(orderedKey(i)) != (i%2 == 0) {
+				t.Fatal("wrong membership of", i)
+			}
+		}
+
+		key := orderedKey(50)
+		if allocs := testing.AllocsPerRun(100, func() { list.Contains(key) }); allocs != 0 {
+			t.Fatal("Contains must not allocate", allocs)
+		}
+	}
+}
+
+func TestMinMaxKey(t *testing.T) {
+	list := New()
+	if !list.IsEmpty() || list.MinKey() != nil || list.MaxKey() != nil || list.Back() != nil {
+		t.Fatal("a new list must be empty")
+	}
+
+	for _, i := range []uint64{50, 10, 90, 30} {
+		list.Set(orderedKey(i), i)
+	}
+	if list.IsEmpty() || orderedKeyValue(list.MinKey()) != 10 || orderedKeyValue(list.MaxKey()) != 90 {
+		t.Fatal("wrong min or max key", list.MinKey(), list.MaxKey())
+	}
+
+	list.Remove(orderedKey(90))
+	list.Remove(orderedKey(10))
+	if orderedKeyValue(list.MinKey()) != 30 || orderedKeyValue(list.MaxKey()) != 50 {
+		t.Fatal("min and max must follow removals", list.MinKey(), list.MaxKey())
+	}
+	if back := list.Back(); back == nil || back.Value() != uint64(50) || back.Next() != nil || back.Prev().Value() != uint64(30) {
+		t.Fatal("Back must return the last element", back)
+	}
+
+	list.Remove(orderedKey(30))
+	list.Remove(orderedKey(50))
+	if !list.IsEmpty() || list.MinKey() != nil || list.MaxKey() != nil || list.Back() != nil {
+		t.Fatal("a drained list must be empty")
+	}
+}
+
+func TestInlineTower(t *testing.T) {
+	for level := 1; level <= 64; level++ {
+		element := allocElement(level)
+		if len(element.next) != level || cap(element.next) < level {
+			t.Fatal("wrong tower", level, len(element.next), cap(element.next))
+		}
+		element.next[level-1] = unsafe.Pointer(element)
+	}
+
+	list := New()
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		keys[i] = orderedKey(uint64(i))
+	}
+	i := 0
+	allocs := testing.AllocsPerRun(len(keys)-1, func() {
+		list.Set(keys[i], nil)
+		i++
+	})
+	if allocs > 1 {
+		t.Fatal("an insert must allocate the element and its tower together", allocs)
+	}
+	checkSanity(list, t)
+}
+
+func TestGetOrCreate(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+
+	element, created := list.GetOrCreate([]byte("a"), func() interface{} {
+		t.Fatal("create must not be called for an existing key")
+		return nil
+	})
+	if created || element.Value() != 1 {
+		t.Fatal("GetOrCreate must return the existing element")
+	}
+
+	var wg sync.WaitGroup
+	var calls, creations int32
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			element, created := list.GetOrCreate([]byte("b"), func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				return 2
+			})
+			if created {
+				atomic.AddInt32(&creations, 1)
+			}
+			if element.Value() != 2 {
+				t.Error("wrong value", element.Value())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 || creations != 1 || list.Len() != 2 {
+		t.Fatal("the element must be created exactly once", calls, creations, list.Len())
+	}
+	checkSanity(list, t)
+}
+
+func TestFingerSearch(t *testing.T) {
+	list := New(WithFingerSearch())
+	for i := uint64(0); i < 1000; i += 2 {
+		list.Set(orderedKey(i), i)
+	}
+
+	check := func(i uint64) {
+		t.Helper()
+		want := i
+		if i%2 == 1 {
+			want = i + 1
+		}
+		if e := list.Seek(orderedKey(i)); want >= 1000 && e != nil || want < 1000 && orderedKeyValue(e.Key()) != want {
+			t.Fatal("wrong Seek result", i, e)
+		}
+		if e := list.SeekForPrev(orderedKey(i)); orderedKeyValue(e.Key()) != i-i%2 {
+			t.Fatal("wrong SeekForPrev result", i, e.Key())
+		}
+		if (list.Get(orderedKey(i)) != nil) != (i%2 == 0) {
+			t.Fatal("wrong Get result", i)
+		}
+	}
+
+	// Nearby keys in both directions, then distant ones.
+	for i := uint64(0); i < 999; i++ {
+		check(i)
+	}
+	for i := uint64(998); i > 0; i-- {
+		check(i)
+	}
+	for i := uint64(0); i < 999; i += 97 {
+		check(998 - i)
+		check(i)
+	}
+
+	// Writes between lookups must not leave a stale path in use.
+	for i := uint64(1); i < 999; i += 2 {
+		check(i)
+		list.Set(orderedKey(i), i)
+		if e := list.Seek(orderedKey(i)); e == nil || orderedKeyValue(e.Key()) != i {
+			t.Fatal("inserted key must be found", i)
+		}
+		list.Remove(orderedKey(i))
+		check(i)
+	}
+}
+
+func BenchmarkFingerGet(b *testing.B) {
+	list := New(WithFingerSearch())
+	for i := 0; i < 100000; i += 2 {
+		list.Set(benchKey(i), nil)
+	}
+
+	// A writer churns the odd keys, invalidating cached paths, while readers seek and iterate.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 1; ; i = (i + 2) % 100000 {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			list.Set(benchKey(i), nil)
+			list.Remove(benchKey(i))
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		it := list.NewIterator()
+		defer it.Release()
+		for i := 0; pb.Next(); i += 2 {
+			it.Seek(benchKey(i % 100000))
+			for n := 0; n < 4 && it.Valid(); n++ {
+				it.Next()
+			}
+			if list.Get(benchKey(i%100000)) == nil {
+				b.Error("failed to Get an element that should exist")
+				return
+			}
+		}
+	})
+}
+
+func TestFingerSearchConcurrent(t *testing.T) {
+	list := New(WithFingerSearch())
+	for i := uint64(0); i < 1000; i += 2 {
+		list.Set(orderedKey(i), i)
+	}
+
+	// Readers seek, iterate and get through their own region of the list while the odd keys
+	// are inserted and removed under them.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g uint64) {
+			defer wg.Done()
+			it := list.NewIterator()
+			defer it.Release()
+			for n := 0; n < 20; n++ {
+				for i := g * 250; i < (g+1)*250; i += 2 {
+					if list.Get(orderedKey(i)) == nil {
+						t.Error("key present throughout was not found", i)
+						return
+					}
+					it.Seek(orderedKey(i + 1))
+					for it.Valid() && orderedKeyValue(it.Key())%2 == 1 {
+						it.Next()
+					}
+					if !it.Valid() && i+2 < 1000 || it.Valid() && orderedKeyValue(it.Key()) != i+2 {
+						t.Error("iteration must reach the next key present throughout", i)
+						return
+					}
+					if e := list.SeekForPrev(orderedKey(i)); e == nil || orderedKeyValue(e.Key()) != i {
+						t.Error("SeekForPrev must find a key present throughout", i)
+						return
+					}
+				}
+			}
+		}(uint64(g))
+	}
+
+	for n := 0; n < 5; n++ {
+		for i := uint64(1); i < 1000; i += 2 {
+			list.Set(orderedKey(i), i)
+		}
+		for i := uint64(1); i < 1000; i += 2 {
+			list.Remove(orderedKey(i))
+		}
+	}
+	wg.Wait()
+	checkSanity(list, t)
+}
+
+func TestMerge(t *testing.T) {
+	if _, err := New().MergeE([]byte("a"), 1); !errors.Is(err, ErrNoMergeOperator) {
+		t.Fatal("expected ErrNoMergeOperator, got", err)
+	}
+
+	list := New(WithMergeOperator(func(key []byte, existing, operand interface{}) interface{} {
+		return existing.(int) + operand.(int)
+	}))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				list.Merge([]byte{byte('a' + i%4)}, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if list.Len() != 4 {
+		t.Fatal("wrong length", list.Len())
+	}
+	for e := list.Front(); e != nil; e = e.Next() {
+		if e.Value() != 2000 {
+			t.Fatal("merges were lost", e.Key(), e.Value())
+		}
+	}
+	checkSanity(list, t)
+}
+
+func TestElementStability(t *testing.T) {
+	list := New(WithMergeOperator(func(key []byte, existing, operand interface{}) interface{} {
+		return existing.(int) + operand.(int)
+	}), WithTombstones())
+	key := []byte("a")
+	element := list.Set(key, 1)
+
+	writes := map[string]func() *Element{
+		"Set":        func() *Element { return list.Set(key, 2) },
+		"Merge":      func() *Element { return list.Merge(key, 1) },
+		"SetWithTTL": func() *Element { return list.SetWithTTL(key, 4, time.Hour) },
+		"Update": func() *Element {
+			return list.Update(key, func(old interface{}) (interface{}, bool) { return 5, true })
+		},
+		"GetOrCreate": func() *Element {
+			e, _ := list.GetOrCreate(key, func() interface{} { return 6 })
+			return e
+		},
+	}
+	for name, write := range writes {
+		if e := write(); e != element {
+			t.Fatal(name, "replaced the element")
+		}
+	}
+
+	var batch WriteBatch
+	batch.Set(key, 7)
+	if err := list.Apply(&batch); err != nil || list.Get(key) != element || element.Value() != 7 {
+		t.Fatal("Apply replaced the element", err)
+	}
+	list.TransformValues(func(key []byte, value interface{}) interface{} { return 8 })
+	if list.Get(key) != element || element.Value() != 8 {
+		t.Fatal("TransformValues replaced the element")
+	}
+
+	// A tombstone keeps the element, which a later write revives.
+	list.Remove(key)
+	if e := list.Set(key, 9); e != element {
+		t.Fatal("Set after Remove replaced the tombstone's element")
+	}
+}
+
+func TestRandOptions(t *testing.T) {
+	heights := func(list *SkipList) []int {
+		for i := uint64(0); i < 1000; i++ {
+			list.Set(orderedKey(i), i)
+		}
+		var h []int
+		for e := list.Front(); e != nil; e = e.Next() {
+			h = append(h, len(e.next))
+		}
+		return h
+	}
+	equal := func(a, b []int) bool {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+
+	if !equal(heights(New(WithSeededRand(42))), heights(New(WithSeededRand(42)))) {
+		t.Fatal("lists seeded alike must have the same structure")
+	}
+	if equal(heights(New(WithSeededRand(42))), heights(New(WithSeededRand(43)))) {
+		t.Fatal("lists seeded differently must not have the same structure")
+	}
+	// Lists created back to back must not share a seed.
+	a, b := New(), New()
+	if equal(heights(a), heights(b)) {
+		t.Fatal("default lists have the same structure")
+	}
+	if equal(heights(New(WithCryptoSeededRand())), heights(New(WithCryptoSeededRand()))) {
+		t.Fatal("crypto seeded lists have the same structure")
+	}
+	checkSanity(New(WithCryptoSeededRand()), t)
+}
+
+func TestCapacity(t *testing.T) {
+	list := New(WithCapacity(2))
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 1)
+
+	if _, err := list.SetE([]byte("c"), 1); !errors.Is(err, ErrFull) {
+		t.Fatal("insert into a full list must fail", err)
+	}
+	if e, created := list.GetOrCreate([]byte("c"), func() interface{} { return 1 }); e != nil || created {
+		t.Fatal("GetOrCreate inserted into a full list")
+	}
+	if _, err := list.SetE([]byte("a"), 2); err != nil {
+		t.Fatal("update of a full list must succeed", err)
+	}
+
+	list.Remove([]byte("a"))
+	if _, err := list.SetE([]byte("c"), 1); err != nil || list.Len() != 2 {
+		t.Fatal("insert after a removal must succeed", err)
+	}
+}
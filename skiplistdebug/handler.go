@@ -0,0 +1,105 @@
+// Package skiplistdebug helps inspect and validate live skip lists: it serves the internals of a
+// list over HTTP for inspection, and checks a list against a trusted reference with Shadow.
+package skiplistdebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+const (
+	// DefaultDumpLimit is the number of elements a dump returns unless asked otherwise.
+	DefaultDumpLimit = 100
+	// MaxDumpLimit bounds the number of elements a single dump can return.
+	MaxDumpLimit = 10000
+)
+
+// Record is a single element of a range dump.
+type Record struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// Levels is the level histogram of a list.
+type Levels struct {
+	MaxLevel int   `json:"maxLevel"`
+	Counts   []int `json:"counts"`
+}
+
+// Handler returns an http.Handler that serves JSON views of list. The view is chosen by the
+// last element of the request path:
+//
+//	.../stats   the list's Stats (also served for any other path)
+//	.../levels  the number of elements on each level
+//	.../dump    elements in [start, end), at most limit of them, taken from the query string
+//
+// Values that cannot be encoded as JSON are dumped in their fmt representation.
+func Handler(list *skiplist.SkipList) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "levels":
+			stats := list.Stats()
+			writeJSON(w, Levels{MaxLevel: stats.MaxLevel, Counts: stats.LevelCounts})
+		case "dump":
+			serveDump(w, r, list)
+		default:
+			writeJSON(w, list.Stats())
+		}
+	})
+}
+
+func serveDump(w http.ResponseWriter, r *http.Request, list *skiplist.SkipList) {
+	query := r.URL.Query()
+
+	limit := DefaultDumpLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit: "+s, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if limit > MaxDumpLimit {
+		limit = MaxDumpLimit
+	}
+
+	var end []byte
+	if query.Has("end") {
+		end = []byte(query.Get("end"))
+	}
+
+	records := make([]Record, 0, limit)
+	it := list.NewIterator()
+	for it.Seek([]byte(query.Get("start"))); it.Valid() && len(records) < limit; it.Next() {
+		if end != nil && bytes.Compare(it.Key(), end) >= 0 {
+			break
+		}
+		records = append(records, Record{Key: string(it.Key()), Value: jsonValue(it.Value())})
+	}
+
+	writeJSON(w, records)
+}
+
+// jsonValue returns value if it can be encoded as JSON, or its fmt representation otherwise.
+func jsonValue(value interface{}) interface{} {
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return value
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
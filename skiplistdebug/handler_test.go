@@ -0,0 +1,58 @@
+package skiplistdebug
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+func get(t *testing.T, list *skiplist.SkipList, url string, v interface{}) {
+	rec := httptest.NewRecorder()
+	Handler(list).ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+
+	if rec.Code != 200 {
+		t.Fatal("unexpected status", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	list := skiplist.New(skiplist.WithName("debug"))
+	for _, k := range []string{"a", "b", "c", "d"} {
+		list.Set([]byte(k), k+k)
+	}
+	list.Set([]byte("e"), func() {})
+
+	var stats skiplist.Stats
+	get(t, list, "/debug/skiplist/stats", &stats)
+	if stats.Name != "debug" || stats.Length != 5 {
+		t.Fatal("wrong stats", stats)
+	}
+
+	var levels Levels
+	get(t, list, "/debug/skiplist/levels", &levels)
+	if levels.MaxLevel != skiplist.DefaultMaxLevel || levels.Counts[0] != 5 {
+		t.Fatal("wrong levels", levels)
+	}
+
+	var records []Record
+	get(t, list, "/debug/skiplist/dump?start=b&end=d", &records)
+	if len(records) != 2 || records[0].Key != "b" || records[0].Value != "bb" || records[1].Key != "c" {
+		t.Fatal("wrong range dump", records)
+	}
+
+	get(t, list, "/debug/skiplist/dump?start=d&limit=5", &records)
+	if len(records) != 2 || records[1].Key != "e" || records[1].Value == nil {
+		t.Fatal("wrong dump of unencodable value", records)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(list).ServeHTTP(rec, httptest.NewRequest("GET", "/dump?limit=x", nil))
+	if rec.Code != 400 {
+		t.Fatal("invalid limits must be rejected", rec.Code)
+	}
+}
@@ -0,0 +1,184 @@
+package skiplistdebug
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+// Mismatch is a read whose result from the list differs from the reference's.
+type Mismatch struct {
+	// Op is the read, e.g. "Get".
+	Op string
+	// Key is the key read, or the key at which a range read diverged.
+	Key []byte
+	// Got is the list's result and Want the reference's, in fmt representation.
+	Got, Want string
+}
+
+func (m Mismatch) String() string {
+	return m.Op + " " + strconv.Quote(string(m.Key)) + ": got " + m.Got + ", want " + m.Want
+}
+
+// Shadow wraps a list to check it against a trusted reference, a sorted map, under real
+// traffic, as when trying new list options in staging. Writes go to both the list and the
+// reference, and the result of every read from the list is compared with the reference's, with
+// differences reported to a callback. Values are compared with reflect.DeepEqual. Reads return
+// the list's results.
+//
+// Writes are serialized, and reads exclude writes, so that both sides always hold the same
+// contents. This costs the list its concurrency, which is why shadowing is for staging. Every
+// write must go through the Shadow, and the list must not drop elements by itself, as eviction
+// and expiry do, or the differences are reported as mismatches.
+type Shadow struct {
+	list       *skiplist.SkipList
+	compare    func(a, b []byte) int
+	onMismatch func(Mismatch)
+
+	mutex  sync.RWMutex
+	keys   [][]byte
+	values map[string]interface{}
+}
+
+// NewShadow returns a Shadow of list, whose keys are ordered by compare, or bytes.Compare if
+// compare is nil. The reference starts with a copy of the list's contents. onMismatch is called
+// with every mismatch, with reads excluded from the list, so it must not use the Shadow.
+func NewShadow(list *skiplist.SkipList, compare func(a, b []byte) int, onMismatch func(Mismatch)) *Shadow {
+	if compare == nil {
+		compare = bytes.Compare
+	}
+	s := &Shadow{list: list, compare: compare, onMismatch: onMismatch, values: map[string]interface{}{}}
+	for element := list.Front(); element != nil; element = element.Next() {
+		s.keys = append(s.keys, element.Key())
+		s.values[string(element.Key())] = element.Value()
+	}
+	return s
+}
+
+// List returns the shadowed list.
+func (s *Shadow) List() *skiplist.SkipList {
+	return s.list
+}
+
+// Set sets key to value in the list and the reference.
+func (s *Shadow) Set(key []byte, value interface{}) *skiplist.Element {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element := s.list.Set(key, value)
+	if element != nil {
+		i, found := s.search(key)
+		if !found {
+			s.keys = append(s.keys, nil)
+			copy(s.keys[i+1:], s.keys[i:])
+			s.keys[i] = element.Key()
+		}
+		s.values[string(key)] = value
+	}
+	return element
+}
+
+// Remove removes key from the list and the reference, checking that it was in both or neither.
+func (s *Shadow) Remove(key []byte) *skiplist.Element {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element := s.list.Remove(key)
+	i, found := s.search(key)
+	if found {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+		delete(s.values, string(key))
+	}
+	if (element != nil) != found {
+		s.report("Remove", key, present(element != nil), present(found))
+	}
+	return element
+}
+
+// Get returns the element of key in the list, checking it against the reference.
+func (s *Shadow) Get(key []byte) *skiplist.Element {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	element := s.list.Get(key)
+	want, found := s.values[string(key)]
+	switch {
+	case element == nil && found:
+		s.report("Get", key, present(false), fmt.Sprint(want))
+	case element != nil && !found:
+		s.report("Get", key, fmt.Sprint(element.Value()), present(false))
+	case element != nil && !reflect.DeepEqual(element.Value(), want):
+		s.report("Get", key, fmt.Sprint(element.Value()), fmt.Sprint(want))
+	}
+	return element
+}
+
+// Len returns the length of the list, checking it against the reference.
+func (s *Shadow) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	n := s.list.Len()
+	if n != len(s.keys) {
+		s.report("Len", nil, strconv.Itoa(n), strconv.Itoa(len(s.keys)))
+	}
+	return n
+}
+
+// Range calls fn with each key in [start, end) of the list and its value, in order, until fn
+// returns false, checking each against the reference. A nil start or end leaves that side of the
+// range open. fn is called with writes excluded, so it must not write through the Shadow.
+func (s *Shadow) Range(start, end []byte, fn func(key []byte, value interface{}) bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	i := 0
+	if start != nil {
+		i, _ = s.search(start)
+	}
+	it := s.list.Range(start, end)
+	for ; it.Valid(); it.Next() {
+		if i == len(s.keys) || (end != nil && s.compare(s.keys[i], end) >= 0) {
+			s.report("Range", it.Key(), fmt.Sprint(it.Value()), present(false))
+			break
+		}
+		if s.compare(it.Key(), s.keys[i]) != 0 {
+			s.report("Range", it.Key(), strconv.Quote(string(it.Key())), strconv.Quote(string(s.keys[i])))
+			break
+		}
+		if want := s.values[string(s.keys[i])]; !reflect.DeepEqual(it.Value(), want) {
+			s.report("Range", it.Key(), fmt.Sprint(it.Value()), fmt.Sprint(want))
+		}
+		if !fn(it.Key(), it.Value()) {
+			return
+		}
+		i++
+	}
+	if !it.Valid() && i < len(s.keys) && (end == nil || s.compare(s.keys[i], end) < 0) {
+		s.report("Range", s.keys[i], present(false), fmt.Sprint(s.values[string(s.keys[i])]))
+	}
+}
+
+// search returns the position of key in the reference's keys, and whether it is there.
+func (s *Shadow) search(key []byte) (int, bool) {
+	i := sort.Search(len(s.keys), func(i int) bool { return s.compare(s.keys[i], key) >= 0 })
+	return i, i < len(s.keys) && s.compare(s.keys[i], key) == 0
+}
+
+func (s *Shadow) report(op string, key []byte, got, want string) {
+	if s.onMismatch != nil {
+		s.onMismatch(Mismatch{Op: op, Key: key, Got: got, Want: want})
+	}
+}
+
+func present(ok bool) string {
+	if ok {
+		return "present"
+	}
+	return "absent"
+}
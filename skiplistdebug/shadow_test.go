@@ -0,0 +1,54 @@
+package skiplistdebug
+
+import (
+	"testing"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+func TestShadow(t *testing.T) {
+	var mismatches []Mismatch
+	list := skiplist.New()
+	list.Set([]byte("a"), 1)
+	s := NewShadow(list, nil, func(m Mismatch) { mismatches = append(mismatches, m) })
+
+	s.Set([]byte("c"), 3)
+	s.Set([]byte("b"), 2)
+	s.Set([]byte("b"), 20)
+	s.Remove([]byte("a"))
+	s.Remove([]byte("z"))
+	if s.Get([]byte("b")).Value() != 20 || s.Get([]byte("a")) != nil || s.Len() != 2 {
+		t.Fatal("reads must return the list's results")
+	}
+	var keys []string
+	s.Range(nil, nil, func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if len(mismatches) != 0 || len(keys) != 2 {
+		t.Fatal("a correct list must not mismatch", mismatches, keys)
+	}
+
+	// Writes that bypass the shadow make the list diverge from the reference.
+	list.Set([]byte("b"), 21)
+	list.Set([]byte("d"), 4)
+	list.Remove([]byte("c"))
+	s.Get([]byte("b"))
+	s.Get([]byte("c"))
+	s.Len()
+	s.Range([]byte("b"), nil, func([]byte, interface{}) bool { return true })
+
+	want := []string{
+		`Get "b": got 21, want 20`,
+		`Get "c": got absent, want 3`,
+	}
+	if len(mismatches) != 4 || mismatches[0].String() != want[0] || mismatches[1].String() != want[1] {
+		t.Fatal("wrong mismatches", mismatches)
+	}
+	if m := mismatches[2]; m.Op != "Range" || string(m.Key) != "b" || m.Got != "21" {
+		t.Fatal("wrong range mismatch", m)
+	}
+	if m := mismatches[3]; m.Op != "Range" || string(m.Key) != "d" {
+		t.Fatal("wrong range mismatch", m)
+	}
+}
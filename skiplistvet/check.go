@@ -0,0 +1,261 @@
+// Package skiplistvet finds misuses of the skiplist package that break its concurrency
+// guarantees, which otherwise only show up under the race detector or as corrupted lists under
+// production load. It reports:
+//
+//   - reads and writes of SkipList.Length, which races with writers; Len is safe.
+//   - writes through the slices returned by Element.Key and Iterator.Key, which are the list's
+//     own keys and are read concurrently by every search.
+//   - calls on an element, other than Key and Seq, after its key was removed from its list in
+//     the same block, since the element no longer belongs to the list and its links are stale.
+//
+// The checks are syntactic and local to a function, so they miss misuses spread across
+// functions, but report few false positives. The skiplistvet command runs them under go vet.
+package skiplistvet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// PackagePath is the import path of the package whose uses are checked.
+const PackagePath = "github.com/m3db/fast-skiplist"
+
+// Diagnostic is a misuse found by Check.
+type Diagnostic struct {
+	Pos     token.Pos
+	Message string
+}
+
+// Check reports the misuses of the skiplist package in files, the syntax of pkg, whose types are
+// recorded in info. info must record Types, Defs, Uses and Selections. The skiplist package
+// itself is not checked, since it is the one place allowed to touch its internals.
+func Check(pkg *types.Package, files []*ast.File, info *types.Info) []Diagnostic {
+	if pkg.Path() == PackagePath {
+		return nil
+	}
+
+	c := &checker{info: info}
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				c.checkLength(n)
+			case *ast.FuncDecl:
+				if n.Body != nil {
+					c.checkKeyWrites(n.Body)
+				}
+			case *ast.BlockStmt:
+				c.checkRemovedElements(n.List)
+			case *ast.CaseClause:
+				c.checkRemovedElements(n.Body)
+			case *ast.CommClause:
+				c.checkRemovedElements(n.Body)
+			}
+			return true
+		})
+	}
+	return c.diagnostics
+}
+
+type checker struct {
+	info        *types.Info
+	diagnostics []Diagnostic
+}
+
+func (c *checker) report(pos token.Pos, message string) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{Pos: pos, Message: message})
+}
+
+// checkLength reports accesses to the Length field of a SkipList.
+func (c *checker) checkLength(sel *ast.SelectorExpr) {
+	selection := c.info.Selections[sel]
+	if selection == nil || selection.Kind() != types.FieldVal || selection.Obj().Name() != "Length" {
+		return
+	}
+	if isNamed(selection.Recv(), "SkipList") {
+		c.report(sel.Sel.Pos(), "SkipList.Length is not safe to access concurrently with writes; use Len")
+	}
+}
+
+// checkKeyWrites reports writes through keys returned by Key in body, whether directly or through
+// local variables holding them.
+func (c *checker) checkKeyWrites(body *ast.BlockStmt) {
+	keys := map[types.Object]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok && len(assign.Lhs) == len(assign.Rhs) {
+			for i, lhs := range assign.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && c.isKeyCall(assign.Rhs[i]) {
+					if obj := c.object(id); obj != nil {
+						keys[obj] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	isKey := func(expr ast.Expr) bool {
+		expr = ast.Unparen(expr)
+		if slice, ok := expr.(*ast.SliceExpr); ok {
+			expr = ast.Unparen(slice.X)
+		}
+		if id, ok := expr.(*ast.Ident); ok {
+			return keys[c.object(id)]
+		}
+		return c.isKeyCall(expr)
+	}
+	const message = "the slice returned by Key is the list's own key and must not be modified"
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if index, ok := ast.Unparen(lhs).(*ast.IndexExpr); ok && isKey(index.X) {
+					c.report(lhs.Pos(), message)
+				}
+			}
+		case *ast.IncDecStmt:
+			if index, ok := ast.Unparen(n.X).(*ast.IndexExpr); ok && isKey(index.X) {
+				c.report(n.Pos(), message)
+			}
+		case *ast.CallExpr:
+			if id, ok := ast.Unparen(n.Fun).(*ast.Ident); ok && len(n.Args) > 0 && isKey(n.Args[0]) {
+				if builtin, ok := c.info.Uses[id].(*types.Builtin); ok && (builtin.Name() == "copy" || builtin.Name() == "append") {
+					c.report(n.Pos(), message+"; "+builtin.Name()+" may write into it")
+				}
+			}
+		}
+		return true
+	})
+}
+
+// checkRemovedElements reports calls on elements after their key was removed from their list,
+// following the statements of a block in order.
+func (c *checker) checkRemovedElements(stmts []ast.Stmt) {
+	// origins records the list and key of each element variable obtained by a lookup.
+	type origin struct {
+		list, key string
+		removed   bool
+	}
+	origins := map[types.Object]*origin{}
+
+	for _, stmt := range stmts {
+		// Uses of elements removed by earlier statements.
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false
+			}
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			id, ok := ast.Unparen(sel.X).(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if o := origins[c.object(id)]; o != nil && o.removed && sel.Sel.Name != "Key" && sel.Sel.Name != "Seq" {
+				c.report(call.Pos(), "element "+id.Name+" is used after its key was removed from "+o.list)
+			}
+			return true
+		})
+
+		// Removals made by this statement.
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false
+			}
+			list, method, args := c.listCall(n)
+			if (method != "Remove" && method != "RemoveE") || len(args) != 1 {
+				return true
+			}
+			key := types.ExprString(args[0])
+			for obj, o := range origins {
+				if o.list == list && (o.key == key || key == obj.Name()+".Key()") {
+					o.removed = true
+				}
+			}
+			return true
+		})
+
+		// Elements looked up, or reassigned, by this statement.
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok {
+			continue
+		}
+		for _, lhs := range assign.Lhs {
+			if id, ok := lhs.(*ast.Ident); ok {
+				delete(origins, c.object(id))
+			}
+		}
+		if len(assign.Rhs) != 1 {
+			continue
+		}
+		list, method, args := c.listCall(assign.Rhs[0])
+		if (method != "Get" && method != "GetE") || len(args) != 1 {
+			continue
+		}
+		if id, ok := assign.Lhs[0].(*ast.Ident); ok {
+			if obj := c.object(id); obj != nil {
+				origins[obj] = &origin{list: list, key: types.ExprString(args[0])}
+			}
+		}
+	}
+}
+
+// listCall returns the receiver, method and arguments of n if it is a method call on a SkipList.
+func (c *checker) listCall(n ast.Node) (string, string, []ast.Expr) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return "", "", nil
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return "", "", nil
+	}
+	selection := c.info.Selections[sel]
+	if selection == nil || selection.Kind() != types.MethodVal || !isNamed(selection.Recv(), "SkipList") {
+		return "", "", nil
+	}
+	return types.ExprString(sel.X), sel.Sel.Name, call.Args
+}
+
+// isKeyCall reports whether expr calls the Key method of an Element or an Iterator.
+func (c *checker) isKeyCall(expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Key" {
+		return false
+	}
+	selection := c.info.Selections[sel]
+	return selection != nil && selection.Kind() == types.MethodVal &&
+		(isNamed(selection.Recv(), "Element") || isNamed(selection.Recv(), "Iterator"))
+}
+
+func (c *checker) object(id *ast.Ident) types.Object {
+	if obj := c.info.Defs[id]; obj != nil {
+		return obj
+	}
+	return c.info.Uses[id]
+}
+
+// isNamed reports whether t is the named type of the skiplist package with the given name, or a
+// pointer to it.
+func isNamed(t types.Type, name string) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == PackagePath
+}
@@ -0,0 +1,140 @@
+package skiplistvet
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// stub declares the parts of the skiplist package that the checks look at.
+const stub = `package skiplist
+
+type Element struct{}
+
+func (e *Element) Key() []byte        { return nil }
+func (e *Element) Value() interface{} { return nil }
+func (e *Element) Seq() uint64        { return 0 }
+func (e *Element) Next() *Element     { return nil }
+
+type Iterator struct{}
+
+func (it *Iterator) Key() []byte { return nil }
+
+type SkipList struct{ Length int }
+
+func (list *SkipList) Get(key []byte) *Element            { return nil }
+func (list *SkipList) GetE(key []byte) (*Element, error)  { return nil, nil }
+func (list *SkipList) Remove(key []byte) *Element         { return nil }
+func (list *SkipList) Len() int                           { return 0 }
+`
+
+const src = `package p
+
+import skiplist "github.com/m3db/fast-skiplist"
+
+func length(list *skiplist.SkipList) int {
+	list.Length++ // want "Length"
+	return list.Length + list.Len() // want "Length"
+}
+
+func keys(e *skiplist.Element, it *skiplist.Iterator) {
+	e.Key()[0] = 1 // want "Key"
+	k := it.Key()
+	k[1]++ // want "Key"
+	copy(k[2:], "x") // want "Key"
+	_ = append(e.Key(), 'x') // want "Key"
+
+	own := append([]byte(nil), e.Key()...)
+	own[0] = 1
+	copy(own, e.Key())
+}
+
+func removed(list, other *skiplist.SkipList, key []byte) {
+	e := list.Get(key)
+	_ = e.Value()
+	list.Remove(key)
+	_ = e.Value() // want "removed"
+	_, _ = e.Key(), e.Seq()
+	if e.Next() != nil { // want "removed"
+	}
+	e = list.Get(key)
+	_ = e.Value()
+
+	f, _ := list.GetE([]byte("f"))
+	other.Remove(f.Key())
+	_ = f.Value()
+	list.Remove(f.Key())
+	_ = f.Value() // want "removed"
+}
+`
+
+type stubImporter struct {
+	fset *token.FileSet
+	std  types.Importer
+}
+
+func (i stubImporter) Import(path string) (*types.Package, error) {
+	if path != PackagePath {
+		return i.std.Import(path)
+	}
+	file, err := parser.ParseFile(i.fset, "skiplist.go", stub, 0)
+	if err != nil {
+		return nil, err
+	}
+	return (&types.Config{}).Check(PackagePath, i.fset, []*ast.File{file}, nil)
+}
+
+func TestCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	config := &types.Config{Importer: stubImporter{fset: fset, std: importer.Default()}}
+	pkg, err := config.Check("p", fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every line marked as wanting a diagnostic must get exactly one, matching the mark.
+	want := map[int]string{}
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if text, ok := strings.CutPrefix(comment.Text, `// want "`); ok {
+				want[fset.Position(comment.Pos()).Line] = strings.TrimSuffix(text, `"`)
+			}
+		}
+	}
+
+	diagnostics := Check(pkg, []*ast.File{file}, info)
+	sort.Slice(diagnostics, func(i, j int) bool { return diagnostics[i].Pos < diagnostics[j].Pos })
+	got := map[int]bool{}
+	for _, d := range diagnostics {
+		line := fset.Position(d.Pos).Line
+		if pattern, ok := want[line]; !ok || got[line] || !strings.Contains(d.Message, pattern) {
+			t.Errorf("unexpected diagnostic on line %d: %s", line, d.Message)
+		}
+		got[line] = true
+	}
+	for line := range want {
+		if !got[line] {
+			t.Errorf("missing diagnostic on line %d: %s", line, want[line])
+		}
+	}
+
+	if Check(types.NewPackage(PackagePath, "skiplist"), []*ast.File{file}, info) != nil {
+		t.Error("the skiplist package itself must not be checked")
+	}
+}
@@ -0,0 +1,178 @@
+// Command skiplistvet reports misuses of the skiplist package, as described by package
+// skiplistvet. It is run by go vet:
+//
+//	go vet -vettool=$(which skiplistvet) ./...
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/m3db/fast-skiplist/skiplistvet"
+)
+
+// config is the description of a package that go vet passes to its tool.
+type config struct {
+	Compiler                  string
+	ImportPath                string
+	GoFiles                   []string
+	ImportMap                 map[string]string
+	PackageFile               map[string]string
+	VetxOnly                  bool
+	VetxOutput                string
+	SucceedOnTypecheckFailure bool
+}
+
+func main() {
+	progname := filepath.Base(os.Args[0])
+	version := flag.String("V", "", "print version and exit")
+	printFlags := flag.Bool("flags", false, "print flags in JSON and exit")
+	jsonOutput := flag.Bool("json", false, "emit diagnostics in JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: go vet -vettool=$(which %s) [packages]\n", progname)
+	}
+	flag.Parse()
+
+	switch {
+	case *version != "":
+		// go vet identifies the tool by a hash of its executable, for caching its results.
+		fmt.Printf("%s version devel buildID=%s\n", progname, executableHash())
+		return
+	case *printFlags:
+		fmt.Println("[]")
+		return
+	case flag.NArg() != 1 || !strings.HasSuffix(flag.Arg(0), ".cfg"):
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pkgPath, diagnostics, fset, err := run(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progname, err)
+		os.Exit(1)
+	}
+	if len(diagnostics) == 0 {
+		return
+	}
+	sort.Slice(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Pos < diagnostics[j].Pos
+	})
+	if *jsonOutput {
+		printJSON(pkgPath, diagnostics, fset)
+		return
+	}
+	for _, d := range diagnostics {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fset.Position(d.Pos), d.Message)
+	}
+	os.Exit(1)
+}
+
+// printJSON prints diagnostics in the JSON form go vet expects of its tools when run with -json:
+// the diagnostics of each analyzer, by package.
+func printJSON(pkgPath string, diagnostics []skiplistvet.Diagnostic, fset *token.FileSet) {
+	type jsonDiagnostic struct {
+		Posn    string `json:"posn"`
+		Message string `json:"message"`
+	}
+	var list []jsonDiagnostic
+	for _, d := range diagnostics {
+		list = append(list, jsonDiagnostic{Posn: fset.Position(d.Pos).String(), Message: d.Message})
+	}
+
+	tree := map[string]map[string][]jsonDiagnostic{pkgPath: {"skiplistvet": list}}
+	data, _ := json.MarshalIndent(tree, "", "\t")
+	fmt.Printf("%s\n", data)
+}
+
+// run checks the package described by the config file at path.
+func run(path string) (string, []skiplistvet.Diagnostic, *token.FileSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", nil, nil, fmt.Errorf("decoding %s: %v", path, err)
+	}
+
+	// The tool records no facts about packages, but go vet expects the file of them.
+	if cfg.VetxOutput != "" {
+		if err := os.WriteFile(cfg.VetxOutput, nil, 0o666); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	if cfg.VetxOnly {
+		return cfg.ImportPath, nil, nil, nil
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range cfg.GoFiles {
+		file, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		files = append(files, file)
+	}
+
+	compilerImporter := importer.ForCompiler(fset, cfg.Compiler, func(path string) (io.ReadCloser, error) {
+		file, ok := cfg.PackageFile[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %q", path)
+		}
+		return os.Open(file)
+	})
+	tc := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if mapped, ok := cfg.ImportMap[path]; ok {
+				path = mapped
+			}
+			return compilerImporter.Import(path)
+		}),
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	pkg, err := tc.Check(cfg.ImportPath, fset, files, info)
+	if err != nil {
+		if cfg.SucceedOnTypecheckFailure {
+			return cfg.ImportPath, nil, nil, nil
+		}
+		return "", nil, nil, err
+	}
+
+	return cfg.ImportPath, skiplistvet.Check(pkg, files, info), fset, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func executableHash() string {
+	path, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
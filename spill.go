@@ -0,0 +1,204 @@
+package skiplist
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrBudgetExceeded is returned by Between when its results outgrow the memory budget and
+// there is no Spiller to move them out of memory.
+var ErrBudgetExceeded = errors.New("range exceeds memory budget")
+
+// Spiller stores runs of range results that do not fit in the memory budget of a query,
+// typically in temporary files.
+type Spiller interface {
+	// Spill stores run, a sequence of records in the format produced by RecordReader, and
+	// returns a reader that reads it back. Spill must not retain run once it returns.
+	Spill(run []byte) (io.ReadCloser, error)
+}
+
+// RangeOptions configure Between.
+type RangeOptions struct {
+	// MemoryBudget bounds the bytes of encoded results held in memory. Once the held results
+	// reach it, they are handed to Spiller and memory is reused for the following ones.
+	// Zero leaves the results unbounded, holding every element of the range.
+	MemoryBudget int
+	// Spiller stores results beyond the memory budget. Without one, a range that exceeds the
+	// budget fails with ErrBudgetExceeded.
+	Spiller Spiller
+	// Codec encodes values for spilling, and is required when MemoryBudget is set.
+	Codec Codec
+}
+
+// Between materializes the elements with start <= key < end. A nil end leaves the range
+// unbounded above. Unlike iterating, the results are not affected by later writes to the list.
+//
+// Materializing a large range can take a lot of memory. Setting opts.MemoryBudget bounds it,
+// spilling results through opts.Spiller as they exceed the budget, so that unbounded client
+// queries cannot run the process out of memory. The list is not locked, so writes concurrent
+// with Between may or may not be part of the results; spilling happens without blocking them.
+//
+// The returned result must be closed to release spilled runs.
+func (list *SkipList) Between(start, end []byte, opts RangeOptions) (*RangeResult, error) {
+	if opts.MemoryBudget <= 0 {
+		result := &RangeResult{}
+		for element := list.searchGreaterOrEqual(start); element != nil; element = element.Next() {
+			if end != nil && list.compare(element.key, end) >= 0 {
+				break
+			}
+			result.keys = append(result.keys, element.key)
+			result.values = append(result.values, element.Value())
+		}
+		result.length = len(result.keys)
+		result.index = -1
+		return result, nil
+	}
+
+	if opts.Codec == nil {
+		return nil, list.newError("Between", start, errors.New("a MemoryBudget requires a Codec"))
+	}
+
+	result := &RangeResult{codec: opts.Codec}
+	var buf []byte
+	for element := list.searchGreaterOrEqual(start); element != nil; element = element.Next() {
+		if end != nil && list.compare(element.key, end) >= 0 {
+			break
+		}
+
+		value, err := opts.Codec.Encode(element.Value())
+		if err != nil {
+			result.Close()
+			return nil, list.newError("Between", element.key, err)
+		}
+		buf = appendRecord(buf, element.key, value)
+		result.length++
+
+		if len(buf) < opts.MemoryBudget {
+			continue
+		}
+		if opts.Spiller == nil {
+			result.Close()
+			return nil, list.newError("Between", start, ErrBudgetExceeded)
+		}
+
+		run, err := opts.Spiller.Spill(buf)
+		if err != nil {
+			result.Close()
+			return nil, list.newError("Between", element.key, err)
+		}
+		result.runs = append(result.runs, run)
+		buf = buf[:0]
+	}
+
+	result.Spilled = len(result.runs)
+	result.runs = append(result.runs, io.NopCloser(bytes.NewReader(buf)))
+	return result, nil
+}
+
+// RangeResult holds the elements materialized by Between. It is read like an iterator:
+//
+//	for result.Next() {
+//		use(result.Key(), result.Value())
+//	}
+//	if err := result.Err(); err != nil {
+//		...
+//	}
+type RangeResult struct {
+	// Spilled is the number of runs that were handed to the Spiller.
+	Spilled int
+
+	length int
+
+	// keys and values hold the results of a query without a memory budget, index being the
+	// position of the current one.
+	keys   [][]byte
+	values []interface{}
+	index  int
+
+	// runs hold the encoded results of a query with a memory budget, in order, the last one
+	// being the run that stayed in memory.
+	codec   Codec
+	runs    []io.ReadCloser
+	scanner *recordScanner
+	key     []byte
+	value   interface{}
+	err     error
+}
+
+// Len returns the number of elements in the result.
+func (r *RangeResult) Len() int {
+	return r.length
+}
+
+// Next advances to the next element, returning false once the elements are exhausted or
+// reading them back failed.
+func (r *RangeResult) Next() bool {
+	if r.codec == nil {
+		if r.index < len(r.keys) {
+			r.index++
+		}
+		return r.index < len(r.keys)
+	}
+
+	for r.err == nil && len(r.runs) > 0 {
+		if r.scanner == nil {
+			r.scanner = &recordScanner{r: bufio.NewReader(r.runs[0]), maxFieldSize: DefaultLoadMaxFieldSize}
+		}
+
+		key, data, err := r.scanner.next()
+		if err == io.EOF {
+			r.err = r.runs[0].Close()
+			r.runs = r.runs[1:]
+			r.scanner = nil
+			continue
+		}
+		if err == nil {
+			r.value, err = r.codec.Decode(data)
+		}
+		if err != nil {
+			r.err = err
+			break
+		}
+
+		r.key = key
+		return true
+	}
+
+	r.key, r.value = nil, nil
+	return false
+}
+
+// Key returns the key of the current element.
+func (r *RangeResult) Key() []byte {
+	if r.codec == nil {
+		return r.keys[r.index]
+	}
+	return r.key
+}
+
+// Value returns the value of the current element.
+func (r *RangeResult) Value() interface{} {
+	if r.codec == nil {
+		return r.values[r.index]
+	}
+	return r.value
+}
+
+// Err returns the error that stopped Next early, if any.
+func (r *RangeResult) Err() error {
+	return r.err
+}
+
+// Close releases the spilled runs that have not been read yet.
+func (r *RangeResult) Close() error {
+	var err error
+	for _, run := range r.runs {
+		if closeErr := run.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	r.runs = nil
+	return err
+}
@@ -0,0 +1,116 @@
+package skiplist
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type memSpiller struct {
+	runs   int
+	bytes  int
+	closed int
+}
+
+type memRun struct {
+	*bytes.Reader
+	spiller *memSpiller
+}
+
+func (r memRun) Close() error {
+	r.spiller.closed++
+	return nil
+}
+
+func (s *memSpiller) Spill(run []byte) (io.ReadCloser, error) {
+	s.runs++
+	s.bytes += len(run)
+	return memRun{bytes.NewReader(append([]byte(nil), run...)), s}, nil
+}
+
+func TestBetween(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), orderedKey(i*2))
+	}
+
+	check := func(result *RangeResult, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer result.Close()
+
+		if result.Len() != 500 {
+			t.Fatal("wrong result length", result.Len())
+		}
+
+		// Writes after Between must not change its results.
+		list.Remove(orderedKey(300))
+
+		want := uint64(100)
+		for result.Next() {
+			if orderedKeyValue(result.Key()) != want || orderedKeyValue(result.Value().([]byte)) != want*2 {
+				t.Fatal("wrong result element", result.Key(), result.Value())
+			}
+			want++
+		}
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if want != 600 {
+			t.Fatal("results ended early", want)
+		}
+		list.Set(orderedKey(300), orderedKey(600))
+	}
+
+	check(list.Between(orderedKey(100), orderedKey(600), RangeOptions{}))
+
+	spiller := &memSpiller{}
+	result, err := list.Between(orderedKey(100), orderedKey(600), RangeOptions{
+		MemoryBudget: 1024,
+		Spiller:      spiller,
+		Codec:        BytesCodec{},
+	})
+	if result.Spilled != spiller.runs || spiller.runs < 8 || spiller.bytes/spiller.runs > 1024+32 {
+		t.Fatal("results beyond the budget must be spilled", result.Spilled, spiller.runs, spiller.bytes)
+	}
+	check(result, err)
+
+	if spiller.closed != spiller.runs {
+		t.Fatal("spilled runs must be closed once read", spiller.closed, spiller.runs)
+	}
+}
+
+func TestBetweenBudgetExceeded(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), nil)
+	}
+
+	opts := RangeOptions{MemoryBudget: 64, Codec: nullCodec{}}
+	if _, err := list.Between(nil, orderedKey(5), opts); err != nil {
+		t.Fatal("a range within the budget must not fail", err)
+	}
+
+	if _, err := list.Between(nil, nil, opts); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatal("expected ErrBudgetExceeded, got", err)
+	}
+
+	spiller := &memSpiller{}
+	opts.Spiller = spiller
+	result, err := list.Between(nil, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Close()
+	if spiller.closed != spiller.runs || spiller.runs == 0 {
+		t.Fatal("Close must release unread runs", spiller.closed, spiller.runs)
+	}
+}
+
+type nullCodec struct{}
+
+func (nullCodec) Encode(value interface{}) ([]byte, error) { return nil, nil }
+func (nullCodec) Decode(data []byte) (interface{}, error)  { return nil, nil }
@@ -0,0 +1,151 @@
+// Package sstable exports skip lists to, and loads them from, sorted string tables in the block
+// based format of LevelDB and RocksDB, so that a frozen memtable can be ingested by RocksDB or
+// Pebble as an external file, and an on-disk table can warm up an in-memory view.
+//
+// Tables are written with the LevelDB footer, which RocksDB reads as format version 0, without
+// compression or filters. Keys are stored as internal keys with sequence number 0, as RocksDB's
+// SstFileWriter does, and the table carries the properties RocksDB expects of external files.
+// Tables are read if they are uncompressed and of the LevelDB format or of a RocksDB format
+// version up to 3, keeping the most recent version of each key.
+package sstable
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+const (
+	// DefaultBlockSize is the size that data blocks are filled up to unless set otherwise.
+	DefaultBlockSize = 4096
+	// DefaultRestartInterval is the number of keys between restart points in data blocks
+	// unless set otherwise.
+	DefaultRestartInterval = 16
+
+	// tableMagic identifies LevelDB tables, and legacy RocksDB block based tables.
+	tableMagic = 0xdb4775248b80fb57
+	// footerSize is two block handles padded to their maximum size, and the magic number.
+	footerSize = 2*maxBlockHandleSize + 8
+	// rocksDBTableMagic identifies RocksDB block based tables of format version 1 and later,
+	// whose footer starts with a checksum type and ends with the format version and the magic.
+	rocksDBTableMagic = 0x88e241b785f4cff7
+	rocksDBFooterSize = 1 + 2*maxBlockHandleSize + 4 + 8
+	// maxFormatVersion is the last RocksDB format version whose index blocks hold plain block
+	// handles. Later versions delta encode them.
+	maxFormatVersion = 3
+	// maxBlockHandleSize is the size of a block handle, two varints, at most.
+	maxBlockHandleSize = 2 * binary.MaxVarintLen64
+	// blockTrailerSize is the compression type and checksum following every block.
+	blockTrailerSize = 5
+	// noCompression is the compression type of uncompressed blocks.
+	noCompression = 0
+
+	// Kinds of internal keys.
+	deletionKind       = 0
+	valueKind          = 1
+	singleDeletionKind = 7
+	// internalKeyTrailerSize is the size of the sequence number and kind appended to user keys.
+	internalKeyTrailerSize = 8
+
+	propertiesBlockName = "rocksdb.properties"
+	bytewiseComparator  = "leveldb.BytewiseComparator"
+	// externalFileVersion is the version of external files with a global sequence number.
+	externalFileVersion = 2
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// maskChecksum masks a block checksum as LevelDB does, since checksums of data that embeds
+// checksums are otherwise prone to collide.
+func maskChecksum(crc uint32) uint32 {
+	return (crc>>15 | crc<<17) + 0xa282ead8
+}
+
+// blockHandle locates a block in a table, excluding its trailer.
+type blockHandle struct {
+	offset, size uint64
+}
+
+func decodeBlockHandle(buf []byte) (blockHandle, int, bool) {
+	offset, n1 := binary.Uvarint(buf)
+	if n1 <= 0 {
+		return blockHandle{}, 0, false
+	}
+	size, n2 := binary.Uvarint(buf[n1:])
+	if n2 <= 0 {
+		return blockHandle{}, 0, false
+	}
+	return blockHandle{offset, size}, n1 + n2, true
+}
+
+func (h blockHandle) append(buf []byte) []byte {
+	buf = binary.AppendUvarint(buf, h.offset)
+	return binary.AppendUvarint(buf, h.size)
+}
+
+// internalKey returns key with the trailer of a value at sequence number 0.
+func internalKey(buf, key []byte) []byte {
+	buf = append(buf, key...)
+	return binary.LittleEndian.AppendUint64(buf, valueKind)
+}
+
+// blockBuilder builds a block of sorted entries. Keys are prefix compressed against the
+// previous key, except at restart points, where a search of the block can start.
+type blockBuilder struct {
+	restartInterval int
+	buf             []byte
+	restarts        []uint32
+	counter         int
+	lastKey         []byte
+	entries         int
+}
+
+func (b *blockBuilder) add(key, value []byte) {
+	if len(b.restarts) == 0 {
+		b.restarts = append(b.restarts, 0)
+	}
+
+	shared := 0
+	if b.counter < b.restartInterval {
+		for shared < len(key) && shared < len(b.lastKey) && key[shared] == b.lastKey[shared] {
+			shared++
+		}
+	} else {
+		b.restarts = append(b.restarts, uint32(len(b.buf)))
+		b.counter = 0
+	}
+
+	b.buf = binary.AppendUvarint(b.buf, uint64(shared))
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(key)-shared))
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(value)))
+	b.buf = append(b.buf, key[shared:]...)
+	b.buf = append(b.buf, value...)
+
+	b.lastKey = append(b.lastKey[:0], key...)
+	b.counter++
+	b.entries++
+}
+
+// estimatedSize returns the size of the block if it were finished now.
+func (b *blockBuilder) estimatedSize() int {
+	return len(b.buf) + 4*len(b.restarts) + 4
+}
+
+// finish appends the restart points and returns the block's contents, which stay valid until
+// the next reset.
+func (b *blockBuilder) finish() []byte {
+	if len(b.restarts) == 0 {
+		b.restarts = append(b.restarts, 0)
+	}
+	for _, restart := range b.restarts {
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, restart)
+	}
+	return binary.LittleEndian.AppendUint32(b.buf, uint32(len(b.restarts)))
+}
+
+func (b *blockBuilder) reset() {
+	b.buf = b.buf[:0]
+	b.restarts = b.restarts[:0]
+	b.counter = 0
+	b.lastKey = b.lastKey[:0]
+	b.entries = 0
+}
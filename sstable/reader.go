@@ -0,0 +1,309 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+// Checksum types of RocksDB tables.
+const (
+	noChecksum     = 0
+	crc32cChecksum = 1
+)
+
+// Reader reads a table written by a Writer, LevelDB or RocksDB. Only uncompressed tables are
+// supported, of the LevelDB format or of RocksDB format versions up to 3 with CRC-32C checksums.
+type Reader struct {
+	r        io.ReaderAt
+	size     int64
+	checksum byte
+	// dataBlocks locates the data blocks, in key order, as listed by the table's index.
+	dataBlocks []blockHandle
+}
+
+// NewReader opens the table of the given size read from r, reading its footer and index.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	tail := make([]byte, rocksDBFooterSize)
+	if size < int64(len(tail)) {
+		tail = tail[:footerSize]
+	}
+	if size < int64(len(tail)) {
+		return nil, errors.New("sstable: file too short to be a table")
+	}
+	if _, err := r.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return nil, fmt.Errorf("sstable: reading footer: %v", err)
+	}
+
+	reader := &Reader{r: r, size: size, checksum: crc32cChecksum}
+	var handles []byte
+	switch magic := binary.LittleEndian.Uint64(tail[len(tail)-8:]); magic {
+	case tableMagic:
+		handles = tail[len(tail)-footerSize:]
+	case rocksDBTableMagic:
+		if len(tail) < rocksDBFooterSize {
+			return nil, errors.New("sstable: file too short to be a table")
+		}
+		if version := binary.LittleEndian.Uint32(tail[len(tail)-12:]); version > maxFormatVersion {
+			return nil, fmt.Errorf("sstable: unsupported format version %d", version)
+		}
+		reader.checksum = tail[0]
+		if reader.checksum != noChecksum && reader.checksum != crc32cChecksum {
+			return nil, fmt.Errorf("sstable: unsupported checksum type %d", reader.checksum)
+		}
+		handles = tail[1:]
+	default:
+		return nil, fmt.Errorf("sstable: bad magic number %#x", magic)
+	}
+
+	_, n, ok := decodeBlockHandle(handles)
+	if !ok {
+		return nil, errors.New("sstable: malformed meta index handle in footer")
+	}
+	indexHandle, _, ok := decodeBlockHandle(handles[n:])
+	if !ok {
+		return nil, errors.New("sstable: malformed index handle in footer")
+	}
+
+	index, err := reader.readBlock(indexHandle)
+	if err != nil {
+		return nil, fmt.Errorf("sstable: reading index: %v", err)
+	}
+	it, err := newBlockIter(index)
+	for err == nil && it.next() {
+		handle, _, ok := decodeBlockHandle(it.value)
+		if !ok {
+			return nil, errors.New("sstable: malformed block handle in index")
+		}
+		reader.dataBlocks = append(reader.dataBlocks, handle)
+	}
+	if err == nil {
+		err = it.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sstable: reading index: %v", err)
+	}
+
+	return reader, nil
+}
+
+// readBlock reads the block at handle and verifies its trailer, returning its contents.
+// Every block is read into a new buffer, which the entries decoded from it may point into.
+func (r *Reader) readBlock(handle blockHandle) ([]byte, error) {
+	if handle.offset > uint64(r.size) || handle.size > uint64(r.size)-handle.offset ||
+		uint64(r.size)-handle.offset-handle.size < blockTrailerSize {
+		return nil, fmt.Errorf("block at offset %d of size %d exceeds the file", handle.offset, handle.size)
+	}
+
+	buf := make([]byte, handle.size+blockTrailerSize)
+	if _, err := r.r.ReadAt(buf, int64(handle.offset)); err != nil {
+		return nil, err
+	}
+
+	contents, trailer := buf[:handle.size], buf[handle.size:]
+	if r.checksum == crc32cChecksum {
+		crc := crc32.Update(crc32.Checksum(contents, castagnoli), castagnoli, trailer[:1])
+		if binary.LittleEndian.Uint32(trailer[1:]) != maskChecksum(crc) {
+			return nil, fmt.Errorf("checksum mismatch in block at offset %d", handle.offset)
+		}
+	}
+	if trailer[0] != noCompression {
+		return nil, fmt.Errorf("unsupported compression type %d of block at offset %d", trailer[0], handle.offset)
+	}
+	return contents, nil
+}
+
+// blockIter decodes the entries of a block in order.
+type blockIter struct {
+	block      []byte
+	pos, end   int
+	key, value []byte
+	err        error
+}
+
+func newBlockIter(block []byte) (*blockIter, error) {
+	if len(block) < 4 {
+		return nil, errors.New("block too short")
+	}
+	restarts := binary.LittleEndian.Uint32(block[len(block)-4:])
+	if uint64(restarts) > uint64(len(block)-4)/4 {
+		return nil, errors.New("malformed block restarts")
+	}
+	return &blockIter{block: block, end: len(block) - 4 - 4*int(restarts)}, nil
+}
+
+func (it *blockIter) next() bool {
+	if it.err != nil || it.pos >= it.end {
+		return false
+	}
+
+	var header [3]uint64
+	for i := range header {
+		v, n := binary.Uvarint(it.block[it.pos:it.end])
+		if n <= 0 {
+			it.err = fmt.Errorf("malformed block entry at %d", it.pos)
+			return false
+		}
+		header[i] = v
+		it.pos += n
+	}
+
+	shared, unshared, valueLen := header[0], header[1], header[2]
+	if shared > uint64(len(it.key)) || unshared > uint64(it.end-it.pos) || valueLen > uint64(it.end-it.pos)-unshared {
+		it.err = fmt.Errorf("malformed block entry at %d", it.pos)
+		return false
+	}
+
+	// The key is built in a new slice rather than in place, so that keys stay valid.
+	it.key = append(it.key[:shared:shared], it.block[it.pos:it.pos+int(unshared)]...)
+	it.pos += int(unshared)
+	it.value = it.block[it.pos : it.pos+int(valueLen) : it.pos+int(valueLen)]
+	it.pos += int(valueLen)
+	return true
+}
+
+// Iterator yields entries in strictly increasing key order. TableIterator implements it, and
+// other sorted formats can implement it to be loaded into a list with Load.
+type Iterator interface {
+	// Next advances to the next entry, returning false once there are none left or reading
+	// failed, in which case Err returns the reason.
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Err() error
+}
+
+// TableIterator iterates over the live keys of a table: for each key, the value of its most
+// recent version, unless that is a deletion. Keys and values remain valid after Next.
+type TableIterator struct {
+	reader *Reader
+	block  int
+	it     *blockIter
+
+	key, value []byte
+	started    bool
+	err        error
+}
+
+// NewIterator returns an iterator over the table, positioned before its first key.
+func (r *Reader) NewIterator() *TableIterator {
+	return &TableIterator{reader: r}
+}
+
+// Next implements Iterator.
+func (t *TableIterator) Next() bool {
+	for t.err == nil {
+		if t.it == nil || !t.it.next() {
+			if t.it != nil && t.it.err != nil {
+				t.err = t.fail(t.it.err)
+				break
+			}
+			if t.block == len(t.reader.dataBlocks) {
+				break
+			}
+
+			contents, err := t.reader.readBlock(t.reader.dataBlocks[t.block])
+			if err == nil {
+				t.it, err = newBlockIter(contents)
+			}
+			if err != nil {
+				t.err = t.fail(err)
+				break
+			}
+			t.block++
+			continue
+		}
+
+		ikey := t.it.key
+		if len(ikey) < internalKeyTrailerSize {
+			t.err = t.fail(errors.New("malformed internal key"))
+			break
+		}
+		userKey := ikey[:len(ikey)-internalKeyTrailerSize]
+		kind := ikey[len(ikey)-internalKeyTrailerSize]
+
+		// Versions of a key are ordered from the most recent, which is the only one that counts.
+		if t.started && bytes.Equal(userKey, t.key) {
+			continue
+		}
+		t.key, t.started = userKey, true
+
+		switch kind {
+		case valueKind:
+			t.value = t.it.value
+			return true
+		case deletionKind, singleDeletionKind:
+			continue
+		default:
+			t.err = t.fail(fmt.Errorf("unsupported kind %d of key %q", kind, userKey))
+		}
+	}
+
+	t.key, t.value = nil, nil
+	return false
+}
+
+func (t *TableIterator) fail(err error) error {
+	return fmt.Errorf("sstable: data block %d: %v", t.block, err)
+}
+
+// Key implements Iterator.
+func (t *TableIterator) Key() []byte {
+	return t.key
+}
+
+// Value implements Iterator.
+func (t *TableIterator) Value() []byte {
+	return t.value
+}
+
+// Err implements Iterator.
+func (t *TableIterator) Err() error {
+	return t.err
+}
+
+// Load builds a list, configured by opts, from the entries of it, decoding values with codec.
+// The list is built by skiplist.NewFromSorted, in a single pass without searching.
+func Load(it Iterator, codec skiplist.Codec, opts ...skiplist.Option) (*skiplist.SkipList, error) {
+	var err error
+	list, buildErr := skiplist.NewFromSorted(func() ([]byte, interface{}, bool) {
+		if err != nil || !it.Next() {
+			return nil, nil, false
+		}
+
+		var value interface{}
+		if value, err = codec.Decode(it.Value()); err != nil {
+			err = fmt.Errorf("sstable: decoding value of key %q: %v", it.Key(), err)
+			return nil, nil, false
+		}
+		return it.Key(), value, true
+	}, opts...)
+
+	if err == nil {
+		err = it.Err()
+	}
+	if err == nil {
+		err = buildErr
+	}
+	if err != nil {
+		if list != nil {
+			list.Close()
+		}
+		return nil, err
+	}
+	return list, nil
+}
+
+// LoadTable builds a list, configured by opts, from the table of the given size read from r.
+func LoadTable(r io.ReaderAt, size int64, codec skiplist.Codec, opts ...skiplist.Option) (*skiplist.SkipList, error) {
+	reader, err := NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return Load(reader.NewIterator(), codec, opts...)
+}
@@ -0,0 +1,127 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+func TestLoadTable(t *testing.T) {
+	list := skiplist.New()
+	for i := 0; i < 1000; i++ {
+		list.Set([]byte(fmt.Sprintf("key-%04d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, list, skiplist.BytesCodec{}, Options{BlockSize: 512}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTable(bytes.NewReader(buf.Bytes()), int64(buf.Len()), skiplist.BytesCodec{},
+		skiplist.WithName("warm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Len() != 1000 || loaded.Name() != "warm" {
+		t.Fatal("wrong loaded list", loaded.Len(), loaded.Name())
+	}
+	for a, b := list.Front(), loaded.Front(); a != nil; a, b = a.Next(), b.Next() {
+		if !bytes.Equal(a.Key(), b.Key()) || !bytes.Equal(a.Value().([]byte), b.Value().([]byte)) {
+			t.Fatal("wrong loaded element", string(b.Key()))
+		}
+	}
+
+	// A table in the footer format of later RocksDB versions reads the same.
+	table := buf.Bytes()
+	footer := table[len(table)-footerSize : len(table)-8]
+	rocksDB := append([]byte(nil), table[:len(table)-footerSize]...)
+	rocksDB = append(rocksDB, crc32cChecksum)
+	rocksDB = append(rocksDB, footer...)
+	rocksDB = binary.LittleEndian.AppendUint32(rocksDB, 2)
+	rocksDB = binary.LittleEndian.AppendUint64(rocksDB, rocksDBTableMagic)
+	if loaded, err := LoadTable(bytes.NewReader(rocksDB), int64(len(rocksDB)), skiplist.BytesCodec{}); err != nil || loaded.Len() != 1000 {
+		t.Fatal("failed to read a RocksDB footer", err)
+	}
+}
+
+func TestTableIteratorVersions(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, Options{BlockSize: 64})
+	add := func(key string, seq uint64, kind byte, value string) {
+		ikey := binary.LittleEndian.AppendUint64([]byte(key), seq<<8|uint64(kind))
+		w.addInternal(ikey, []byte(value))
+	}
+	add("a", 3, valueKind, "a3")
+	add("a", 2, valueKind, "a2")
+	add("b", 5, deletionKind, "")
+	add("b", 4, valueKind, "b4")
+	add("c", 1, valueKind, "c1")
+	add("d", 9, singleDeletionKind, "")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	it := reader.NewIterator()
+	for it.Next() {
+		got = append(got, string(it.Key())+"="+string(it.Value()))
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if strings.Join(got, ",") != "a=a3,c=c1" {
+		t.Fatal("only the most recent live version of each key must be read", got)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	list := skiplist.New()
+	for i := 0; i < 100; i++ {
+		list.Set([]byte(fmt.Sprintf("key-%04d", i)), []byte("value"))
+	}
+	var buf bytes.Buffer
+	if err := Export(&buf, list, skiplist.BytesCodec{}, Options{BlockSize: 256}); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(table []byte) error {
+		_, err := LoadTable(bytes.NewReader(table), int64(len(table)), skiplist.BytesCodec{})
+		return err
+	}
+
+	corrupt := append([]byte(nil), buf.Bytes()...)
+	corrupt[300]++
+	if err := load(corrupt); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatal("expected a checksum error, got", err)
+	}
+
+	compressed := append([]byte(nil), buf.Bytes()...)
+	reader, err := NewReader(bytes.NewReader(compressed), int64(len(compressed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := reader.dataBlocks[0]
+	compressed[first.offset+first.size] = 1
+	if err := load(compressed); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatal("the compression type must be covered by the checksum, got", err)
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if err := load(truncated); err == nil || !strings.Contains(err.Error(), "bad magic number") {
+		t.Fatal("expected a magic number error, got", err)
+	}
+
+	if err := load([]byte("short")); err == nil {
+		t.Fatal("a short file must fail")
+	}
+}
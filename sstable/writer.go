@@ -0,0 +1,218 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"strconv"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+// Options configure a Writer.
+type Options struct {
+	// BlockSize is the size that data blocks are filled up to before starting the next one.
+	// Defaults to DefaultBlockSize.
+	BlockSize int
+	// RestartInterval is the number of keys between restart points in data blocks.
+	// Defaults to DefaultRestartInterval.
+	RestartInterval int
+}
+
+// Writer writes a table. Entries must be added in strictly increasing byte-wise key order,
+// and the table is complete once Close returns.
+type Writer struct {
+	w    io.Writer
+	opts Options
+
+	offset uint64
+	err    error
+	closed bool
+
+	data  blockBuilder
+	index blockBuilder
+	// lastKey is the internal key of the last entry added, which ends the current data block.
+	lastKey []byte
+	ikey    []byte
+
+	dataBlocks    int
+	dataSize      uint64
+	entries       uint64
+	rawKeySize    uint64
+	rawValueSize  uint64
+	indexSize     uint64
+	trailer       [blockTrailerSize]byte
+	handleScratch []byte
+}
+
+// NewWriter returns a Writer of a table to w.
+func NewWriter(w io.Writer, opts Options) *Writer {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultBlockSize
+	}
+	if opts.RestartInterval <= 0 {
+		opts.RestartInterval = DefaultRestartInterval
+	}
+
+	return &Writer{
+		w:     w,
+		opts:  opts,
+		data:  blockBuilder{restartInterval: opts.RestartInterval},
+		index: blockBuilder{restartInterval: 1},
+	}
+}
+
+// Add appends an entry to the table. key must sort after the key of the previous entry.
+func (w *Writer) Add(key, value []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.closed {
+		return errors.New("sstable: Add after Close")
+	}
+	if w.entries > 0 && bytes.Compare(key, w.lastKey[:len(w.lastKey)-internalKeyTrailerSize]) <= 0 {
+		return fmt.Errorf("sstable: key %q does not sort after the previous key", key)
+	}
+
+	w.ikey = internalKey(w.ikey[:0], key)
+	w.addInternal(w.ikey, value)
+	return w.err
+}
+
+// addInternal appends an entry with an internal key, which the caller has checked sorts after
+// the previous one.
+func (w *Writer) addInternal(ikey, value []byte) {
+	w.data.add(ikey, value)
+	w.lastKey = append(w.lastKey[:0], ikey...)
+	w.entries++
+	w.rawKeySize += uint64(len(ikey))
+	w.rawValueSize += uint64(len(value))
+
+	if w.data.estimatedSize() >= w.opts.BlockSize {
+		w.flushData()
+	}
+}
+
+// flushData writes the current data block and indexes it under its last key.
+func (w *Writer) flushData() {
+	if w.data.entries == 0 {
+		return
+	}
+
+	handle := w.writeBlock(w.data.finish())
+	w.data.reset()
+	w.dataBlocks++
+	w.dataSize = w.offset
+
+	w.handleScratch = handle.append(w.handleScratch[:0])
+	w.index.add(w.lastKey, w.handleScratch)
+}
+
+// writeBlock writes a block and its trailer, returning the block's handle.
+func (w *Writer) writeBlock(contents []byte) blockHandle {
+	handle := blockHandle{offset: w.offset, size: uint64(len(contents))}
+
+	w.trailer[0] = noCompression
+	crc := crc32.Update(crc32.Checksum(contents, castagnoli), castagnoli, w.trailer[:1])
+	binary.LittleEndian.PutUint32(w.trailer[1:], maskChecksum(crc))
+
+	w.write(contents)
+	w.write(w.trailer[:])
+	return handle
+}
+
+func (w *Writer) write(p []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(p)
+	w.offset += uint64(n)
+	w.err = err
+}
+
+// Close writes the last data block, the index, the properties and the footer of the table.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+
+	w.flushData()
+
+	indexHandle := w.writeBlock(w.index.finish())
+	w.indexSize = indexHandle.size
+
+	meta := blockBuilder{restartInterval: 1}
+	propertiesHandle := w.writeBlock(w.properties())
+	meta.add([]byte(propertiesBlockName), propertiesHandle.append(nil))
+	metaIndexHandle := w.writeBlock(meta.finish())
+
+	footer := make([]byte, 0, footerSize)
+	footer = metaIndexHandle.append(footer)
+	footer = indexHandle.append(footer)
+	footer = footer[:footerSize-8]
+	footer = binary.LittleEndian.AppendUint64(footer, tableMagic)
+	w.write(footer)
+
+	return w.err
+}
+
+// properties returns the properties block, holding the properties RocksDB records of its tables.
+func (w *Writer) properties() []byte {
+	varint := func(v uint64) string {
+		return string(binary.AppendUvarint(nil, v))
+	}
+
+	props := map[string]string{
+		"rocksdb.comparator":       bytewiseComparator,
+		"rocksdb.compression":      "NoCompression",
+		"rocksdb.data.size":        varint(w.dataSize),
+		"rocksdb.filter.size":      varint(0),
+		"rocksdb.fixed.key.length": varint(0),
+		"rocksdb.format.version":   varint(0),
+		"rocksdb.index.size":       varint(w.indexSize),
+		"rocksdb.num.data.blocks":  varint(uint64(w.dataBlocks)),
+		"rocksdb.num.entries":      varint(w.entries),
+		"rocksdb.raw.key.size":     varint(w.rawKeySize),
+		"rocksdb.raw.value.size":   varint(w.rawValueSize),
+		// External files are ingested at a global sequence number that replaces the zero
+		// sequence numbers of their keys.
+		"rocksdb.external_sst_file.version":      string(binary.LittleEndian.AppendUint32(nil, externalFileVersion)),
+		"rocksdb.external_sst_file.global_seqno": string(binary.LittleEndian.AppendUint64(nil, 0)),
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	block := blockBuilder{restartInterval: 1}
+	for _, name := range names {
+		block.add([]byte(name), []byte(props[name]))
+	}
+	return block.finish()
+}
+
+// Export writes the elements of list to w as a table, with values encoded by codec.
+// The list's keys must be in byte-wise order, as tables order keys byte-wise; a list ordered by
+// another comparator fails at the first key that is not. Concurrent writes to the list may or
+// may not be exported, as with any iteration; export a frozen list for a consistent table.
+func Export(w io.Writer, list *skiplist.SkipList, codec skiplist.Codec, opts Options) error {
+	tw := NewWriter(w, opts)
+	for e := list.Front(); e != nil; e = e.Next() {
+		value, err := codec.Encode(e.Value())
+		if err != nil {
+			return fmt.Errorf("sstable: encoding value of key %s: %v", strconv.Quote(string(e.Key())), err)
+		}
+		if err := tw.Add(e.Key(), value); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
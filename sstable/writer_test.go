@@ -0,0 +1,166 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+// readBlock returns the contents of the block at handle, verifying its trailer.
+func readBlock(t *testing.T, table []byte, handle blockHandle) []byte {
+	t.Helper()
+	end := handle.offset + handle.size
+	contents, trailer := table[handle.offset:end], table[end:end+blockTrailerSize]
+	if trailer[0] != noCompression {
+		t.Fatal("unexpected compression", trailer[0])
+	}
+	crc := crc32.Update(crc32.Checksum(contents, castagnoli), castagnoli, trailer[:1])
+	if binary.LittleEndian.Uint32(trailer[1:]) != maskChecksum(crc) {
+		t.Fatal("wrong block checksum at", handle.offset)
+	}
+	return contents
+}
+
+// blockEntries decodes the entries of a block, checking that its restart points start entries.
+func blockEntries(t *testing.T, block []byte) (keys, values [][]byte) {
+	t.Helper()
+	n := int(binary.LittleEndian.Uint32(block[len(block)-4:]))
+	restartsAt := len(block) - 4 - 4*n
+	restarts := map[int]bool{}
+	for i := 0; i < n; i++ {
+		restarts[int(binary.LittleEndian.Uint32(block[restartsAt+4*i:]))] = true
+	}
+
+	var key []byte
+	for pos := 0; pos < restartsAt; {
+		shared, n1 := binary.Uvarint(block[pos:])
+		unshared, n2 := binary.Uvarint(block[pos+n1:])
+		valueLen, n3 := binary.Uvarint(block[pos+n1+n2:])
+		if restarts[pos] && shared != 0 {
+			t.Fatal("restart point with a shared prefix at", pos)
+		}
+		pos += n1 + n2 + n3
+
+		key = append(key[:shared:shared], block[pos:pos+int(unshared)]...)
+		pos += int(unshared)
+		keys = append(keys, key)
+		values = append(values, block[pos:pos+int(valueLen)])
+		pos += int(valueLen)
+	}
+	return keys, values
+}
+
+func decodeHandle(t *testing.T, buf []byte) (blockHandle, int) {
+	handle, n, ok := decodeBlockHandle(buf)
+	if !ok {
+		t.Fatal("malformed block handle")
+	}
+	return handle, n
+}
+
+func TestExport(t *testing.T) {
+	list := skiplist.New()
+	for i := 0; i < 1000; i++ {
+		list.Set([]byte(fmt.Sprintf("key-%04d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, list, skiplist.BytesCodec{}, Options{BlockSize: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	table := buf.Bytes()
+
+	footer := table[len(table)-footerSize:]
+	if binary.LittleEndian.Uint64(footer[footerSize-8:]) != tableMagic {
+		t.Fatal("wrong magic number")
+	}
+	metaIndexHandle, n := decodeHandle(t, footer)
+	indexHandle, _ := decodeHandle(t, footer[n:])
+
+	indexKeys, handles := blockEntries(t, readBlock(t, table, indexHandle))
+	if len(indexKeys) < 10 {
+		t.Fatal("expected several data blocks", len(indexKeys))
+	}
+
+	i := 0
+	for b, encoded := range handles {
+		handle, _ := decodeHandle(t, encoded)
+		keys, values := blockEntries(t, readBlock(t, table, handle))
+		for j, key := range keys {
+			userKey, trailer := key[:len(key)-8], binary.LittleEndian.Uint64(key[len(key)-8:])
+			if string(userKey) != fmt.Sprintf("key-%04d", i) || trailer != valueKind ||
+				string(values[j]) != fmt.Sprintf("value-%d", i) {
+				t.Fatal("wrong entry", i, string(userKey), trailer, string(values[j]))
+			}
+			i++
+		}
+		if !bytes.Equal(indexKeys[b], keys[len(keys)-1]) {
+			t.Fatal("index keys must be the last key of their block")
+		}
+	}
+	if i != 1000 {
+		t.Fatal("wrong number of entries", i)
+	}
+
+	names, handles := blockEntries(t, readBlock(t, table, metaIndexHandle))
+	if len(names) != 1 || string(names[0]) != propertiesBlockName {
+		t.Fatal("wrong meta index", names)
+	}
+	propertiesHandle, _ := decodeHandle(t, handles[0])
+	names, values := blockEntries(t, readBlock(t, table, propertiesHandle))
+	props := map[string][]byte{}
+	for j, name := range names {
+		if j > 0 && bytes.Compare(names[j-1], name) >= 0 {
+			t.Fatal("properties must be sorted")
+		}
+		props[string(name)] = values[j]
+	}
+
+	if entries, _ := binary.Uvarint(props["rocksdb.num.entries"]); entries != 1000 {
+		t.Fatal("wrong number of entries property", entries)
+	}
+	if blocks, _ := binary.Uvarint(props["rocksdb.num.data.blocks"]); int(blocks) != len(indexKeys) {
+		t.Fatal("wrong number of data blocks property", blocks)
+	}
+	if string(props["rocksdb.comparator"]) != bytewiseComparator {
+		t.Fatal("wrong comparator property", props["rocksdb.comparator"])
+	}
+}
+
+func TestWriterRejectsUnsortedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, Options{})
+	if err := w.Add([]byte("b"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add([]byte("a"), nil); err == nil {
+		t.Fatal("out of order keys must be rejected")
+	}
+	if err := w.Add([]byte("b"), nil); err == nil {
+		t.Fatal("duplicate keys must be rejected")
+	}
+
+	list := skiplist.New(skiplist.WithComparator(func(a, b []byte) int { return bytes.Compare(b, a) }))
+	list.Set([]byte("a"), []byte{})
+	list.Set([]byte("b"), []byte{})
+	if err := Export(&buf, list, skiplist.BytesCodec{}, Options{}); err == nil {
+		t.Fatal("lists not in byte-wise order must not be exported")
+	}
+}
+
+func TestExportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, skiplist.New(), skiplist.BytesCodec{}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	table := buf.Bytes()
+	_, n := decodeHandle(t, table[len(table)-footerSize:])
+	indexHandle, _ := decodeHandle(t, table[len(table)-footerSize+n:])
+	if keys, _ := blockEntries(t, readBlock(t, table, indexHandle)); len(keys) != 0 {
+		t.Fatal("an empty table must have an empty index", keys)
+	}
+}
@@ -0,0 +1,91 @@
+package skiplist
+
+// Stats is a point-in-time summary of a list.
+type Stats struct {
+	// Name is the name the list was constructed with.
+	Name string
+	// Labels are the labels the list was constructed with.
+	Labels map[string]string
+	// Length is the number of elements in the list.
+	Length int
+	// MaxLevel is the maximum tower height of the list.
+	MaxLevel int
+	// LevelCounts holds the number of elements whose tower reaches each level, starting at
+	// the bottom level, which holds every element.
+	LevelCounts []int
+	// OccupiedLevel is the height of the tallest tower in the list, or 0 if it is empty. Well
+	// below MaxLevel, it suggests the list could do with a lower maximum level.
+	OccupiedLevel int
+	// AverageHeight is the average height of the towers in the list, which is 1/(1-p) for a
+	// probability p, or 0 if the list is empty.
+	AverageHeight float64
+	// KeyBytes is the total length of the keys in the list.
+	KeyBytes int64
+	// ValueBytes is the total size of the values in the list, as reported by the sizer set
+	// WithValueSizer. Without one, it is the total length of the values that are byte slices or
+	// strings, and values of other types count for nothing.
+	ValueBytes int64
+	// Inserts is the number of elements inserted into the list over its lifetime.
+	Inserts uint64
+	// Appends is the number of inserts whose key sorted after every other key in the list.
+	// When nearly all inserts are appends, the list is effectively append-only.
+	Appends uint64
+	// TailFastPath reports whether inserts currently skip the search when appending, which
+	// happens automatically when the workload is effectively append-only.
+	TailFastPath bool
+	// Weight is the total weight of the elements, as assigned by the list's Weigher.
+	Weight int64
+	// HotKeys holds the most frequently accessed keys, hottest first.
+	// It is only populated when the list was constructed WithHotKeyTracking.
+	HotKeys []HotKey
+	// StatsSampling is the rate at which access statistics are sampled: on average one in
+	// every StatsSampling operations is counted. Sampled counts, such as those of HotKeys, are scaled
+	// up accordingly and are estimates.
+	StatsSampling int
+	// CASRetries is the number of compare-and-swaps that writers of a ConcurrentSkipList lost to
+	// other writers and retried.
+	CASRetries uint64
+	// LockFallbacks is the number of writes to a ConcurrentSkipList that exceeded its retry
+	// limit and fell back to retrying under a lock (see WithRetryLimit).
+	LockFallbacks uint64
+	// LockWaits holds the time spent waiting to lock the list, by operation, for the operations
+	// sampled so far. It is only populated when the list was constructed WithLockWaitTracking.
+	LockWaits []LockWait
+}
+
+// Stats returns a summary of the list.
+func (list *SkipList) Stats() Stats {
+	list.mutex.RLock()
+	stats := Stats{
+		Name:          list.name,
+		Labels:        list.Labels(),
+		Length:        list.Length,
+		MaxLevel:      list.maxLevel,
+		LevelCounts:   append([]int(nil), list.levelCounts...),
+		KeyBytes:      list.keyBytes,
+		ValueBytes:    list.valueBytes,
+		Inserts:       list.inserts,
+		Appends:       list.appends,
+		TailFastPath:  list.appendMode(),
+		Weight:        list.weight,
+		StatsSampling: list.statsSampling,
+		LockWaits:     list.lockWaitStats(),
+	}
+	list.mutex.RUnlock()
+
+	towers := 0
+	for i, n := range stats.LevelCounts {
+		if n > 0 {
+			stats.OccupiedLevel = i + 1
+		}
+		towers += n
+	}
+	if stats.Length > 0 {
+		stats.AverageHeight = float64(towers) / float64(stats.Length)
+	}
+
+	if list.hotKeys != nil {
+		stats.HotKeys = list.hotKeys.hotKeys()
+	}
+	return stats
+}
@@ -0,0 +1,212 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStatsHotKeys(t *testing.T) {
+	list := New(WithName("hot"), WithHotKeyTracking(2))
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	for i := 0; i < 50; i++ {
+		list.Get(orderedKey(7))
+		list.Get(orderedKey(42))
+	}
+	list.Get(orderedKey(42))
+
+	stats := list.Stats()
+	if stats.Name != "hot" || stats.Length != 100 || stats.LevelCounts[0] != 100 {
+		t.Fatal("wrong stats", stats)
+	}
+
+	if len(stats.HotKeys) != 2 {
+		t.Fatal("wrong number of hot keys", stats.HotKeys)
+	}
+
+	if orderedKeyValue(stats.HotKeys[0].Key) != 42 || orderedKeyValue(stats.HotKeys[1].Key) != 7 {
+		t.Fatal("wrong hot keys", stats.HotKeys)
+	}
+
+	if stats.HotKeys[0].Count < 52 {
+		t.Fatal("hot key count must not be underestimated", stats.HotKeys[0].Count)
+	}
+}
+
+func TestStatsWithoutHotKeys(t *testing.T) {
+	list := New()
+	list.Get([]byte("a"))
+
+	if stats := list.Stats(); stats.HotKeys != nil {
+		t.Fatal("hot keys must not be tracked by default", stats.HotKeys)
+	}
+}
+
+func TestStatsMonotonicInserts(t *testing.T) {
+	list := New()
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	checkSanity(list, t)
+
+	stats := list.Stats()
+	if stats.Inserts != 1000 || stats.Appends != 1000 || !stats.TailFastPath {
+		t.Fatal("ascending inserts must be detected as append-only", stats)
+	}
+
+	// Removing the tail must keep appends correctly linked.
+	for i := uint64(900); i < 1000; i++ {
+		list.Remove(orderedKey(i))
+	}
+	for i := uint64(950); i < 1050; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	checkSanity(list, t)
+
+	if list.Length != 1000 {
+		t.Fatal("wrong list length", list.Length)
+	}
+
+	for i := uint64(10000); i > 0; i -= 5 {
+		list.Set(orderedKey(i+3), i)
+	}
+	checkSanity(list, t)
+
+	if stats = list.Stats(); stats.TailFastPath {
+		t.Fatal("random inserts must disable the tail fast path", stats)
+	}
+}
+
+func TestStatsSampling(t *testing.T) {
+	list := New(WithStatsSampling(10), WithHotKeyTracking(2))
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 2)
+
+	for i := 0; i < 100000; i++ {
+		list.Get([]byte("a"))
+		if i%4 == 0 {
+			list.Get([]byte("b"))
+		}
+	}
+
+	stats := list.Stats()
+	if stats.StatsSampling != 10 {
+		t.Fatal("wrong sampling rate", stats.StatsSampling)
+	}
+
+	if len(stats.HotKeys) != 2 || string(stats.HotKeys[0].Key) != "a" {
+		t.Fatal("wrong hot keys", stats.HotKeys)
+	}
+
+	// About 1 in 10 of the 125002 accesses are sampled, so the scaled total must be close.
+	total := stats.HotKeys[0].Count + stats.HotKeys[1].Count
+	if total < 112500 || total > 137500 {
+		t.Fatal("sampled counts must be scaled up to the number of accesses", total)
+	}
+}
+
+func BenchmarkGetHotKeys(b *testing.B) {
+	for _, n := range []int{1, 100} {
+		list := New(WithHotKeyTracking(10), WithStatsSampling(n))
+		for i := 0; i < 1000; i++ {
+			list.Set(benchKey(i), i)
+		}
+
+		b.Run(fmt.Sprintf("sampling=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				list.Get(benchKey(i % 1000))
+			}
+		})
+	}
+}
+
+func TestStatsLockWaits(t *testing.T) {
+	if New().Stats().LockWaits != nil {
+		t.Fatal("lock waits must only be tracked when enabled")
+	}
+
+	list := New(WithLockWaitTracking())
+	list.Set([]byte("a"), 1)
+	list.Remove([]byte("a"))
+
+	list.mutex.Lock()
+	done := make(chan struct{})
+	go func() {
+		list.Set([]byte("b"), 2)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	list.mutex.Unlock()
+	<-done
+
+	waits := map[string]LockWait{}
+	for _, w := range list.Stats().LockWaits {
+		waits[w.Op] = w
+	}
+	if len(waits) != 2 || waits["Set"].Acquisitions != 2 || waits["Remove"].Acquisitions != 1 {
+		t.Fatal("every lock acquisition must be counted by operation", waits)
+	}
+	if w := waits["Set"]; w.MaxWait < 10*time.Millisecond || w.Wait < w.MaxWait {
+		t.Fatal("the wait for a held lock must be measured", w)
+	}
+}
+
+func TestStatsSizes(t *testing.T) {
+	list := New(WithProbability(0.5), WithTombstones())
+	if stats := list.Stats(); stats.OccupiedLevel != 0 || stats.AverageHeight != 0 {
+		t.Fatal("wrong stats of an empty list", stats)
+	}
+
+	for i := uint64(0); i < 10000; i++ {
+		list.Set(orderedKey(i), "value")
+	}
+	list.Set(orderedKey(0), []byte("v"))
+	list.Set(orderedKey(1), 1)
+	list.Remove(orderedKey(2))
+
+	stats := list.Stats()
+	if stats.KeyBytes != 8*10000 || stats.ValueBytes != 5*9997+1 {
+		t.Fatal("wrong byte sizes", stats.KeyBytes, stats.ValueBytes)
+	}
+	if stats.OccupiedLevel < 10 || stats.OccupiedLevel > stats.MaxLevel || stats.LevelCounts[stats.OccupiedLevel-1] == 0 {
+		t.Fatal("wrong occupied level", stats.OccupiedLevel)
+	}
+	if stats.AverageHeight < 1.9 || stats.AverageHeight > 2.1 {
+		t.Fatal("average height far from 1/(1-p)", stats.AverageHeight)
+	}
+
+	list.Clear()
+	if stats := list.Stats(); stats.KeyBytes != 0 || stats.ValueBytes != 0 {
+		t.Fatal("sizes not reset by Clear", stats)
+	}
+}
+
+func TestApproxMemoryUsage(t *testing.T) {
+	type blob struct{ size int64 }
+	list := New(WithValueSizer(func(value interface{}) int64 {
+		return value.(blob).size
+	}))
+	empty := list.ApproxMemoryUsage()
+
+	for i := uint64(0); i < 1000; i++ {
+		list.Set(orderedKey(i), blob{100})
+	}
+	used := list.ApproxMemoryUsage() - empty
+	// Each element holds 8 bytes of key, 100 of value, its struct and at least one tower slot.
+	if min := int64(1000 * (8 + 100 + elementSize + pointerSize)); used < min || used > 2*min {
+		t.Fatal("implausible memory usage", used, min)
+	}
+
+	list.Set(orderedKey(0), blob{1100})
+	if got := list.ApproxMemoryUsage() - empty; got != used+1000 {
+		t.Fatal("update not accounted for", got-used)
+	}
+
+	list.Clear()
+	if got := list.ApproxMemoryUsage(); got != empty {
+		t.Fatal("memory of a cleared list must be that of an empty one", got, empty)
+	}
+}
@@ -0,0 +1,56 @@
+package skiplist
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// tombstoneValue is the value of tombstones. Tombstones point their value at it, so that they
+// are told apart from elements whose value is nil by address, and their value reads as nil.
+var tombstoneValue interface{}
+
+// IsTombstone reports whether the element is a tombstone, left by removing its key from a list
+// constructed WithTombstones. The value of a tombstone is nil.
+func (e *Element) IsTombstone() bool {
+	return atomic.LoadPointer(&e.value) == unsafe.Pointer(&tombstoneValue)
+}
+
+// Tombstones returns the number of tombstones in the list, which Len counts among its elements.
+func (list *SkipList) Tombstones() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.tombstones
+}
+
+// SkipTombstones makes the iterator step over tombstones, so that it only visits live elements,
+// or visit them again if skip is false. Iterators visit tombstones by default.
+func (it *Iterator) SkipTombstones(skip bool) {
+	it.skipTombstones = skip
+}
+
+// bury turns element into a tombstone. The caller must hold the list mutex.
+func (list *SkipList) bury(element *Element) {
+	value := element.Value()
+	list.store(element, nil)
+	atomic.StorePointer(&element.value, unsafe.Pointer(&tombstoneValue))
+	list.tombstones++
+	if list.namespaces != nil {
+		list.namespaces.removed(element)
+	}
+	if list.watchers != nil {
+		list.emit(EventDelete, element.key, value, element.Seq())
+	}
+}
+
+// insertTombstone links a tombstone for key after the previous nodes found by a search,
+// returning any order violation found when the list verifies inserts. The caller must hold the
+// list mutex.
+func (list *SkipList) insertTombstone(prevs []*elementNode, key []byte) *OrderViolation {
+	// The element is a tombstone before it is linked, so that namespaces do not count it.
+	element := list.allocate(key, nil, list.randLevel())
+	atomic.StorePointer(&element.value, unsafe.Pointer(&tombstoneValue))
+	violation := list.linkNew(prevs, element)
+	list.tombstones++
+	return violation
+}
@@ -0,0 +1,85 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestTombstones(t *testing.T) {
+	var removed int
+	list := New(WithTombstones(), WithRemoveCallback(func(*Element, RemoveReason) { removed++ }))
+	for i := uint64(0); i < 10; i++ {
+		list.Set(orderedKey(i), i)
+	}
+
+	if e := list.Remove(orderedKey(3)); e == nil || !e.IsTombstone() || e.Value() != nil {
+		t.Fatal("removing a key must leave a tombstone in its place", e)
+	}
+	if list.Remove(orderedKey(3)) != nil || list.Remove(orderedKey(20)) != nil || removed != 1 {
+		t.Fatal("removing a missing key must remove nothing", removed)
+	}
+	if e := list.Get(orderedKey(20)); e == nil || !e.IsTombstone() {
+		t.Fatal("removing a missing key must write a tombstone for it")
+	}
+	if list.Len() != 11 || list.Tombstones() != 2 {
+		t.Fatal("wrong counts", list.Len(), list.Tombstones())
+	}
+	checkSanity(list, t)
+
+	var visited int
+	it := list.NewIterator()
+	it.SkipTombstones(true)
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		if it.Element().IsTombstone() {
+			t.Fatal("tombstones must be skipped when asked")
+		}
+		visited++
+	}
+	if visited != 9 {
+		t.Fatal("every live element must be visited", visited)
+	}
+	if it.SeekToLast(); it.Key() == nil || string(it.Key()) != string(orderedKey(9)) {
+		t.Fatal("backward seeks must skip tombstones too")
+	}
+	if it.Seek(orderedKey(3)); string(it.Key()) != string(orderedKey(4)) {
+		t.Fatal("seeks must land past tombstones")
+	}
+	if it.Prev(); string(it.Key()) != string(orderedKey(2)) {
+		t.Fatal("stepping back must skip tombstones")
+	}
+
+	if e, created := list.GetOrCreate(orderedKey(3), func() interface{} { return "back" }); !created || e.IsTombstone() || e.Value() != "back" {
+		t.Fatal("a tombstone must count as a missing key", created)
+	}
+	if list.Tombstones() != 1 {
+		t.Fatal("reviving a key must drop its tombstone", list.Tombstones())
+	}
+
+	var batch WriteBatch
+	batch.Remove(orderedKey(4))
+	batch.Remove(orderedKey(30))
+	if err := list.Apply(&batch); err != nil || list.Tombstones() != 3 || !list.Get(orderedKey(30)).IsTombstone() {
+		t.Fatal("batched removals must leave tombstones", err, list.Tombstones())
+	}
+
+	if n := list.RemoveRange(orderedKey(20), nil); n != 2 || list.Tombstones() != 1 {
+		t.Fatal("removing a range must unlink tombstones", n, list.Tombstones())
+	}
+	checkSanity(list, t)
+}
+
+func TestUpdateTombstone(t *testing.T) {
+	inserts := 0
+	list := New(WithTombstones(), WithInsertHook(func([]byte, interface{}) { inserts++ }))
+	list.Set([]byte("a"), 1)
+	list.Remove([]byte("a"))
+
+	if list.CompareAndSet([]byte("a"), nil, 2) {
+		t.Fatal("CompareAndSet must treat a tombstone as absent")
+	}
+	if list.Update([]byte("a"), func(old interface{}) (interface{}, bool) { return 3, true }) != nil {
+		t.Fatal("Update must treat a tombstone as absent")
+	}
+	if e := list.Get([]byte("a")); e == nil || !e.IsTombstone() || inserts != 1 {
+		t.Fatal("a tombstone must not be revived by Update", inserts)
+	}
+}
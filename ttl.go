@@ -0,0 +1,198 @@
+package skiplist
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// SetWithTTL is like Set, but the value expires after ttl, from when on Get, Contains and
+// iterators treat the key as absent, and writes treat it as a new key, reusing its element.
+// Expired elements are reclaimed lazily, by the Get that finds them, or all at once by ExpireNow,
+// and are reported to the remove callback with the Expired reason. Until then, they still count
+// towards Len and Weight, and walking elements with Front and Next visits them.
+//
+// A Set of the key clears its expiry, while a Merge or Update keeps it. A non-positive ttl
+// expires the value at once.
+func (list *SkipList) SetWithTTL(key []byte, value interface{}, ttl time.Duration) *Element {
+	element, _ := list.SetWithTTLE(key, value, ttl)
+	return element
+}
+
+// SetWithTTLE is like SetWithTTL, but returns an *Error describing why a write was rejected.
+func (list *SkipList) SetWithTTLE(key []byte, value interface{}, ttl time.Duration) (*Element, error) {
+	if list.metrics != nil {
+		defer list.observeLatency("SetWithTTL", time.Now())
+	}
+	if err := list.checkKey("SetWithTTL", key); err != nil {
+		return nil, err
+	}
+
+	if list.hotKeys != nil {
+		list.hotKeys.record(key)
+	}
+
+	element, _, err := list.set(key, value, nil, nil, time.Now().Add(ttl).UnixNano())
+	if err == ErrReadOnly {
+		return list.frozenWrite("SetWithTTL", key, func(overflow *SkipList) (*Element, error) {
+			element, err := overflow.SetWithTTLE(key, value, ttl)
+			return list.replaced("SetWithTTL", key, element, err)
+		})
+	}
+	if err != nil {
+		return nil, list.newError("SetWithTTL", key, err)
+	}
+
+	if list.metrics != nil {
+		list.metrics.IncSet()
+	}
+	list.enforceMaxWeight()
+	return element, nil
+}
+
+// ExpiresAt returns when the element expires, or the zero time if it never does.
+func (e *Element) ExpiresAt() time.Time {
+	if nanos := e.expires.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// expired reports whether the element has expired. Only elements with an expiry read the clock.
+func (e *Element) expired() bool {
+	expires := e.expires.Load()
+	return expires != 0 && time.Now().UnixNano() >= expires
+}
+
+// ExpireNow removes every expired element in a single pass with the list locked, returning how
+// many it removed, which are reported to the remove callback with the Expired reason once the
+// list is unlocked. A frozen list expires nothing.
+func (list *SkipList) ExpireNow() int {
+	expired, _ := list.expireBatch(nil, 0)
+	for _, element := range expired {
+		list.notifyRemove(element, Expired)
+	}
+	return len(expired)
+}
+
+// sweep removes the expired elements like ExpireNow, but takes the list's lock for at most
+// batchSize elements at a time, yielding to other goroutines between batches.
+func (list *SkipList) sweep(batchSize int) {
+	var resume []byte
+	for started := false; !started || resume != nil; started = true {
+		var expired []*Element
+		expired, resume = list.expireBatch(resume, batchSize)
+		for _, element := range expired {
+			list.notifyRemove(element, Expired)
+		}
+		runtime.Gosched()
+	}
+}
+
+// expireBatch visits up to batchSize elements, or every element if batchSize is not positive,
+// starting at the first key >= resume, or at the front of the list when resume is nil, and
+// removes those that have expired. Returns the removed elements and the key to resume from,
+// which is nil once the end of the list is reached.
+func (list *SkipList) expireBatch(resume []byte, batchSize int) ([]*Element, []byte) {
+	list.lock(lockExpire)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, nil
+	}
+
+	var prevs []*elementNode
+	if resume == nil {
+		prevs = list.prevNodesCache
+		for i := range prevs {
+			prevs[i] = &list.elementNode
+		}
+	} else {
+		prevs = list.getPrevElementNodes(resume)
+	}
+
+	var expired []*Element
+	now := time.Now().UnixNano()
+	element := prevs[0].Next()
+	for visited := 0; element != nil; visited++ {
+		if batchSize > 0 && visited == batchSize {
+			return expired, element.key
+		}
+
+		next := element.Next()
+		if expires := element.expires.Load(); expires != 0 && now >= expires && !list.held(element) {
+			list.unlink(prevs, element)
+			expired = append(expired, element)
+		} else {
+			for i := range element.next {
+				prevs[i] = &element.elementNode
+			}
+		}
+		element = next
+	}
+	return expired, nil
+}
+
+// Close stops the list's expiry sweeper, if it has one, waiting for a sweep in progress to
+// finish, and stops its watches, closing their channels. The list remains usable, with expired
+// elements reclaimed lazily from then on. Close may be called more than once.
+func (list *SkipList) Close() {
+	if list.sweeper != nil {
+		list.sweeper.stop()
+	}
+	list.unwatchAll()
+}
+
+// sweeper runs the periodic sweeps of a list.
+type sweeper struct {
+	interval  time.Duration
+	batchSize int
+	once      sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func (s *sweeper) run(list *SkipList) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			list.sweep(s.batchSize)
+		}
+	}
+}
+
+func (s *sweeper) stop() {
+	s.once.Do(func() { close(s.done) })
+	<-s.stopped
+}
+
+// reclaim removes element, found expired by a lock-free read, unless it was removed or given
+// a new expiry since.
+func (list *SkipList) reclaim(element *Element) {
+	if list.unlinkExpired(element) {
+		list.notifyRemove(element, Expired)
+	}
+}
+
+// unlinkExpired unlinks element if it is still in the list and expired, reporting whether it
+// did.
+func (list *SkipList) unlinkExpired(element *Element) bool {
+	list.lock(lockExpire)
+	defer list.unlock()
+
+	if list.frozen || list.held(element) {
+		return false
+	}
+	prevs := list.getPrevElementNodes(element.key)
+	if prevs[0].Next() != element || !element.expired() {
+		return false
+	}
+	list.unlink(prevs, element)
+	return true
+}
@@ -0,0 +1,123 @@
+package skiplist
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	var expired []string
+	list := New(WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		if reason == Expired {
+			expired = append(expired, string(e.Key()))
+		}
+	}))
+	list.SetWithTTL([]byte("a"), 1, -time.Second)
+	list.SetWithTTL([]byte("b"), 2, time.Hour)
+	list.SetWithTTL([]byte("c"), 3, -time.Second)
+	list.SetWithTTL([]byte("d"), 4, -time.Second)
+	list.Set([]byte("e"), 5)
+
+	if e := list.Get([]byte("b")); e == nil || e.ExpiresAt().Before(time.Now()) || !list.Get([]byte("e")).ExpiresAt().IsZero() {
+		t.Fatal("live elements must be found, with their expiry")
+	}
+	if list.Contains([]byte("a")) || list.Get([]byte("a")) != nil {
+		t.Fatal("expired elements must be treated as absent")
+	}
+	if len(expired) != 1 || list.Len() != 4 {
+		t.Fatal("Get must reclaim the expired element it finds", expired, list.Len())
+	}
+
+	var keys []string
+	it := list.NewIterator()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "e" {
+		t.Fatal("iterators must skip expired elements", keys)
+	}
+	if it.SeekToLast(); string(it.Key()) != "e" {
+		t.Fatal("wrong last element")
+	}
+
+	if e, created := list.GetOrCreate([]byte("c"), func() interface{} { return 30 }); !created || e.Value() != 30 || !e.ExpiresAt().IsZero() {
+		t.Fatal("a write must replace an expired element as a new key")
+	}
+	if n := list.ExpireNow(); n != 1 || list.Len() != 3 || expired[1] != "d" {
+		t.Fatal("ExpireNow must remove every expired element", n, expired)
+	}
+	checkSanity(list, t)
+
+	list.SetWithTTL([]byte("b"), 20, -time.Second)
+	list.Set([]byte("b"), 21)
+	if list.Get([]byte("b")) == nil {
+		t.Fatal("Set must clear the expiry")
+	}
+}
+
+func TestExpirySweeper(t *testing.T) {
+	expired := make(chan struct{}, 100)
+	list := New(WithExpirySweeper(time.Millisecond, 8), WithRemoveCallback(func(e *Element, reason RemoveReason) {
+		if reason == Expired {
+			expired <- struct{}{}
+		}
+	}))
+	defer list.Close()
+
+	for i := uint64(0); i < 100; i++ {
+		if i%2 == 0 {
+			list.SetWithTTL(orderedKey(i), i, time.Millisecond)
+		} else {
+			list.Set(orderedKey(i), i)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the sweeper must remove expired elements", i)
+		}
+	}
+	list.Close()
+	list.Close()
+
+	if list.Len() != 50 {
+		t.Fatal("the sweeper must only remove expired elements", list.Len())
+	}
+	checkSanity(list, t)
+}
+
+func TestApplyClearsExpiry(t *testing.T) {
+	list := New()
+	list.SetWithTTL([]byte("a"), 1, 20*time.Millisecond)
+
+	var batch WriteBatch
+	batch.Set([]byte("a"), 2)
+	if err := list.Apply(&batch); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if element := list.Get([]byte("a")); element == nil || element.Value() != 2 {
+		t.Fatal("a batch Set must clear the expiry", element)
+	}
+}
+
+func TestUpdateExpired(t *testing.T) {
+	list := New()
+	list.SetWithTTL([]byte("a"), 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := list.UpdateE([]byte("a"), func(old interface{}) (interface{}, bool) {
+		return 2, true
+	}); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Update must treat an expired element as absent, got", err)
+	}
+	if list.CompareAndSet([]byte("a"), 1, 3) {
+		t.Fatal("CompareAndSet must treat an expired element as absent")
+	}
+	if list.Get([]byte("a")) != nil {
+		t.Fatal("an expired element must stay absent")
+	}
+}
@@ -10,6 +10,9 @@ import (
 type elementNode struct {
 	list *SkipList
 	next []unsafe.Pointer
+	// spans holds the number of elements spanned by each link in next, if the list maintains
+	// a rank index.
+	spans []int
 }
 
 func (n *elementNode) Next() *Element {
@@ -20,10 +23,80 @@ func (n *elementNode) NextAt(i int) *Element {
 	return (*Element)(atomic.LoadPointer(&n.next[i]))
 }
 
+// Element is a key and its value in a list. An element stays the key's element for as long as
+// the key is in the list: Set, Update, Merge and every other write of an existing key replace
+// the value in place, so external indexes may hold on to *Element across updates. Only removing
+// the key, compacting the list (see Compact), or forwarding the write of a frozen list (see
+// ErrElementReplaced), yields a new element for it.
 type Element struct {
 	elementNode
-	key   []byte
-	value interface{}
+	key []byte
+	// prev points to the previous Element on the bottom level, or is nil for the first element.
+	prev unsafe.Pointer
+	// value points to the current value, and is replaced atomically on update so that
+	// readers never observe a partially written interface. It initially points to initial,
+	// which saves an allocation when inserting.
+	value   unsafe.Pointer
+	initial interface{}
+	seq     atomic.Uint64
+	weight  int64
+	pins    int
+	// accessed is the time of the last recorded access, in nanoseconds since the Unix epoch.
+	accessed atomic.Int64
+	// expires is the time the element expires, in nanoseconds since the Unix epoch, or zero if
+	// it never does.
+	expires atomic.Int64
+}
+
+func newElement(list *SkipList, key []byte, value interface{}, level int) *Element {
+	element := allocElement(level)
+	element.list = list
+	element.key = key
+	element.initial = value
+	element.value = unsafe.Pointer(&element.initial)
+	return element
+}
+
+// allocElement allocates an element together with its tower of the given level, so that an
+// insert costs a single allocation and the tower sits next to the element's fields during a
+// search. Levels are rounded up to one of a few tower sizes, which wastes little space since
+// nearly all towers are short.
+func allocElement(level int) *Element {
+	switch {
+	case level <= 1:
+		return allocElementWith[[1]unsafe.Pointer](level)
+	case level <= 2:
+		return allocElementWith[[2]unsafe.Pointer](level)
+	case level <= 3:
+		return allocElementWith[[3]unsafe.Pointer](level)
+	case level <= 4:
+		return allocElementWith[[4]unsafe.Pointer](level)
+	case level <= 6:
+		return allocElementWith[[6]unsafe.Pointer](level)
+	case level <= 8:
+		return allocElementWith[[8]unsafe.Pointer](level)
+	case level <= 12:
+		return allocElementWith[[12]unsafe.Pointer](level)
+	case level <= 16:
+		return allocElementWith[[16]unsafe.Pointer](level)
+	case level <= 24:
+		return allocElementWith[[24]unsafe.Pointer](level)
+	case level <= 32:
+		return allocElementWith[[32]unsafe.Pointer](level)
+	case level <= 48:
+		return allocElementWith[[48]unsafe.Pointer](level)
+	default:
+		return allocElementWith[[64]unsafe.Pointer](level)
+	}
+}
+
+func allocElementWith[Tower any](level int) *Element {
+	node := new(struct {
+		Element
+		tower Tower
+	})
+	node.next = unsafe.Slice((*unsafe.Pointer)(unsafe.Pointer(&node.tower)), level)
+	return &node.Element
 }
 
 // Key allows retrieval of the key for a given Element
@@ -31,9 +104,21 @@ func (e *Element) Key() []byte {
 	return e.key
 }
 
-// Value allows retrieval of the value for a given Element
+// Value allows retrieval of the value for a given Element.
+// It is safe to call concurrently with updates to the element, and returns either the old
+// or the new value.
 func (e *Element) Value() interface{} {
-	return e.value
+	return *(*interface{})(atomic.LoadPointer(&e.value))
+}
+
+// Seq returns the sequence number of the last mutation of the element: its insert, the last
+// update of its value, or its removal. Sequence numbers increase with every mutation of the list.
+func (e *Element) Seq() uint64 {
+	return e.seq.Load()
+}
+
+func (e *Element) storeValue(value interface{}) {
+	atomic.StorePointer(&e.value, unsafe.Pointer(&value))
 }
 
 // Next returns the following Element or nil if we're at the end of the list.
@@ -42,13 +127,101 @@ func (element *Element) Next() *Element {
 	return element.elementNode.Next()
 }
 
+// Prev returns the preceding Element or nil if we're at the start of the list.
+// Only operates on the bottom level of the skip list, which is doubly linked.
+func (element *Element) Prev() *Element {
+	return (*Element)(atomic.LoadPointer(&element.prev))
+}
+
 type SkipList struct {
 	elementNode
-	maxLevel       int
-	Length         int
-	randSource     rand.Source
-	probability    float64
-	probTable      []float64
-	mutex          sync.RWMutex
-	prevNodesCache []*elementNode
+	name             string
+	labels           map[string]string
+	maxKeySize       int
+	compare          func(a, b []byte) int
+	byteOrder        bool
+	maxLevel         int
+	expectedSize     int
+	Length           int
+	randSource       rand.Source
+	randMutex        sync.Mutex
+	newRand          func() rand.Source
+	probability      float64
+	probTable        []float64
+	mutex            sync.RWMutex
+	prevNodesCache   []*elementNode
+	tails            []*elementNode
+	last             unsafe.Pointer
+	levelCounts      []int
+	inserts          uint64
+	appends          uint64
+	recentInserts    uint64
+	recentAppends    uint64
+	seq              uint64
+	hotKeyCount      int
+	hotKeys          *hotKeyTracker
+	statsSampling    int
+	onRemove         func(removal, RemoveReason)
+	onInsert         func(key []byte, value interface{})
+	onUpdate         func(key []byte, old, new interface{})
+	watchers         []*watcher
+	watchBuffer      int
+	onOrderViolation func(OrderViolation)
+	namespaces       *namespaceRegistry
+	frozen           bool
+	frozenPolicy     FrozenPolicy
+	overflow         *SkipList
+	weigher          Weigher
+	weight           int64
+	maxWeight        int64
+	capacity         int
+	keyBytes         int64
+	byteQuota        int64
+	valueBytes       int64
+	nodeBytes        int64
+	valueSizer       func(value interface{}) int64
+	metrics          Metrics
+	pinSites         map[*Element][]pinSite
+	arena            *arena
+	fingerSearch     bool
+	merge            MergeFunc
+	ghostCapacity    int
+	ghosts           *ghostList
+	reservations     []*Reservation
+	fingers          sync.Pool
+	clampMaxLevel    bool
+	rankIndex        bool
+	rankCache        []int
+	trackAccess      bool
+	watermarks       []*watermark
+	// frozenRanges holds the key ranges frozen by FreezeRange.
+	frozenRanges []*FrozenRange
+	// retryLimit is the retry limit of a ConcurrentSkipList configured by the options.
+	retryLimit int
+	// sweeper periodically removes expired elements, if the list was constructed with one.
+	sweeper *sweeper
+	// tombstoneMode makes removals leave tombstones, which the list holds tombstones of.
+	tombstoneMode bool
+	tombstones    int
+	// history holds the past versions of the keys mutated since versionsFloor, if the list keeps
+	// up to maxVersions of them, keyed like the list with *keyHistory values.
+	history       *SkipList
+	maxVersions   int
+	versionsFloor uint64
+	// lostSeq is the sequence number of the last removal Changes cannot report: that of an
+	// element unlinked without leaving a tombstone, or of a tombstone unlinked in turn.
+	lostSeq uint64
+	// lockWaits holds the sampled lock waits of each operation, if the list tracks them.
+	lockWaits *lockWaits
+	// lockedOp is the operation holding the list mutex for writing, reported if it panics.
+	lockedOp lockOp
+	// evictHand is the key at which the next search for elements to evict starts, or nil to
+	// start at the front of the list.
+	evictHand []byte
+	// onMaxLevelClamped is called by New when it clamps maxLevel.
+	onMaxLevelClamped func(requested, clamped int)
+	// linkVersion counts the elements linked and unlinked, invalidating the paths cached by fingers.
+	linkVersion atomic.Uint64
+	// epoch is the epoch of the list's current contents, replaced on every Rotate.
+	epoch atomic.Pointer[epoch]
 }
@@ -0,0 +1,223 @@
+// Package typed provides a skip list with type-parameterized keys and values. It mirrors the
+// []byte-keyed skiplist package, sharing its tower height calculation, for callers that would
+// otherwise encode keys into bytes and box values into interface{}.
+package typed
+
+import (
+	"bytes"
+	"cmp"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/m3db/fast-skiplist/internal/tower"
+)
+
+const (
+	DefaultMaxLevel    int     = 18
+	DefaultProbability float64 = 1 / math.E
+)
+
+// Element is an entry of a SkipList.
+type Element[K, V any] struct {
+	next []atomic.Pointer[Element[K, V]]
+	key  K
+	// value points to the current value and is replaced atomically on update.
+	// It initially points to initial, which saves an allocation when inserting.
+	value   atomic.Pointer[V]
+	initial V
+}
+
+// Key returns the key of the element.
+func (e *Element[K, V]) Key() K {
+	return e.key
+}
+
+// Value returns the value of the element. It is safe to call concurrently with updates.
+func (e *Element[K, V]) Value() V {
+	return *e.value.Load()
+}
+
+// Next returns the following element, or nil at the end of the list.
+func (e *Element[K, V]) Next() *Element[K, V] {
+	return e.next[0].Load()
+}
+
+// SkipList is a skip list of V values ordered by K keys according to a comparison function.
+// It is safe for concurrent use, with the same guarantees as skiplist.SkipList.
+type SkipList[K, V any] struct {
+	// head is a sentinel element whose key and value are unused.
+	head       Element[K, V]
+	compare    func(a, b K) int
+	maxLevel   int
+	length     int
+	randSource rand.Source
+	probTable  []float64
+	mutex      sync.RWMutex
+	prevs      []*Element[K, V]
+}
+
+// New creates a skip list ordering keys by compare, which returns a negative number when a < b,
+// zero when a == b and a positive number when a > b.
+func New[K, V any](compare func(a, b K) int) *SkipList[K, V] {
+	return NewWithMaxLevel[K, V](compare, DefaultMaxLevel)
+}
+
+// NewBytes creates a skip list of []byte keys and values in bytes.Compare order. Values are
+// stored as they are rather than boxed in an interface{}, which saves an allocation and an
+// interface header per entry compared to the skiplist package, for memtables of encoded values.
+func NewBytes() *SkipList[[]byte, []byte] {
+	return New[[]byte, []byte](bytes.Compare)
+}
+
+// NewOrdered creates a skip list for key types with a natural order, such as ints and strings.
+func NewOrdered[K cmp.Ordered, V any]() *SkipList[K, V] {
+	return New[K, V](cmp.Compare[K])
+}
+
+// NewWithMaxLevel creates a skip list with MaxLevel set to the provided number.
+func NewWithMaxLevel[K, V any](compare func(a, b K) int, maxLevel int) *SkipList[K, V] {
+	if maxLevel < 1 || maxLevel > 64 {
+		panic("maxLevel for a SkipList must be a positive integer <= 64")
+	}
+
+	return &SkipList[K, V]{
+		head:       Element[K, V]{next: make([]atomic.Pointer[Element[K, V]], maxLevel)},
+		compare:    compare,
+		maxLevel:   maxLevel,
+		randSource: tower.NewXorshift(tower.UniqueSeed()),
+		probTable:  tower.ProbabilityTable(DefaultProbability, maxLevel),
+		prevs:      make([]*Element[K, V], maxLevel),
+	}
+}
+
+// Len returns the number of elements in the list.
+func (list *SkipList[K, V]) Len() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.length
+}
+
+// Front returns the first element of the list, or nil if it is empty.
+func (list *SkipList[K, V]) Front() *Element[K, V] {
+	return list.head.Next()
+}
+
+// Set inserts a value in the list with the specified key, or updates the value of the existing
+// element with that key. Returns the element holding the value.
+func (list *SkipList[K, V]) Set(key K, value V) *Element[K, V] {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	prevs := list.getPrevElements(key)
+	if element := prevs[0].Next(); element != nil && list.compare(element.key, key) == 0 {
+		// Copying value keeps it from escaping to the heap on inserts, which store it inline.
+		updated := value
+		element.value.Store(&updated)
+		return element
+	}
+
+	element := allocElement[K, V](tower.Level(list.randSource, list.probTable))
+	element.key = key
+	element.initial = value
+	element.value.Store(&element.initial)
+
+	for i := range element.next {
+		element.next[i].Store(prevs[i].next[i].Load())
+		prevs[i].next[i].Store(element)
+	}
+
+	list.length++
+	return element
+}
+
+// Get returns the element with the given key, or nil if there is none.
+func (list *SkipList[K, V]) Get(key K) *Element[K, V] {
+	if element := list.Seek(key); element != nil && list.compare(element.key, key) == 0 {
+		return element
+	}
+	return nil
+}
+
+// Seek returns the first element whose key is greater than or equal to key, or nil if there is none.
+func (list *SkipList[K, V]) Seek(key K) *Element[K, V] {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	prev := &list.head
+	var next *Element[K, V]
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next = prev.next[i].Load()
+		for next != nil && list.compare(key, next.key) > 0 {
+			prev = next
+			next = next.next[i].Load()
+		}
+	}
+	return next
+}
+
+// Remove deletes the element with the given key and returns it, or returns nil if there is none.
+func (list *SkipList[K, V]) Remove(key K) *Element[K, V] {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	prevs := list.getPrevElements(key)
+	element := prevs[0].Next()
+	if element == nil || list.compare(element.key, key) != 0 {
+		return nil
+	}
+
+	for i := range element.next {
+		prevs[i].next[i].Store(element.next[i].Load())
+	}
+
+	list.length--
+	return element
+}
+
+// allocElement allocates an element together with its tower of the given level, so that an
+// insert costs a single allocation. Levels are rounded up to one of a few tower sizes.
+func allocElement[K, V any](level int) *Element[K, V] {
+	switch {
+	case level <= 1:
+		return allocElementWith[K, V, [1]atomic.Pointer[Element[K, V]]](level)
+	case level <= 2:
+		return allocElementWith[K, V, [2]atomic.Pointer[Element[K, V]]](level)
+	case level <= 4:
+		return allocElementWith[K, V, [4]atomic.Pointer[Element[K, V]]](level)
+	case level <= 8:
+		return allocElementWith[K, V, [8]atomic.Pointer[Element[K, V]]](level)
+	case level <= 16:
+		return allocElementWith[K, V, [16]atomic.Pointer[Element[K, V]]](level)
+	case level <= 32:
+		return allocElementWith[K, V, [32]atomic.Pointer[Element[K, V]]](level)
+	default:
+		return allocElementWith[K, V, [64]atomic.Pointer[Element[K, V]]](level)
+	}
+}
+
+func allocElementWith[K, V, Tower any](level int) *Element[K, V] {
+	node := new(struct {
+		Element[K, V]
+		tower Tower
+	})
+	node.next = unsafe.Slice((*atomic.Pointer[Element[K, V]])(unsafe.Pointer(&node.tower)), level)
+	return &node.Element
+}
+
+// getPrevElements finds the last element before key on each level. The caller must hold the mutex.
+func (list *SkipList[K, V]) getPrevElements(key K) []*Element[K, V] {
+	prev := &list.head
+	for i := list.maxLevel - 1; i >= 0; i-- {
+		next := prev.next[i].Load()
+		for next != nil && list.compare(key, next.key) > 0 {
+			prev = next
+			next = next.next[i].Load()
+		}
+		list.prevs[i] = prev
+	}
+	return list.prevs
+}
@@ -0,0 +1,135 @@
+package typed
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func checkSanity[K, V any](t *testing.T, list *SkipList[K, V]) {
+	for k := range list.head.next {
+		for e := list.head.next[k].Load(); e != nil; e = e.next[k].Load() {
+			if next := e.next[k].Load(); next != nil && list.compare(e.key, next.key) >= 0 {
+				t.Fatalf("keys out of order on level %d: %v, %v", k, e.key, next.key)
+			}
+		}
+	}
+
+	n := 0
+	for e := list.Front(); e != nil; e = e.Next() {
+		n++
+	}
+	if n != list.Len() {
+		t.Fatal("list length must match the bottom level", n, list.Len())
+	}
+}
+
+func TestOrderedCRUD(t *testing.T) {
+	list := NewOrdered[int, string]()
+	for _, k := range []int{50, 10, 30, 20, 40} {
+		list.Set(k, strings.Repeat("x", k/10))
+	}
+	list.Set(30, "updated")
+	list.Remove(20)
+	list.Remove(99)
+	checkSanity(t, list)
+
+	if list.Len() != 4 {
+		t.Fatal("wrong length", list.Len())
+	}
+
+	if e := list.Get(30); e == nil || e.Value() != "updated" {
+		t.Fatal("wrong value for 30", e)
+	}
+
+	if list.Get(20) != nil {
+		t.Fatal("found removed key")
+	}
+
+	if e := list.Seek(21); e == nil || e.Key() != 30 {
+		t.Fatal("wrong Seek result", e)
+	}
+
+	var keys []int
+	for e := list.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Key())
+	}
+	if len(keys) != 4 || keys[0] != 10 || keys[3] != 50 {
+		t.Fatal("wrong iteration order", keys)
+	}
+}
+
+type series struct {
+	timestamp int64
+	id        string
+}
+
+func TestCustomComparator(t *testing.T) {
+	list := New[series, float64](func(a, b series) int {
+		if a.timestamp != b.timestamp {
+			if a.timestamp < b.timestamp {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.id, b.id)
+	})
+
+	list.Set(series{2, "a"}, 1)
+	list.Set(series{1, "b"}, 2)
+	list.Set(series{1, "a"}, 3)
+	checkSanity(t, list)
+
+	if k := list.Front().Key(); k != (series{1, "a"}) {
+		t.Fatal("wrong first key", k)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	list := NewOrdered[int, int]()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			list.Set(i, i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			if e := list.Get(i); e != nil && e.Value() != i {
+				t.Error("wrong value", i, e.Value())
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	checkSanity(t, list)
+}
+
+func TestBytes(t *testing.T) {
+	list := NewBytes()
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key-%04d", i))
+	}
+
+	value := []byte("value")
+	i := 0
+	allocs := testing.AllocsPerRun(len(keys)-1, func() {
+		list.Set(keys[i], value)
+		i++
+	})
+	if allocs > 1 {
+		t.Fatal("inserting a []byte value must take a single allocation", allocs)
+	}
+	checkSanity(t, list)
+
+	if e := list.Get([]byte("key-0042")); e == nil || string(e.Value()) != "value" {
+		t.Fatal("wrong element", e)
+	}
+}
@@ -0,0 +1,170 @@
+package skiplist
+
+import (
+	"runtime"
+)
+
+// UpdateFunc computes the new value of an element from its current one. It returns false to
+// leave the element unchanged.
+type UpdateFunc func(old interface{}) (new interface{}, ok bool)
+
+// Update conditionally replaces the value of key. fn is called with the current value while the
+// list is locked, so no other write can intervene between reading the value and replacing it,
+// and the value is replaced only if fn returns true. fn must be quick and must not use the list.
+// Update returns the element if its value was replaced, or nil if the key is not in the list,
+// fn declined the update or the write was rejected (see UpdateE).
+func (list *SkipList) Update(key []byte, fn UpdateFunc) *Element {
+	element, _ := list.UpdateE(key, fn)
+	return element
+}
+
+// UpdateE is like Update, but returns an *Error wrapping ErrNotFound if the key is not in the
+// list, or describing why the write was rejected. A declined update is not an error: it returns
+// a nil element and a nil error.
+func (list *SkipList) UpdateE(key []byte, fn UpdateFunc) (*Element, error) {
+	if err := list.checkKey("Update", key); err != nil {
+		return nil, err
+	}
+
+	element, err := list.updateIf(key, fn)
+	if err == ErrReadOnly {
+		return list.frozenWrite("Update", key, func(overflow *SkipList) (*Element, error) {
+			element, err := overflow.UpdateE(key, fn)
+			return list.replaced("Update", key, element, err)
+		})
+	}
+	if err != nil {
+		return nil, list.newError("Update", key, err)
+	}
+
+	list.enforceMaxWeight()
+	return element, nil
+}
+
+// CompareAndSet replaces the value of key with new if its current value is old, reporting
+// whether it did. Values are compared with ==, so they must be of comparable types.
+func (list *SkipList) CompareAndSet(key []byte, old, new interface{}) bool {
+	element, _ := list.UpdateE(key, func(current interface{}) (interface{}, bool) {
+		return new, current == old
+	})
+	return element != nil
+}
+
+func (list *SkipList) updateIf(key []byte, fn UpdateFunc) (*Element, error) {
+	list.lock(lockUpdate)
+	defer list.unlock()
+
+	if list.frozen {
+		return nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil && list.rangeFrozen(key) {
+		return nil, ErrRangeFrozen
+	}
+
+	// A tombstone, or an expired element until it is reclaimed, reads as absent, as for Get.
+	element := list.find(key)
+	if element == nil || element.IsTombstone() || element.expired() {
+		return nil, ErrNotFound
+	}
+
+	value, ok := fn(element.Value())
+	if !ok {
+		return nil, nil
+	}
+	if list.exceedsQuota(list.growth(element, value)) {
+		return nil, ErrQuotaExceeded
+	}
+	list.update(element, value)
+	return element, nil
+}
+
+// TransformValues replaces the value of every element with the result of calling fn with its key
+// and value, in a single pass with the list locked, as when migrating cached values to a new
+// schema. It saves re-inserting every key, and no write can intervene between reading a value and
+// replacing it. fn must not use the list. Tombstones are left as they are. Writes rejected by a
+// frozen list transform nothing (see TransformValuesE).
+func (list *SkipList) TransformValues(fn func(key []byte, value interface{}) interface{}) {
+	_ = list.TransformValuesE(fn)
+}
+
+// TransformValuesE is like TransformValues, but returns an *Error wrapping ErrReadOnly if the
+// list is frozen. If a transformed value would take the list past the quota set WithByteQuota,
+// the transformation stops there with an *Error wrapping ErrQuotaExceeded, and the values
+// transformed before it stay transformed.
+func (list *SkipList) TransformValuesE(fn func(key []byte, value interface{}) interface{}) error {
+	_, _, err := list.transformBatch(^uint64(0), nil, 0, fn)
+	list.enforceMaxWeight()
+	if err != nil {
+		return list.newError("TransformValues", nil, err)
+	}
+	return nil
+}
+
+// TransformValuesIncremental is like TransformValuesE, but takes the list's lock for at most
+// batchSize elements at a time and yields to other goroutines between batches, so that
+// transforming a large list never blocks writers for long. Elements inserted or updated while it
+// runs are newer than the transformation and are left as they are. Returns the number of values
+// transformed.
+//
+// If the list is frozen part way through, the values transformed so far stay transformed and the
+// error wraps ErrReadOnly, as it wraps ErrQuotaExceeded if a value would exceed the byte quota.
+func (list *SkipList) TransformValuesIncremental(batchSize int, fn func(key []byte, value interface{}) interface{}) (int, error) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	cut := list.Seq()
+	total := 0
+	var resume []byte
+	for started := false; !started || resume != nil; started = true {
+		n, next, err := list.transformBatch(cut, resume, batchSize, fn)
+		total += n
+		list.enforceMaxWeight()
+		if err != nil {
+			return total, list.newError("TransformValuesIncremental", nil, err)
+		}
+
+		resume = next
+		runtime.Gosched()
+	}
+	return total, nil
+}
+
+// transformBatch visits up to batchSize elements, or every element if batchSize is not positive,
+// starting at the first key >= resume, or at the front of the list when resume is nil, and
+// transforms the values of those not newer than cut. Returns the number of values transformed
+// and the key to resume from, which is nil once the end of the list is reached. It stops with
+// ErrQuotaExceeded at the first value that would exceed the byte quota.
+func (list *SkipList) transformBatch(cut uint64, resume []byte, batchSize int, fn func([]byte, interface{}) interface{}) (int, []byte, error) {
+	list.lock(lockUpdate)
+	defer list.unlock()
+
+	if list.frozen {
+		return 0, nil, ErrReadOnly
+	}
+	if list.frozenRanges != nil {
+		return 0, nil, ErrRangeFrozen
+	}
+
+	element := list.Front()
+	if resume != nil {
+		element = list.searchGreaterOrEqual(resume)
+	}
+
+	n := 0
+	for visited := 0; element != nil; visited++ {
+		if batchSize > 0 && visited == batchSize {
+			return n, element.key, nil
+		}
+		if !element.IsTombstone() && element.Seq() <= cut {
+			value := fn(element.key, element.Value())
+			if list.exceedsQuota(list.growth(element, value)) {
+				return n, nil, ErrQuotaExceeded
+			}
+			list.update(element, value)
+			n++
+		}
+		element = element.Next()
+	}
+	return n, nil, nil
+}
@@ -0,0 +1,152 @@
+package skiplist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+
+	if list.Update([]byte("a"), func(old interface{}) (interface{}, bool) {
+		return old.(int) + 1, true
+	}) == nil || list.Get([]byte("a")).Value() != 2 {
+		t.Fatal("Update must replace the value")
+	}
+
+	seq := list.Seq()
+	if list.Update([]byte("a"), func(old interface{}) (interface{}, bool) {
+		return 100, false
+	}) != nil || list.Get([]byte("a")).Value() != 2 || list.Seq() != seq {
+		t.Fatal("a declined update must leave the element unchanged")
+	}
+
+	if _, err := list.UpdateE([]byte("b"), func(old interface{}) (interface{}, bool) {
+		t.Fatal("fn must not be called for a missing key")
+		return nil, true
+	}); !errors.Is(err, ErrNotFound) {
+		t.Fatal("expected ErrNotFound, got", err)
+	}
+}
+
+func TestCompareAndSet(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), "x")
+
+	if list.CompareAndSet([]byte("a"), "y", "z") || list.Get([]byte("a")).Value() != "x" {
+		t.Fatal("CompareAndSet must fail on a different value")
+	}
+	if !list.CompareAndSet([]byte("a"), "x", "z") || list.Get([]byte("a")).Value() != "z" {
+		t.Fatal("CompareAndSet must succeed on the current value")
+	}
+	if list.CompareAndSet([]byte("b"), nil, "z") || list.Get([]byte("b")) != nil {
+		t.Fatal("CompareAndSet must not insert")
+	}
+}
+
+func TestCompareAndSetConcurrent(t *testing.T) {
+	list := New()
+	list.Set([]byte("counter"), 0)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; {
+				old := list.Get([]byte("counter")).Value().(int)
+				if list.CompareAndSet([]byte("counter"), old, old+1) {
+					i++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := list.Get([]byte("counter")).Value(); n != 8000 {
+		t.Fatal("increments were lost", n)
+	}
+}
+
+func TestTransformValues(t *testing.T) {
+	list := New(WithWeigher(func(key []byte, value interface{}) int64 { return int64(value.(uint64)) }))
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i), i)
+	}
+	seq := list.Seq()
+
+	list.TransformValues(func(key []byte, value interface{}) interface{} {
+		if orderedKeyValue(key) != value.(uint64) {
+			t.Fatal("fn must be called with the key of the value")
+		}
+		return value.(uint64) * 2
+	})
+	if list.Get(orderedKey(7)).Value() != uint64(14) || list.Weight() != 9900 || list.Seq() != seq+100 {
+		t.Fatal("every value must be transformed as an update", list.Weight(), list.Seq())
+	}
+
+	n, err := list.TransformValuesIncremental(8, func(key []byte, value interface{}) interface{} {
+		return value.(uint64) + 1
+	})
+	if err != nil || n != 100 || list.Get(orderedKey(99)).Value() != uint64(199) {
+		t.Fatal("wrong incremental transformation", n, err)
+	}
+
+	list.Freeze()
+	if err := list.TransformValuesE(func([]byte, interface{}) interface{} { return nil }); !errors.Is(err, ErrReadOnly) {
+		t.Fatal("a frozen list must not be transformed", err)
+	}
+}
+
+func TestWriteHooks(t *testing.T) {
+	var events []string
+	list := New(
+		WithInsertHook(func(key []byte, value interface{}) {
+			events = append(events, fmt.Sprintf("insert %s=%v", key, value))
+		}),
+		WithUpdateHook(func(key []byte, old, new interface{}) {
+			events = append(events, fmt.Sprintf("update %s=%v->%v", key, old, new))
+		}),
+		WithTombstones(),
+	)
+
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("a"), 2)
+	list.Update([]byte("a"), func(old interface{}) (interface{}, bool) { return old.(int) + 1, true })
+	var batch WriteBatch
+	batch.Set([]byte("b"), 1)
+	batch.Set([]byte("a"), 4)
+	list.Apply(&batch)
+	// Removing leaves a tombstone, which is not reported; writing the key again is an insert.
+	list.Remove([]byte("b"))
+	list.Remove([]byte("c"))
+	list.Set([]byte("b"), 5)
+
+	want := "insert a=1,update a=1->2,update a=2->3,update a=3->4,insert b=1,insert b=5"
+	if got := strings.Join(events, ","); got != want {
+		t.Fatal("wrong hook events", got)
+	}
+}
+
+func TestTransformValuesQuota(t *testing.T) {
+	list := New(WithByteQuota(16))
+	list.Set([]byte("a"), "x")
+	list.Set([]byte("b"), "x")
+
+	err := list.TransformValuesE(func(key []byte, value interface{}) interface{} {
+		return "01234567"
+	})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatal("expected ErrQuotaExceeded, got", err)
+	}
+	if a, b := list.Get([]byte("a")).Value(), list.Get([]byte("b")).Value(); a != "01234567" || b != "x" {
+		t.Fatal("the transformation must stop at the value exceeding the quota", a, b)
+	}
+	if stats := list.Stats(); stats.KeyBytes+stats.ValueBytes > 16 {
+		t.Fatal("quota exceeded", stats.KeyBytes, stats.ValueBytes)
+	}
+}
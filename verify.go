@@ -0,0 +1,71 @@
+package skiplist
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// OrderViolation describes an insert that left the list out of order, which happens when the
+// comparator is not a consistent total order. It is reported by lists constructed
+// WithInsertVerification.
+type OrderViolation struct {
+	// Key is the key of the inserted element.
+	Key []byte
+	// Level is the lowest level on which the element is out of order with a neighbour.
+	Level int
+	// Prev and Next are the keys of the element's neighbours on that level. Prev is nil when the
+	// element follows the head of the list and Next is nil when it is the last on the level.
+	Prev, Next []byte
+	// Dump shows the element's neighbours on every level of its tower, at the moment it was linked.
+	Dump string
+}
+
+func (v OrderViolation) String() string {
+	return fmt.Sprintf("key %s is out of order on level %d between %s and %s\n%s",
+		quoteKey(v.Key), v.Level, quoteKey(v.Prev), quoteKey(v.Next), v.Dump)
+}
+
+// verifyInsert checks that a newly linked element sorts strictly between its neighbours on
+// every level, comparing in both directions so that asymmetric comparators are caught too.
+// Returns nil if it does. The caller must hold the list mutex.
+func (list *SkipList) verifyInsert(prevs []*elementNode, element *Element) *OrderViolation {
+	for i := range element.next {
+		prev, next := list.elementOf(prevs[i]), element.NextAt(i)
+
+		if (prev != nil && (list.compare(prev.key, element.key) >= 0 || list.compare(element.key, prev.key) <= 0)) ||
+			(next != nil && (list.compare(element.key, next.key) >= 0 || list.compare(next.key, element.key) <= 0)) {
+			violation := &OrderViolation{Key: element.key, Level: i, Dump: list.dumpTower(prevs, element)}
+			if prev != nil {
+				violation.Prev = prev.key
+			}
+			if next != nil {
+				violation.Next = next.key
+			}
+			return violation
+		}
+	}
+	return nil
+}
+
+// dumpTower renders the neighbours of element on each level of its tower, top level first.
+func (list *SkipList) dumpTower(prevs []*elementNode, element *Element) string {
+	var buf bytes.Buffer
+	for i := len(element.next) - 1; i >= 0; i-- {
+		prev, next := list.elementOf(prevs[i]), element.NextAt(i)
+
+		fmt.Fprintf(&buf, "level %d: ", i)
+		if prev == nil {
+			buf.WriteString("head")
+		} else {
+			buf.WriteString(quoteKey(prev.key))
+		}
+		fmt.Fprintf(&buf, " -> %s -> ", quoteKey(element.key))
+		if next == nil {
+			buf.WriteString("end")
+		} else {
+			buf.WriteString(quoteKey(next.key))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
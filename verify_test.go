@@ -0,0 +1,47 @@
+package skiplist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInsertVerification(t *testing.T) {
+	var violations []OrderViolation
+	report := func(v OrderViolation) {
+		violations = append(violations, v)
+	}
+
+	list := New(WithInsertVerification(report))
+	for i := uint64(0); i < 100; i++ {
+		list.Set(orderedKey(i*7%100), i)
+	}
+	if len(violations) != 0 {
+		t.Fatal("a consistent comparator must not report violations", violations[0])
+	}
+
+	// This comparator is asymmetric: "m" sorts after every key when it is the first argument,
+	// but normally when it is the second.
+	broken := func(a, b []byte) int {
+		if string(a) == "m" {
+			return 1
+		}
+		return bytes.Compare(a, b)
+	}
+	list = New(WithComparator(broken), WithInsertVerification(report))
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("z"), 2)
+	list.Set([]byte("m"), 3)
+
+	if len(violations) != 1 {
+		t.Fatal("expected one violation", violations)
+	}
+
+	v := violations[0]
+	if string(v.Key) != "m" || v.Level != 0 || string(v.Prev) != "z" || v.Next != nil {
+		t.Fatal("wrong violation", v)
+	}
+	if !strings.Contains(v.Dump, `level 0: "z" -> "m" -> end`) {
+		t.Fatal("the dump must show the element's neighbours", v.Dump)
+	}
+}
@@ -0,0 +1,241 @@
+package skiplist
+
+// version is a past value of a key, or its removal, kept for reads at earlier sequence numbers.
+type version struct {
+	// seq is the sequence number of the mutation that set the value, or removed the key.
+	seq     uint64
+	value   interface{}
+	removed bool
+	older   *version
+}
+
+// keyHistory holds the past versions of a key, newest first. It is the value of the key in the
+// history list, and is only read or written with the mutex of the versioned list held.
+type keyHistory struct {
+	newest *version
+	// discarded is set once older versions were dropped to keep at most maxVersions.
+	discarded bool
+}
+
+// Snapshot is a consistent, read-only view of a list as of a sequence number, taken by Snapshot
+// or SnapshotAt. Reads through a snapshot see the values the list held at its sequence number,
+// however the list has changed since, as long as the list still keeps the versions they need
+// (see WithVersions).
+type Snapshot struct {
+	list *SkipList
+	seq  uint64
+}
+
+// Snapshot returns a view of the list as of its latest mutation.
+func (list *SkipList) Snapshot() *Snapshot {
+	return list.SnapshotAt(list.Seq())
+}
+
+// SnapshotAt returns a view of the list as of the mutation with sequence number seq.
+func (list *SkipList) SnapshotAt(seq uint64) *Snapshot {
+	return &Snapshot{list: list, seq: seq}
+}
+
+// Seq returns the sequence number the snapshot views the list at.
+func (s *Snapshot) Seq() uint64 {
+	return s.seq
+}
+
+// Get returns the value key had at the snapshot's sequence number, and whether it was present.
+// It also returns false if that version was discarded (see GetE).
+func (s *Snapshot) Get(key []byte) (interface{}, bool) {
+	value, err := s.GetE(key)
+	return value, err == nil
+}
+
+// GetE is like Get, but returns an *Error wrapping ErrNotFound if key was not present, or
+// ErrVersionDiscarded if the list no longer keeps the version of key at the snapshot.
+func (s *Snapshot) GetE(key []byte) (interface{}, error) {
+	value, err := s.list.readValueAt(key, s.seq)
+
+	if err != nil {
+		return nil, s.list.newError("GetAtSeq", key, err)
+	}
+	return value, nil
+}
+
+// Range calls fn with each key in [start, end) present at the snapshot's sequence number, and
+// its value then, in order, until fn returns false. A nil start or end leaves that side of the
+// range open. Like iterating the list, it does not hold the list's lock while calling fn. Returns
+// an *Error wrapping ErrVersionDiscarded, after visiting the keys before it, if the list no
+// longer keeps the version of a key in the range.
+func (s *Snapshot) Range(start, end []byte, fn func(key []byte, value interface{}) bool) error {
+	list := s.list
+	var element, past *Element
+	if start == nil {
+		element = list.Front()
+	} else {
+		element = list.Seek(start)
+	}
+	if history := list.versionHistory(); history != nil {
+		if start == nil {
+			past = history.Front()
+		} else {
+			past = history.Seek(start)
+		}
+	}
+
+	// Keys removed since the snapshot are no longer in the list, but their removal left them in
+	// its history, so the walk visits the keys of both.
+	for element != nil || past != nil {
+		var key []byte
+		if past == nil || (element != nil && list.compare(element.key, past.key) <= 0) {
+			key = element.key
+		} else {
+			key = past.key
+		}
+		if end != nil && list.compare(key, end) >= 0 {
+			return nil
+		}
+
+		value, err := list.readValueAt(key, s.seq)
+
+		switch err {
+		case nil:
+			if !fn(key, value) {
+				return nil
+			}
+		case ErrVersionDiscarded:
+			return list.newError("GetAtSeq", key, err)
+		}
+
+		for element != nil && list.compare(element.key, key) <= 0 {
+			element = element.Next()
+		}
+		for past != nil && list.compare(past.key, key) <= 0 {
+			past = past.Next()
+		}
+	}
+	return nil
+}
+
+// GetAtSeq returns the value key had after the mutation with sequence number seq, and whether it
+// was present then. It also returns false if that version was discarded (see GetAtSeqE).
+func (list *SkipList) GetAtSeq(key []byte, seq uint64) (interface{}, bool) {
+	return list.SnapshotAt(seq).Get(key)
+}
+
+// GetAtSeqE is like GetAtSeq, but returns an *Error wrapping ErrNotFound if key was not present,
+// or ErrVersionDiscarded if the list no longer keeps the version of key at seq.
+func (list *SkipList) GetAtSeqE(key []byte, seq uint64) (interface{}, error) {
+	return list.SnapshotAt(seq).GetE(key)
+}
+
+// ReleaseVersions discards the versions that no read at a sequence number of at least before
+// needs, such as the values overwritten before the oldest snapshot still in use, and the history
+// of keys removed before it. Reads at sequence numbers below before fail with
+// ErrVersionDiscarded from then on.
+func (list *SkipList) ReleaseVersions(before uint64) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if list.history == nil || before <= list.versionsFloor {
+		return
+	}
+	list.versionsFloor = before
+
+	for past := list.history.Front(); past != nil; past = past.Next() {
+		h := past.Value().(*keyHistory)
+		v := h.newest
+		for v != nil && v.seq > before {
+			v = v.older
+		}
+		if v == nil {
+			continue
+		}
+		// v is the version reads at before see, so every older one can go. A removal that is the
+		// newest version of a key absent from the list says no more than the key's absence.
+		v.older = nil
+		if v == h.newest && v.removed && list.find(past.key) == nil {
+			list.history.Remove(past.key)
+		}
+	}
+}
+
+// readValueAt is valueAt, locking the list mutex for reading.
+func (list *SkipList) readValueAt(key []byte, seq uint64) (interface{}, error) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.valueAt(key, seq)
+}
+
+// valueAt returns the value key had after the mutation with sequence number seq. The caller
+// must hold the list mutex.
+func (list *SkipList) valueAt(key []byte, seq uint64) (interface{}, error) {
+	element := list.find(key)
+	if element != nil && element.Seq() <= seq {
+		if element.IsTombstone() {
+			return nil, ErrNotFound
+		}
+		return element.Value(), nil
+	}
+	if seq >= list.seq {
+		return nil, ErrNotFound
+	}
+	if list.history == nil || seq < list.versionsFloor {
+		return nil, ErrVersionDiscarded
+	}
+
+	past := list.history.find(key)
+	if past == nil {
+		// The key was not mutated since it was inserted, or since the floor, if at all.
+		return nil, ErrNotFound
+	}
+	h := past.Value().(*keyHistory)
+	for v := h.newest; v != nil; v = v.older {
+		if v.seq <= seq {
+			if v.removed {
+				return nil, ErrNotFound
+			}
+			return v.value, nil
+		}
+	}
+	if h.discarded {
+		return nil, ErrVersionDiscarded
+	}
+	return nil, ErrNotFound
+}
+
+// versionHistory returns the history list of the list, if it keeps versions.
+func (list *SkipList) versionHistory() *SkipList {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.history
+}
+
+// recordVersion keeps the current value of element, which is about to be replaced, or removed
+// by the mutation with sequence number removed if it is not zero. The caller must hold the list
+// mutex.
+func (list *SkipList) recordVersion(element *Element, removed uint64) {
+	past := list.history.find(element.key)
+	if past == nil {
+		past = list.history.Set(element.key, &keyHistory{})
+	}
+	h := past.Value().(*keyHistory)
+	h.newest = &version{seq: element.Seq(), value: element.Value(), removed: element.IsTombstone(), older: h.newest}
+	if removed != 0 {
+		h.newest = &version{seq: removed, removed: true, older: h.newest}
+	}
+
+	// Keep at most maxVersions versions.
+	v := h.newest
+	for i := 1; i < list.maxVersions && v != nil; i++ {
+		v = v.older
+	}
+	if v != nil && v.older != nil {
+		v.older = nil
+		h.discarded = true
+	}
+}
+
+// newHistory returns an empty history list for list.
+func (list *SkipList) newHistory() *SkipList {
+	return New(WithComparator(list.compare))
+}
@@ -0,0 +1,99 @@
+package skiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersions(t *testing.T) {
+	list := New(WithVersions(2))
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 1)
+	s1 := list.Snapshot()
+	list.Set([]byte("a"), 2)
+	list.Remove([]byte("b"))
+	list.Set([]byte("c"), 1)
+	s2 := list.Snapshot()
+	list.Set([]byte("b"), 3)
+
+	cases := []struct {
+		snapshot *Snapshot
+		key      string
+		value    interface{}
+	}{
+		{s1, "a", 1}, {s1, "b", 1}, {s1, "c", nil},
+		{s2, "a", 2}, {s2, "b", nil}, {s2, "c", 1},
+		{list.Snapshot(), "b", 3},
+	}
+	for _, c := range cases {
+		if value, ok := c.snapshot.Get([]byte(c.key)); value != c.value || ok != (c.value != nil) {
+			t.Fatal("wrong value at a snapshot", c.snapshot.Seq(), c.key, value, ok)
+		}
+	}
+	if _, err := list.GetAtSeqE([]byte("c"), s1.Seq()); !errors.Is(err, ErrNotFound) {
+		t.Fatal("keys inserted after a snapshot must not be found", err)
+	}
+
+	var got []string
+	err := s1.Range(nil, nil, func(key []byte, value interface{}) bool {
+		got = append(got, string(key))
+		return true
+	})
+	if err != nil || len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatal("a snapshot must range over the keys present at it, including removed ones", got, err)
+	}
+
+	// Keeping two versions of a key pushes out the third newest.
+	list.Set([]byte("a"), 3)
+	list.Set([]byte("a"), 4)
+	if value, _ := list.GetAtSeq([]byte("a"), s2.Seq()); value != 2 {
+		t.Fatal("recent versions must be kept", value)
+	}
+	if _, err := s1.GetE([]byte("a")); !errors.Is(err, ErrVersionDiscarded) {
+		t.Fatal("reads of versions pushed out must fail", err)
+	}
+
+	list.ReleaseVersions(s2.Seq() + 1)
+	if _, err := s2.GetE([]byte("b")); !errors.Is(err, ErrVersionDiscarded) {
+		t.Fatal("reads below the released sequence must fail", err)
+	}
+	if value, ok := list.Snapshot().Get([]byte("a")); value != 4 || !ok {
+		t.Fatal("releasing versions must keep the current ones", value)
+	}
+
+	list.Clear()
+	if _, err := list.GetAtSeqE([]byte("a"), s2.Seq()+1); !errors.Is(err, ErrVersionDiscarded) {
+		t.Fatal("clearing must discard the versions", err)
+	}
+	if _, err := list.Snapshot().GetE([]byte("a")); !errors.Is(err, ErrNotFound) {
+		t.Fatal("a cleared list must be empty at its latest sequence", err)
+	}
+}
+
+func TestVersionsRotate(t *testing.T) {
+	list := New(WithVersions(4))
+	list.Set([]byte("a"), 1)
+	s := list.Snapshot()
+	list.Set([]byte("a"), 2)
+
+	frozen, _ := list.Rotate()
+	if value, _ := frozen.GetAtSeq([]byte("a"), s.Seq()); value != 1 {
+		t.Fatal("a frozen list must keep the versions of its contents", value)
+	}
+	if _, err := s.GetE([]byte("a")); !errors.Is(err, ErrVersionDiscarded) {
+		t.Fatal("rotating must discard the versions of the list", err)
+	}
+}
+
+func TestVersionsDisabled(t *testing.T) {
+	list := New()
+	list.Set([]byte("a"), 1)
+	s := list.Snapshot()
+	if value, ok := s.Get([]byte("a")); value != 1 || !ok {
+		t.Fatal("unchanged keys must be readable at a snapshot", value)
+	}
+	list.Set([]byte("a"), 2)
+	if _, err := s.GetE([]byte("a")); !errors.Is(err, ErrVersionDiscarded) {
+		t.Fatal("lists without versions must report past versions as discarded", err)
+	}
+}
@@ -0,0 +1,379 @@
+package skiplist
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultWatchBuffer is the number of events a watch buffers unless set WithWatchBuffer.
+const defaultWatchBuffer = 1024
+
+// EventType is the kind of change reported by an Event.
+type EventType int
+
+const (
+	// EventInsert reports a key inserted into the list.
+	EventInsert EventType = iota
+	// EventUpdate reports a new value of a key already in the list.
+	EventUpdate
+	// EventDelete reports a key leaving the list, for whatever reason.
+	EventDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventInsert:
+		return "insert"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// Event is a change of a key in a watched range, delivered by Watch.
+type Event struct {
+	Type EventType
+	Key  []byte
+	// Value is the new value of the key, or the value it held before it was deleted.
+	Value interface{}
+	// Seq is the sequence number of the write that made the change.
+	Seq uint64
+}
+
+// DeliveryPolicy decides what a watch does once its consumer has fallen behind by the list's
+// watch buffer.
+type DeliveryPolicy int
+
+const (
+	// DeliverBlock makes writes of the watched keys wait, after unlocking the list, until the
+	// consumer catches up, so that no event is lost. It is the default.
+	DeliverBlock DeliveryPolicy = iota
+	// DeliverDropOldest drops the oldest undelivered event to make room for a new one, so that
+	// writes never wait and the consumer receives the most recent events.
+	DeliverDropOldest
+	// DeliverCoalesce merges each event into the undelivered event of its key, if there is one,
+	// so that a consumer that falls behind receives the latest change of each key rather than
+	// every change. An insert followed by an update is delivered as an insert of the new value,
+	// a deletion followed by an insert as an update, and an insert followed by a deletion not at
+	// all. Writes wait as with DeliverBlock once the buffer holds as many distinct keys.
+	DeliverCoalesce
+)
+
+// WatchOption configures a watch created by Watch.
+type WatchOption func(*watcher)
+
+// WatchPolicy sets the delivery policy of the watch. The default is DeliverBlock.
+func WatchPolicy(policy DeliveryPolicy) WatchOption {
+	return func(w *watcher) {
+		w.policy = policy
+	}
+}
+
+// WatchDeadline bounds how long a write waits for the watch to make room, under DeliverBlock or
+// DeliverCoalesce. Past the deadline, the oldest undelivered events are dropped to make room, so
+// that a stalled consumer delays writers by at most d per write rather than indefinitely.
+func WatchDeadline(d time.Duration) WatchOption {
+	return func(w *watcher) {
+		w.deadline = d
+	}
+}
+
+// WatchCoalesceWindow holds each event for d before delivering it, merging into it the later
+// events of its key that arrive meanwhile, as DeliverCoalesce does, so that a key written in a
+// burst is delivered once with its final state. Events of different keys keep the order of the
+// first change of each, and every event is delayed by d.
+func WatchCoalesceWindow(d time.Duration) WatchOption {
+	return func(w *watcher) {
+		w.window = d
+	}
+}
+
+// watcher queues the events of a watched range for delivery by its own goroutine.
+type watcher struct {
+	start, end []byte
+	out        chan Event
+	done       chan struct{}
+	limit      int
+	policy     DeliveryPolicy
+	deadline   time.Duration
+	window     time.Duration
+
+	mutex sync.Mutex
+	cond  sync.Cond
+	queue []queuedEvent
+	// first is the position in the stream of queued events of queue[0], and keys holds the
+	// position of the undelivered event of each key, if the watch coalesces events.
+	first uint64
+	keys  map[string]uint64
+	// pending counts the events in queue that were not merged away.
+	pending int
+	closed  bool
+}
+
+// queuedEvent is an event waiting for delivery.
+type queuedEvent struct {
+	Event
+	// merged marks an event coalesced away, which is skipped.
+	merged bool
+	// at is when the event was queued, if the watch holds events for a coalescing window.
+	at time.Time
+}
+
+// Watch returns a channel delivering, in the order of the writes, an Event for every insert,
+// update and deletion of a key in [start, end). A nil start or end leaves the range unbounded on
+// that side. Deletions include removals, evictions, expirations, flushes, clears and rotations.
+// A list constructed WithTombstones reports the removal of a key when its tombstone is written,
+// and the tombstone no more.
+//
+// Events are queued for the watcher and sent on the channel by a goroutine of its own, so
+// writers never wait for the watcher while the list is locked. Once the watcher has fallen
+// behind by the list's watch buffer, set WithWatchBuffer, writes to the list block after
+// unlocking it until the watcher catches up, so that a slow consumer slows writers rather than
+// losing events or holding an unbounded backlog; opts can choose another policy per watch (see
+// DeliveryPolicy). For the same reason, the consumer must not write to the list itself. A
+// watcher that is done must call Unwatch, or Close the list, to release writers and close the
+// channel.
+func (list *SkipList) Watch(start, end []byte, opts ...WatchOption) <-chan Event {
+	limit := list.watchBuffer
+	if limit <= 0 {
+		limit = defaultWatchBuffer
+	}
+	w := &watcher{
+		start: start,
+		end:   end,
+		out:   make(chan Event),
+		done:  make(chan struct{}),
+		limit: limit,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.policy == DeliverCoalesce || w.window > 0 {
+		w.keys = make(map[string]uint64)
+	}
+	w.cond.L = &w.mutex
+
+	list.mutex.Lock()
+	list.watchers = append(list.watchers, w)
+	list.mutex.Unlock()
+
+	go w.run()
+	return w.out
+}
+
+// Unwatch stops the watch delivering on ch, dropping any events not yet received, and closes
+// ch. It does nothing if ch is not a channel of a watch of the list.
+func (list *SkipList) Unwatch(ch <-chan Event) {
+	list.mutex.Lock()
+	var stopped *watcher
+	for i, w := range list.watchers {
+		if w.out == ch {
+			stopped = w
+			list.watchers = append(list.watchers[:i], list.watchers[i+1:]...)
+			break
+		}
+	}
+	if len(list.watchers) == 0 {
+		list.watchers = nil
+	}
+	list.mutex.Unlock()
+
+	if stopped != nil {
+		stopped.stop()
+	}
+}
+
+// unwatchAll stops every watch of the list.
+func (list *SkipList) unwatchAll() {
+	list.mutex.Lock()
+	watchers := list.watchers
+	list.watchers = nil
+	list.mutex.Unlock()
+
+	for _, w := range watchers {
+		w.stop()
+	}
+}
+
+// emit queues an event for the watchers of key. The caller must hold the list mutex.
+func (list *SkipList) emit(t EventType, key []byte, value interface{}, seq uint64) {
+	for _, w := range list.watchers {
+		if (w.start == nil || list.compare(key, w.start) >= 0) && (w.end == nil || list.compare(key, w.end) < 0) {
+			w.push(Event{Type: t, Key: key, Value: value, Seq: seq})
+		}
+	}
+}
+
+// emitDeletes queues a deletion for every live element of the list, which is about to be
+// emptied. The caller must hold the list mutex.
+func (list *SkipList) emitDeletes() {
+	seq := list.seq
+	for element := list.Front(); element != nil; element = element.Next() {
+		if !element.IsTombstone() {
+			list.emit(EventDelete, element.key, element.Value(), seq)
+		}
+	}
+}
+
+// awaitWatchers blocks until every watcher in watchers has caught up to within its buffer. It
+// must be called without holding the list mutex.
+func awaitWatchers(watchers []*watcher) {
+	for _, w := range watchers {
+		w.await()
+	}
+}
+
+func (w *watcher) push(event Event) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return
+	}
+	defer w.cond.Broadcast()
+
+	if w.keys != nil {
+		if pos, ok := w.keys[string(event.Key)]; ok {
+			queued := &w.queue[pos-w.first]
+			if merged, ok := coalesce(queued.Event, event); ok {
+				queued.Event = merged
+			} else {
+				queued.merged = true
+				delete(w.keys, string(event.Key))
+				w.pending--
+			}
+			return
+		}
+		w.keys[string(event.Key)] = w.first + uint64(len(w.queue))
+	}
+
+	if w.policy == DeliverDropOldest && w.pending >= w.limit {
+		w.dropOldest()
+	}
+	queued := queuedEvent{Event: event}
+	if w.window > 0 {
+		queued.at = time.Now()
+	}
+	w.queue = append(w.queue, queued)
+	w.pending++
+}
+
+// coalesce merges a later event of a key into an earlier one not yet delivered, returning the
+// single event to the same effect, or false if the two cancel out.
+func coalesce(earlier, later Event) (Event, bool) {
+	switch {
+	case earlier.Type == EventInsert && later.Type == EventDelete:
+		return Event{}, false
+	case earlier.Type == EventInsert:
+		later.Type = EventInsert
+	case earlier.Type == EventDelete:
+		later.Type = EventUpdate
+	}
+	return later, true
+}
+
+// pop removes the first event of the queue. The caller must hold w.mutex.
+func (w *watcher) pop() queuedEvent {
+	queued := w.queue[0]
+	w.queue[0] = queuedEvent{}
+	w.queue = w.queue[1:]
+	if !queued.merged {
+		w.pending--
+		if pos, ok := w.keys[string(queued.Key)]; ok && pos == w.first {
+			delete(w.keys, string(queued.Key))
+		}
+	}
+	w.first++
+	return queued
+}
+
+// dropOldest drops the oldest undelivered event. The caller must hold w.mutex.
+func (w *watcher) dropOldest() {
+	for w.pop().merged {
+	}
+}
+
+func (w *watcher) await() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.policy == DeliverDropOldest || w.pending < w.limit || w.closed {
+		return
+	}
+
+	expired := false
+	if w.deadline > 0 {
+		timer := time.AfterFunc(w.deadline, func() {
+			w.mutex.Lock()
+			defer w.mutex.Unlock()
+
+			expired = true
+			w.cond.Broadcast()
+		})
+		defer timer.Stop()
+	}
+	for w.pending >= w.limit && !w.closed {
+		if expired {
+			for w.pending >= w.limit {
+				w.dropOldest()
+			}
+			return
+		}
+		w.cond.Wait()
+	}
+}
+
+func (w *watcher) stop() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.closed {
+		w.closed = true
+		w.queue = nil
+		w.cond.Broadcast()
+		close(w.done)
+	}
+}
+
+func (w *watcher) run() {
+	defer close(w.out)
+
+	for {
+		w.mutex.Lock()
+		for w.pending == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.closed {
+			w.mutex.Unlock()
+			return
+		}
+		for w.queue[0].merged {
+			w.pop()
+		}
+		if w.window > 0 {
+			if wait := w.window - time.Since(w.queue[0].at); wait > 0 {
+				w.mutex.Unlock()
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-w.done:
+					timer.Stop()
+					return
+				}
+				continue
+			}
+		}
+		queued := w.pop()
+		// Wake writers waiting for room in the queue.
+		w.cond.Broadcast()
+		w.mutex.Unlock()
+
+		select {
+		case w.out <- queued.Event:
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package skiplist
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	list := New()
+	events := list.Watch([]byte("b"), []byte("d"))
+	defer list.Close()
+
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("b"), 1)
+	list.Set([]byte("b"), 2)
+	list.Set([]byte("c"), 3)
+	list.Set([]byte("d"), 4)
+	list.Remove([]byte("b"))
+	list.Clear()
+
+	var got []string
+	for len(got) < 5 {
+		e := <-events
+		got = append(got, fmt.Sprintf("%v %s=%v", e.Type, e.Key, e.Value))
+	}
+	want := "insert b=1,update b=2,insert c=3,delete b=2,delete c=3"
+	if strings.Join(got, ",") != want {
+		t.Fatal("wrong events", got)
+	}
+	select {
+	case e := <-events:
+		t.Fatal("unexpected event", e)
+	default:
+	}
+
+	list.Unwatch(events)
+	if _, ok := <-events; ok {
+		t.Fatal("channel not closed by Unwatch")
+	}
+}
+
+func TestWatchBackpressure(t *testing.T) {
+	list := New(WithWatchBuffer(2))
+	events := list.Watch(nil, nil)
+
+	written := make(chan struct{})
+	go func() {
+		for i := uint64(0); i < 10; i++ {
+			list.Set(orderedKey(i), i)
+		}
+		close(written)
+	}()
+
+	// The writer cannot get further ahead than the buffer and the event in flight.
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-written:
+		t.Fatal("writer was not held back by the watcher")
+	default:
+	}
+
+	for i := uint64(0); i < 10; i++ {
+		if e := <-events; e.Type != EventInsert || e.Value != i {
+			t.Fatal("wrong event", e)
+		}
+	}
+	<-written
+
+	// Closing the list releases writers waiting for a watcher that went away.
+	list.Watch(nil, nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		list.Close()
+	}()
+	for i := uint64(0); i < 10; i++ {
+		list.Set(orderedKey(i), i)
+	}
+}
+
+func TestWatchDropOldest(t *testing.T) {
+	list := New(WithWatchBuffer(2))
+	defer list.Close()
+	events := list.Watch(nil, nil, WatchPolicy(DeliverDropOldest))
+
+	written := make(chan struct{})
+	go func() {
+		for i := uint64(0); i < 10; i++ {
+			list.Set(orderedKey(i), i)
+		}
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer was held back by a watcher dropping events")
+	}
+
+	// At most one event was in flight before the rest were dropped for the newest two.
+	var got []uint64
+	for len(got) == 0 || got[len(got)-1] != 9 {
+		got = append(got, (<-events).Value.(uint64))
+	}
+	if len(got) > 3 || got[len(got)-2] != 8 {
+		t.Fatal("the oldest events must be dropped", got)
+	}
+}
+
+func TestWatchDeadline(t *testing.T) {
+	list := New(WithWatchBuffer(2))
+	defer list.Close()
+	events := list.Watch(nil, nil, WatchDeadline(time.Millisecond))
+
+	written := make(chan struct{})
+	go func() {
+		for i := uint64(0); i < 10; i++ {
+			list.Set(orderedKey(i), i)
+		}
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer was held back past the deadline")
+	}
+
+	prev := -1
+	for prev != 9 {
+		v := int((<-events).Value.(uint64))
+		if v <= prev {
+			t.Fatal("events must stay in order", v, prev)
+		}
+		prev = v
+	}
+}
+
+func TestWatchCoalesce(t *testing.T) {
+	list := New(WithWatchBuffer(4))
+	defer list.Close()
+	list.Set([]byte("d"), 0)
+	events := list.Watch(nil, nil, WatchPolicy(DeliverCoalesce))
+
+	// The first event is taken by the watcher's goroutine, which then waits for the consumer.
+	list.Set([]byte("z"), 0)
+	time.Sleep(10 * time.Millisecond)
+
+	list.Set([]byte("a"), 1)
+	list.Remove([]byte("d"))
+	list.Set([]byte("a"), 2)
+	list.Set([]byte("b"), 1)
+	list.Remove([]byte("b"))
+	list.Set([]byte("d"), 5)
+	list.Set([]byte("c"), 1)
+	list.Set([]byte("a"), 3)
+
+	var got []string
+	for len(got) < 4 {
+		e := <-events
+		got = append(got, fmt.Sprintf("%v %s=%v", e.Type, e.Key, e.Value))
+	}
+	want := "insert z=0,insert a=3,update d=5,insert c=1"
+	if strings.Join(got, ",") != want {
+		t.Fatal("wrong coalesced events", got)
+	}
+	select {
+	case e := <-events:
+		t.Fatal("unexpected event", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestWatchCoalesceWindow(t *testing.T) {
+	list := New()
+	defer list.Close()
+	events := list.Watch(nil, nil, WatchCoalesceWindow(20*time.Millisecond))
+
+	start := time.Now()
+	list.Set([]byte("a"), 1)
+	list.Set([]byte("a"), 2)
+	list.Set([]byte("b"), 1)
+	list.Set([]byte("a"), 3)
+	list.Remove([]byte("b"))
+	list.Set([]byte("c"), 1)
+
+	var got []string
+	for len(got) < 2 {
+		e := <-events
+		got = append(got, fmt.Sprintf("%v %s=%v", e.Type, e.Key, e.Value))
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("events must be held for the window")
+	}
+	if want := "insert a=3,insert c=1"; strings.Join(got, ",") != want {
+		t.Fatal("wrong coalesced events", got)
+	}
+
+	// A write after the window was delivered starts a new one.
+	list.Set([]byte("a"), 4)
+	if e := <-events; e.Type != EventUpdate || e.Value != 4 {
+		t.Fatal("wrong event after the window", e)
+	}
+	select {
+	case e := <-events:
+		t.Fatal("unexpected event", e)
+	case <-time.After(40 * time.Millisecond):
+	}
+}
@@ -0,0 +1,111 @@
+package skiplist
+
+// WatermarkMetric is a measure of a list watched by a Watermark.
+type WatermarkMetric int
+
+const (
+	// LengthWatermark watches the number of elements in the list.
+	LengthWatermark WatermarkMetric = iota
+	// WeightWatermark watches the total weight of the elements, typically the memory they hold,
+	// as assigned by the list's Weigher.
+	WeightWatermark
+)
+
+func (m WatermarkMetric) String() string {
+	switch m {
+	case LengthWatermark:
+		return "length"
+	case WeightWatermark:
+		return "weight"
+	}
+	return "unknown"
+}
+
+// Watermark calls Notify once Metric reaches High, and again once it falls back to Low, so that
+// an embedder can trigger a flush or shed load when a list fills up, and stop when it drains,
+// without polling Stats. Low must be less than High: the gap between them keeps a list hovering
+// around a threshold from firing the callback on every write.
+//
+// Watermarks are checked at the end of every write that locks the list, such as a Set, an Apply
+// or an eviction, and Notify is called once the list is unlocked, by the goroutine that wrote.
+type Watermark struct {
+	Metric    WatermarkMetric
+	High, Low int64
+	Notify    func(WatermarkEvent)
+}
+
+// WatermarkEvent reports a list crossing a Watermark.
+type WatermarkEvent struct {
+	Metric WatermarkMetric
+	// Value is the value of the metric after the write that crossed the watermark.
+	Value int64
+	// High is true when the metric reached the watermark's High, and false when it fell back to
+	// its Low.
+	High bool
+}
+
+type watermark struct {
+	Watermark
+	// above is set once the metric reaches High, until it falls back to Low.
+	above bool
+}
+
+// crossing is a watermark crossed by a write, to be reported once the list is unlocked.
+type crossing struct {
+	notify func(WatermarkEvent)
+	event  WatermarkEvent
+}
+
+// unlock releases the list mutex, held for writing, and then reports the watermarks crossed by
+// the writes made under it and waits for watchers that have fallen behind. It must be deferred:
+// if the operation holding the mutex panics, typically in a callback such as the comparator, a
+// hook or an UpdateFunc, unlock still releases the mutex and re-panics with a *PanicError.
+func (list *SkipList) unlock() {
+	if r := recover(); r != nil {
+		op := list.lockedOp
+		list.unlockMutex()
+		panic(list.newPanic(lockOpNames[op], r))
+	}
+	list.unlockMutex()
+}
+
+// unlockMutex is unlock without the recovery.
+func (list *SkipList) unlockMutex() {
+	if list.watermarks == nil && list.watchers == nil {
+		list.mutex.Unlock()
+		return
+	}
+
+	crossings := list.crossedWatermarks()
+	watchers := list.watchers
+	list.mutex.Unlock()
+	for _, c := range crossings {
+		c.notify(c.event)
+	}
+	awaitWatchers(watchers)
+}
+
+// crossedWatermarks updates the state of the list's watermarks, returning those crossed since
+// they were last checked. The caller must hold the list mutex.
+func (list *SkipList) crossedWatermarks() []crossing {
+	var crossings []crossing
+	for _, w := range list.watermarks {
+		value := int64(list.Length)
+		if w.Metric == WeightWatermark {
+			value = list.weight
+		}
+
+		switch {
+		case !w.above && value >= w.High:
+			w.above = true
+		case w.above && value <= w.Low:
+			w.above = false
+		default:
+			continue
+		}
+		if w.Notify != nil {
+			crossings = append(crossings, crossing{w.Notify, WatermarkEvent{Metric: w.Metric, Value: value, High: w.above}})
+		}
+	}
+	return crossings
+}
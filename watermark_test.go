@@ -0,0 +1,67 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestWatermarks(t *testing.T) {
+	var events []WatermarkEvent
+	notify := func(e WatermarkEvent) {
+		events = append(events, e)
+	}
+	list := New(
+		WithWeigher(func(key []byte, value interface{}) int64 { return int64(value.(int)) }),
+		WithWatermark(Watermark{Metric: LengthWatermark, High: 3, Low: 1, Notify: notify}),
+		WithWatermark(Watermark{Metric: WeightWatermark, High: 100, Low: 50, Notify: notify}),
+	)
+
+	list.Set([]byte("a"), 10)
+	list.Set([]byte("b"), 10)
+	if len(events) != 0 {
+		t.Fatal("no watermark must fire below its high", events)
+	}
+
+	list.Set([]byte("c"), 10)
+	if len(events) != 1 || events[0] != (WatermarkEvent{Metric: LengthWatermark, Value: 3, High: true}) {
+		t.Fatal("reaching the high must fire", events)
+	}
+
+	// Hovering between the low and the high does not fire again.
+	list.Remove([]byte("c"))
+	list.Set([]byte("c"), 10)
+	list.Set([]byte("d"), 10)
+	if len(events) != 1 {
+		t.Fatal("a watermark must not fire again until it falls back to its low", events)
+	}
+
+	var batch WriteBatch
+	batch.Set([]byte("d"), 100)
+	batch.Remove([]byte("a"))
+	batch.Remove([]byte("b"))
+	batch.Remove([]byte("c"))
+	if err := list.Apply(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[1] != (WatermarkEvent{Metric: LengthWatermark, Value: 1}) ||
+		events[2] != (WatermarkEvent{Metric: WeightWatermark, Value: 100, High: true}) {
+		t.Fatal("a write crossing several watermarks must fire each", events)
+	}
+
+	if _, err := list.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 || events[3] != (WatermarkEvent{Metric: WeightWatermark, Value: 0}) {
+		t.Fatal("rotating a full list must report it drained", events)
+	}
+
+	if WeightWatermark.String() != "weight" || WatermarkMetric(-1).String() != "unknown" {
+		t.Fatal("wrong metric names")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a watermark whose low is not below its high must panic")
+		}
+	}()
+	New(WithWatermark(Watermark{High: 1, Low: 1}))
+}
@@ -0,0 +1,86 @@
+package skiplist
+
+import (
+	"time"
+)
+
+// Weigher assigns a weight to an element, typically its approximate size in bytes. It is called
+// when the element is inserted and whenever its value is updated, with the list locked.
+type Weigher func(key []byte, value interface{}) int64
+
+// weigh returns the weight of an element, or 0 if the list has no weigher.
+func (list *SkipList) weigh(key []byte, value interface{}) int64 {
+	if list.weigher == nil {
+		return 0
+	}
+	return list.weigher(key, value)
+}
+
+// Weight returns the total weight of the elements in the list.
+func (list *SkipList) Weight() int64 {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return list.weight
+}
+
+// enforceMaxWeight evicts elements if a write took the list over its maximum weight.
+// The list must not be locked.
+func (list *SkipList) enforceMaxWeight() {
+	if list.maxWeight > 0 {
+		for _, evicted := range list.evict() {
+			list.notifyRemove(evicted, Evicted)
+		}
+	}
+}
+
+// evict removes unpinned elements, smallest keys first, or least recently accessed first if the
+// list tracks accesses, until the list's weight is within its maximum. It returns the evicted
+// elements, for the caller to notify once the list is unlocked.
+func (list *SkipList) evict() []*Element {
+	list.lock(lockEvict)
+	defer list.unlock()
+
+	var evicted []*Element
+	if list.weight <= list.maxWeight {
+		return nil
+	}
+	if list.trackAccess {
+		return list.evictLeastRecent()
+	}
+
+	prevs := list.prevNodesCache
+	for i := range prevs {
+		prevs[i] = &list.elementNode
+	}
+
+	for element := list.Front(); element != nil && list.weight > list.maxWeight; {
+		next := element.Next()
+		if list.held(element) {
+			for i := range element.next {
+				prevs[i] = &element.elementNode
+			}
+		} else {
+			list.unlink(prevs, element)
+			evicted = append(evicted, element)
+			list.remember(element)
+		}
+		element = next
+	}
+
+	return evicted
+}
+
+// held reports whether element is pinned or in a frozen range, which keeps it from being
+// evicted. The caller must hold the list mutex.
+func (list *SkipList) held(element *Element) bool {
+	return element.pins > 0 || list.frozenRanges != nil && list.rangeFrozen(element.key)
+}
+
+// remember records an evicted element in the ghost list, if the list keeps one.
+// The caller must hold the list mutex.
+func (list *SkipList) remember(element *Element) {
+	if list.ghosts != nil {
+		list.ghosts.add(Ghost{Key: element.key, Weight: element.weight, Seq: element.Seq(), Evicted: time.Now()})
+	}
+}
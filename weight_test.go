@@ -0,0 +1,88 @@
+package skiplist
+
+import (
+	"testing"
+)
+
+func TestWeightBoundedEviction(t *testing.T) {
+	var evicted []string
+	list := New(
+		WithWeigher(func(key []byte, value interface{}) int64 {
+			return int64(len(value.(string)))
+		}),
+		WithMaxWeight(10),
+		WithRemoveCallback(func(e *Element, reason RemoveReason) {
+			if reason == Evicted {
+				evicted = append(evicted, string(e.Key()))
+			}
+		}),
+	)
+
+	list.Set([]byte("a"), "xxxx")
+	list.Set([]byte("b"), "xxxx")
+	if list.Weight() != 8 || len(evicted) != 0 {
+		t.Fatal("nothing must be evicted below the maximum weight", list.Weight(), evicted)
+	}
+
+	// Growing a value counts the difference in weight.
+	list.Set([]byte("b"), "xxxxxx")
+	if list.Weight() != 10 || len(evicted) != 0 {
+		t.Fatal("wrong weight after update", list.Weight(), evicted)
+	}
+
+	list.Set([]byte("c"), "xxxxxxx")
+	checkSanity(list, t)
+
+	if list.Weight() != 7 || len(evicted) != 2 || evicted[0] != "a" || evicted[1] != "b" {
+		t.Fatal("the smallest keys must be evicted first", list.Weight(), evicted)
+	}
+
+	list.Remove([]byte("c"))
+	if stats := list.Stats(); stats.Weight != 0 || stats.Length != 0 {
+		t.Fatal("removal must release weight", stats)
+	}
+}
+
+func TestGhostList(t *testing.T) {
+	list := New(
+		WithWeigher(func(key []byte, value interface{}) int64 { return 1 }),
+		WithMaxWeight(2),
+		WithGhostList(3),
+	)
+
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		list.Set([]byte(k), nil)
+	}
+
+	ghosts := list.Ghosts()
+	if len(ghosts) != 3 || string(ghosts[0].Key) != "a" || string(ghosts[2].Key) != "c" || ghosts[0].Weight != 1 {
+		t.Fatal("wrong ghosts", ghosts)
+	}
+
+	ghost, ok := list.Ghost([]byte("b"))
+	if !ok || ghost.Seq <= ghosts[0].Seq || ghost.Evicted.IsZero() {
+		t.Fatal("wrong ghost of an evicted key", ghost, ok)
+	}
+	if _, ok := list.Ghost([]byte("d")); ok {
+		t.Fatal("keys in the list have no ghost")
+	}
+
+	// Re-inserting a key forgets its ghost.
+	list.Remove([]byte("d"))
+	list.Set([]byte("a"), nil)
+	if _, ok := list.Ghost([]byte("a")); ok || len(list.Ghosts()) != 2 {
+		t.Fatal("a re-inserted key must be forgotten", list.Ghosts())
+	}
+
+	// Evicting it again remembers the new eviction, and the oldest ghosts are forgotten first.
+	list.Set([]byte("f"), nil)
+	list.Set([]byte("g"), nil)
+	ghosts = list.Ghosts()
+	if len(ghosts) != 3 || string(ghosts[0].Key) != "c" || string(ghosts[1].Key) != "a" || string(ghosts[2].Key) != "e" {
+		t.Fatal("wrong ghosts after evicting a re-inserted key", ghosts)
+	}
+
+	if New().Ghosts() != nil {
+		t.Fatal("lists without a ghost list must report no ghosts")
+	}
+}
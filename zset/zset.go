@@ -0,0 +1,238 @@
+// Package zset provides a sorted set in the manner of Redis's ZSET: members ordered by a float64
+// score, ties broken by member, with score updates, range queries by score and rank queries in
+// logarithmic time. It is backed by a skiplist.SkipList with a rank index, keyed by the score and
+// member encoded so that byte-wise order is score order.
+package zset
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+
+	skiplist "github.com/m3db/fast-skiplist"
+)
+
+// ErrNaNScore is returned by writes that would give a member a score that is not a number.
+var ErrNaNScore = errors.New("zset: score is not a number")
+
+// scoreSize is the size of the encoded score that prefixes every key.
+const scoreSize = 8
+
+// Entry is a member of a sorted set and its score.
+type Entry struct {
+	Member string
+	Score  float64
+}
+
+// SortedSet is a set of members ordered by score. It is safe for concurrent use: reads see the
+// set either before or after any write, never part way through one.
+type SortedSet struct {
+	mutex  sync.RWMutex
+	scores map[string]float64
+	list   *skiplist.SkipList
+}
+
+// New creates an empty sorted set.
+func New() *SortedSet {
+	return &SortedSet{
+		scores: make(map[string]float64),
+		list:   skiplist.New(skiplist.WithRankIndex()),
+	}
+}
+
+// Len returns the number of members of the set.
+func (s *SortedSet) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.scores)
+}
+
+// Add sets the score of member, adding it to the set if it is not a member yet, as ZADD does.
+// It returns whether member was added.
+func (s *SortedSet) Add(member string, score float64) (bool, error) {
+	if math.IsNaN(score) {
+		return false, ErrNaNScore
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, ok := s.scores[member]
+	s.set(member, old, ok, score)
+	return !ok, nil
+}
+
+// IncrBy adds delta to the score of member, as ZINCRBY does, and returns the new score. A member
+// that is not in the set is added with a score of delta.
+func (s *SortedSet) IncrBy(member string, delta float64) (float64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old, ok := s.scores[member]
+	score := old + delta
+	if math.IsNaN(score) {
+		return 0, ErrNaNScore
+	}
+	s.set(member, old, ok, score)
+	return score, nil
+}
+
+// set moves member from its old score, if it is a member, to score. The caller must hold the
+// set's mutex.
+func (s *SortedSet) set(member string, old float64, ok bool, score float64) {
+	if ok {
+		if old == score {
+			return
+		}
+		s.list.Remove(key(old, member))
+	}
+	s.scores[member] = score
+	s.list.Set(key(score, member), nil)
+}
+
+// Remove removes member from the set, returning whether it was a member.
+func (s *SortedSet) Remove(member string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	score, ok := s.scores[member]
+	if ok {
+		delete(s.scores, member)
+		s.list.Remove(key(score, member))
+	}
+	return ok
+}
+
+// Score returns the score of member, and whether it is a member.
+func (s *SortedSet) Score(member string) (float64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	score, ok := s.scores[member]
+	return score, ok
+}
+
+// Rank returns the zero-based position of member in score order, as ZRANK does, and whether it
+// is a member.
+func (s *SortedSet) Rank(member string) (int, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	score, ok := s.scores[member]
+	if !ok {
+		return 0, false
+	}
+	rank, _ := s.list.Rank(key(score, member))
+	return rank, true
+}
+
+// RangeByRank returns the members from position start to position stop inclusive, in score
+// order, as ZRANGE does. Negative positions count from the end of the set, -1 being the last
+// member. Positions beyond the set are clamped to it.
+func (s *SortedSet) RangeByRank(start, stop int) []Entry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	n := len(s.scores)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return nil
+	}
+
+	entries := make([]Entry, 0, stop-start+1)
+	for e := s.list.GetByRank(start); len(entries) < cap(entries); e = e.Next() {
+		entries = append(entries, entry(e.Key()))
+	}
+	return entries
+}
+
+// RangeByScore returns the members whose scores are between min and max inclusive, in score
+// order, as ZRANGEBYSCORE does.
+func (s *SortedSet) RangeByScore(min, max float64) []Entry {
+	start, end := scoreRange(min, max)
+	if start == nil {
+		return nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var entries []Entry
+	for it := s.list.Range(start, end); it.Valid(); it.Next() {
+		entries = append(entries, entry(it.Key()))
+	}
+	return entries
+}
+
+// CountByScore returns the number of members whose scores are between min and max inclusive,
+// as ZCOUNT does, in logarithmic time.
+func (s *SortedSet) CountByScore(min, max float64) int {
+	start, end := scoreRange(min, max)
+	if start == nil {
+		return 0
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.list.CountRange(start, end)
+}
+
+// scoreRange returns the range of keys of the scores between min and max inclusive, with a nil
+// end if it is unbounded, or a nil start if it is empty.
+func scoreRange(min, max float64) (start, end []byte) {
+	if math.IsNaN(min) || math.IsNaN(max) || min > max {
+		return nil, nil
+	}
+
+	start = encodeScore(nil, min)
+	if !math.IsInf(max, 1) {
+		end = encodeScore(nil, math.Nextafter(max, math.Inf(1)))
+	}
+	return start, end
+}
+
+// key returns the key of member with score.
+func key(score float64, member string) []byte {
+	buf := make([]byte, 0, scoreSize+len(member))
+	return append(encodeScore(buf, score), member...)
+}
+
+// encodeScore appends score to buf such that byte-wise order of encoded scores is numeric order:
+// the sign bit is flipped for positive numbers, and every bit for negative ones.
+func encodeScore(buf []byte, score float64) []byte {
+	if score == 0 {
+		// Negative zero equals zero, so it must encode the same.
+		score = 0
+	}
+	bits := math.Float64bits(score)
+	if bits>>63 == 0 {
+		bits |= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return binary.BigEndian.AppendUint64(buf, bits)
+}
+
+func entry(key []byte) Entry {
+	bits := binary.BigEndian.Uint64(key)
+	if bits>>63 == 1 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return Entry{Member: string(key[scoreSize:]), Score: math.Float64frombits(bits)}
+}
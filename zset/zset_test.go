@@ -0,0 +1,103 @@
+package zset
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestSortedSet(t *testing.T) {
+	s := New()
+	for member, score := range map[string]float64{"a": 3, "b": 1, "c": 2, "d": 2, "e": -1} {
+		if added, err := s.Add(member, score); !added || err != nil {
+			t.Fatal("wrong result of adding", member, added, err)
+		}
+	}
+	if added, _ := s.Add("a", 5); added || s.Len() != 5 {
+		t.Fatal("updating a score must not add a member", added, s.Len())
+	}
+
+	entries := s.RangeByRank(0, -1)
+	want := []Entry{{"e", -1}, {"b", 1}, {"c", 2}, {"d", 2}, {"a", 5}}
+	if len(entries) != len(want) {
+		t.Fatal("wrong range", entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Fatal("members must be ordered by score, then member", entries)
+		}
+	}
+
+	if score, err := s.IncrBy("b", 10); score != 11 || err != nil {
+		t.Fatal("wrong incremented score", score, err)
+	}
+	if score, _ := s.IncrBy("f", 0.5); score != 0.5 {
+		t.Fatal("incrementing a new member must add it", score)
+	}
+	if rank, ok := s.Rank("b"); rank != 5 || !ok {
+		t.Fatal("wrong rank after IncrBy", rank, ok)
+	}
+	if rank, ok := s.Rank("f"); rank != 1 || !ok {
+		t.Fatal("wrong rank of a new member", rank, ok)
+	}
+	if _, ok := s.Rank("z"); ok {
+		t.Fatal("non-members have no rank")
+	}
+
+	if r := s.RangeByScore(0.5, 2); len(r) != 3 || r[0].Member != "f" || r[2].Member != "d" {
+		t.Fatal("wrong range by score", r)
+	}
+	if n := s.CountByScore(math.Inf(-1), 2); n != 4 {
+		t.Fatal("wrong count by score", n)
+	}
+	if r := s.RangeByScore(5, math.Inf(1)); len(r) != 2 || r[0].Member != "a" || r[1].Member != "b" {
+		t.Fatal("wrong unbounded range by score", r)
+	}
+	if s.RangeByScore(3, 2) != nil || s.RangeByRank(4, 2) != nil || s.CountByScore(math.NaN(), 1) != 0 {
+		t.Fatal("empty ranges must return nothing")
+	}
+	if r := s.RangeByRank(-2, 100); len(r) != 2 || r[1].Member != "b" {
+		t.Fatal("wrong range by negative rank", r)
+	}
+
+	if !s.Remove("c") || s.Remove("c") || s.Len() != 5 {
+		t.Fatal("wrong removal")
+	}
+	if _, ok := s.Score("c"); ok {
+		t.Fatal("removed members must have no score")
+	}
+
+	if _, err := s.Add("n", math.NaN()); err != ErrNaNScore {
+		t.Fatal("NaN scores must be rejected", err)
+	}
+	s.Add("inf", math.Inf(1))
+	if _, err := s.IncrBy("inf", math.Inf(-1)); err != ErrNaNScore {
+		t.Fatal("increments resulting in NaN must be rejected", err)
+	}
+	if score, _ := s.Score("inf"); !math.IsInf(score, 1) {
+		t.Fatal("a rejected increment must leave the score unchanged", score)
+	}
+}
+
+func TestSortedSetScoreOrder(t *testing.T) {
+	s := New()
+	rng := rand.New(rand.NewSource(1))
+	scores := []float64{0, math.Copysign(0, -1), math.Inf(1), math.Inf(-1), math.MaxFloat64, -math.MaxFloat64,
+		math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64}
+	for i := 0; i < 200; i++ {
+		scores = append(scores, rng.NormFloat64()*1e6)
+	}
+	for i, score := range scores {
+		s.Add(strconv.Itoa(i), score)
+	}
+
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+	for i, e := range s.RangeByRank(0, -1) {
+		if e.Score != sorted[i] {
+			t.Fatal("scores must be in numeric order", i, e.Score, sorted[i])
+		}
+	}
+}